@@ -0,0 +1,9 @@
+package assets
+
+import "embed"
+
+// Emojis are the crewmate emojis the bot adds to guilds, au<color>.png and au<color>dead.png (see pkg/render, which
+// draws them on stats images)
+//
+//go:embed emojis/*.png
+var Emojis embed.FS
@@ -2,6 +2,7 @@ package bot
 
 import (
 	_ "embed"
+	"errors"
 	"github.com/automuteus/automuteus/v8/bot/command"
 	"github.com/automuteus/automuteus/v8/docs"
 	"github.com/automuteus/automuteus/v8/pkg/discord"
@@ -12,6 +13,7 @@ import (
 	"html/template"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -55,6 +57,7 @@ func (bot *Bot) StartAPIServer(port string) {
 		"admin": adminPassword,
 	}))
 	gameGroup.GET("/state", handleGetGameState(bot))
+	gameGroup.GET("/timeline", handleGetGameTimeline(bot))
 
 	// TODO same as above, but we also need to check the User's permissions within the server in question
 	// (aka if user is not a bot admin for a guild, they can't change that guild's settings)
@@ -198,6 +201,67 @@ func handleGetGameState(bot *Bot) func(c *gin.Context) {
 	}
 }
 
+// GetGameTimeline godoc
+// @Summary Get Game Timeline
+// @Schemes GET
+// @Description Get the rounds, meetings, deaths and time alive of every player of a game that was played
+// @Security BasicAuth
+// @Tags game
+// @Accept json
+// @Produce json
+// @Param guildID query string true "Guild ID"
+// @Param connectCode query string true "Connect Code"
+// @Param matchID query string true "Match ID"
+// @Success 200 {object} storage.MatchTimeline
+// @Failure 400 {string} HttpError
+// @Failure 404 {string} HttpError
+// @Failure 500 {object} HttpError
+// @Router /game/timeline [get]
+func handleGetGameTimeline(bot *Bot) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		guildID := c.Query("guildID")
+		if discord.ValidateSnowflake(guildID) != nil {
+			c.JSON(http.StatusBadRequest, HttpError{
+				StatusCode: http.StatusBadRequest,
+				Error:      "invalid guild ID",
+			})
+			return
+		}
+		connectCode := c.Query("connectCode")
+		if len(connectCode) != 8 {
+			c.JSON(http.StatusBadRequest, HttpError{
+				StatusCode: http.StatusBadRequest,
+				Error:      "invalid connect code",
+			})
+			return
+		}
+		matchID := c.Query("matchID")
+		if _, err := strconv.ParseInt(matchID, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, HttpError{
+				StatusCode: http.StatusBadRequest,
+				Error:      "invalid match ID",
+			})
+			return
+		}
+
+		timeline, err := bot.matchTimeline(guildID, matchID, connectCode)
+		if errors.Is(err, errGameNotFound) {
+			c.JSON(http.StatusNotFound, HttpError{
+				StatusCode: http.StatusNotFound,
+				Error:      err.Error(),
+			})
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, HttpError{
+				StatusCode: http.StatusInternalServerError,
+				Error:      err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, timeline)
+	}
+}
+
 // GetGuildSettings godoc
 // @Summary Get Guild Settings
 // @Schemes GET
@@ -14,6 +14,7 @@ import (
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	storageutils "github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/automuteus/automuteus/v8/pkg/task"
 	"github.com/automuteus/automuteus/v8/pkg/token"
 	"github.com/automuteus/automuteus/v8/storage"
 	"github.com/bwmarrin/discordgo"
@@ -31,14 +32,13 @@ type Bot struct {
 	official bool
 	url      string
 
-	// mapping of socket connections to the game connect codes
-	ConnsToGames map[string]string
+	// identifies this bot process when consuming capture jobs
+	nodeID string
 
-	StatusEmojis AlivenessEmojis
-
-	EndGameChannels map[string]chan EndGameMessage
+	// connect codes of the games this process is running a worker for (see SubscribeToGameByConnectCode)
+	gameWorkers sync.Map
 
-	ChannelsMapLock sync.RWMutex
+	StatusEmojis AlivenessEmojis
 
 	PrimarySession *discordgo.Session
 
@@ -77,11 +77,9 @@ func MakeAndStartBot(version, commit, botToken, topGGToken, url, emojiGuildID st
 		commit:       commit,
 		official:     os.Getenv("AUTOMUTEUS_OFFICIAL") != "",
 		url:          url,
-		ConnsToGames: make(map[string]string),
+		nodeID:       makeNodeID(shardID),
 		StatusEmojis: emptyStatusEmojis(),
 
-		EndGameChannels:   make(map[string]chan EndGameMessage),
-		ChannelsMapLock:   sync.RWMutex{},
 		PrimarySession:    dg,
 		RedisInterface:    redisInterface,
 		StorageInterface:  storageInterface,
@@ -151,9 +149,21 @@ func MakeAndStartBot(version, commit, botToken, topGGToken, url, emojiGuildID st
 		log.Println("No TOP_GG_TOKEN provided")
 	}
 
+	go bot.adoptOrphanedGamesWorker()
+	go bot.editSchedulerWorker()
+	go bot.scheduleWorker()
+
 	return &bot
 }
 
+func makeNodeID(shardID int) string {
+	nodeID := os.Getenv("SCW_NODE_ID")
+	if nodeID == "" {
+		nodeID, _ = os.Hostname()
+	}
+	return fmt.Sprintf("%s:%d", nodeID, shardID)
+}
+
 func (bot *Bot) InitTokenProvider(tp *tokenprovider.TokenProvider) {
 	tp.Init(bot.RedisInterface.client, bot.PrimarySession)
 }
@@ -162,6 +172,11 @@ func (bot *Bot) StartMetricsServer(nodeID string) error {
 	return server.PrometheusMetricsServer(bot.RedisInterface.client, nodeID, "2112")
 }
 
+// StartIngestServer accepts capture clients directly, instead of through a separate broker
+func (bot *Bot) StartIngestServer(port string) error {
+	return server.StartIngestServer(bot.RedisInterface.client, port)
+}
+
 func (bot *Bot) Close() {
 	bot.PrimarySession.Close()
 	bot.RedisInterface.Close()
@@ -207,31 +222,7 @@ func (bot *Bot) newGuild(emojiGuildID string) func(s *discordgo.Session, m *disc
 		}
 		EmojiLock.Unlock()
 
-		games := bot.RedisInterface.LoadAllActiveGames(m.Guild.ID)
-
-		for _, connCode := range games {
-			gsr := GameStateRequest{
-				GuildID:     m.Guild.ID,
-				ConnectCode: connCode,
-			}
-			lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
-			for lock == nil {
-				lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
-			}
-			if dgs != nil && dgs.ConnectCode != "" {
-				log.Println("Resubscribing to Redis events for an old game: " + connCode)
-				killChan := make(chan EndGameMessage)
-				go bot.SubscribeToGameByConnectCode(gsr.GuildID, dgs.ConnectCode, killChan)
-				dgs.Subscribed = true
-
-				bot.RedisInterface.SetDiscordGameState(dgs, lock)
-
-				bot.ChannelsMapLock.Lock()
-				bot.EndGameChannels[dgs.ConnectCode] = killChan
-				bot.ChannelsMapLock.Unlock()
-			}
-			lock.Release(ctx)
-		}
+		bot.recoverGames(m.Guild)
 	}
 }
 
@@ -264,6 +255,37 @@ func (bot *Bot) forceEndGame(gsr GameStateRequest) {
 
 	// Note, this shouldn't be necessary with the TTL of the keys, but it can't hurt to clean up...
 	bot.RedisInterface.DeleteDiscordGameState(dgs)
+
+	err := task.DeleteJobStream(ctx, bot.RedisInterface.client, gsr.ConnectCode)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+var errGameStateLocked = errors.New("could not lock the game state")
+
+// togglePause pauses the game and unmutes/undeafens everyone, or resumes the game if it was paused. Normally run by the
+// node that owns the game, in response to a task.ControlPause message
+func (bot *Bot) togglePause(gsr GameStateRequest) error {
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		return errGameStateLocked
+	}
+	if !dgs.GameStateMsg.Exists() {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return nil
+	}
+
+	dgs.Running = !dgs.Running
+
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	var err error
+	// if we paused the game, unmute/undeafen all players
+	if !dgs.Running {
+		err = bot.applyToAll(dgs, false, false)
+	}
+	bot.DispatchRefreshOrEdit(dgs, gsr, bot.StorageInterface.GetGuildSettings(dgs.GuildID))
+	return err
 }
 
 func MessageDeleteWorker(s *discordgo.Session, msgChannelID, msgID string, waitDur time.Duration) {
@@ -282,7 +304,7 @@ func (bot *Bot) RefreshGameStateMessage(gsr GameStateRequest, sett *settings.Gui
 	}
 
 	// don't try to edit this message, because we're about to delete it
-	RemovePendingDGSEdit(dgs.GameStateMsg.MessageID)
+	bot.RedisInterface.RemovePendingDGSEdit(dgs.GameStateMsg.MessageID)
 
 	// note, this checks the variables being set, not whether or not the actual Discord message still exists
 	gameExists := dgs.GameStateMsg.Exists()
@@ -329,7 +351,7 @@ func (bot *Bot) getInfo() command.BotInfo {
 	}
 }
 
-func linkPlayer(redis *RedisInterface, dgs *GameState, userID, color string) (command.LinkStatus, error) {
+func linkPlayer(psql *storageutils.PsqlInterface, dgs *GameState, userID, color string) (command.LinkStatus, error) {
 	var auData amongus.PlayerData
 	found := false
 	if game.IsColorString(color) {
@@ -338,9 +360,11 @@ func linkPlayer(redis *RedisInterface, dgs *GameState, userID, color string) (co
 	if found {
 		foundID := dgs.AttemptPairingByUserIDs(auData, map[string]interface{}{userID: struct{}{}})
 		if foundID != "" {
-			err := redis.AddUsernameLink(dgs.GuildID, userID, auData.Name)
-			if err != nil {
-				log.Println(err)
+			if psql != nil {
+				err := psql.AddPlayerAlias(dgs.GuildID, userID, auData.Name, auData.Color)
+				if err != nil {
+					log.Println(err)
+				}
 			}
 			return command.LinkSuccess, nil
 		} else {
@@ -376,10 +400,7 @@ func getTrackingChannel(guild *discordgo.Guild, userID string) string {
 
 func (bot *Bot) newGame(dgs *GameState) (_ command.NewStatus, activeGames int64) {
 	if dgs.GameStateMsg.Exists() {
-		if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
-			v <- true
-		}
-		delete(bot.EndGameChannels, dgs.ConnectCode)
+		bot.sendGameControl(dgs.ConnectCode, task.ControlEnd)
 
 		dgs.Reset()
 	} else {
@@ -34,6 +34,8 @@ var All = []*discordgo.ApplicationCommand{
 	&Premium,
 	&Debug,
 	&Download,
+	&Schedule,
+	&Season,
 }
 
 // ===== スラッシュコマンド有効・無効設定 =====
@@ -56,6 +58,8 @@ var EnabledSlashCommands = map[string]bool{
 	"premium":  false,
 	"debug":    false,
 	"download": false,
+	"schedule": true,
+	"season":   false,
 }
 
 // EnabledCommands は EnabledSlashCommands で true のコマンドだけを返します。
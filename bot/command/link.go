@@ -1,8 +1,12 @@
 package command
 
 import (
+	"bytes"
+	"fmt"
 	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
 	"github.com/bwmarrin/discordgo"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"strings"
@@ -16,28 +20,71 @@ const (
 	LinkNoGameData
 )
 
+const (
+	LinkPlayer  = "player"
+	LinkAliases = "aliases"
+	LinkForget  = "forget"
+)
+
 var Link = discordgo.ApplicationCommand{
 	Name:        "link",
 	Description: "ディスコード名とアモアス名に手動リンクします",
 	Options: []*discordgo.ApplicationCommandOption{
 		{
-			Type:        discordgo.ApplicationCommandOptionUser,
-			Name:        "user",
-			Description: "User to link",
-			Required:    true,
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        LinkPlayer,
+			Description: "ディスコード名とアモアス名に手動リンクします",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "User to link",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "color",
+					Description: "In-game color",
+					Required:    true,
+					Choices:     colorsToCommandChoices(),
+				},
+			},
 		},
 		{
-			Type:        discordgo.ApplicationCommandOptionString,
-			Name:        "color",
-			Description: "In-game color",
-			Required:    true,
-			Choices:     colorsToCommandChoices(),
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        LinkAliases,
+			Description: "自分にリンクされたことのあるアモアス名を表示します",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        LinkForget,
+			Description: "自分にリンクされたアモアス名の記録を削除します",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "In-game name to forget (all of them if empty)",
+					Required:    false,
+				},
+			},
 		},
 	},
 }
 
-func GetLinkParams(s *discordgo.Session, options []*discordgo.ApplicationCommandInteractionDataOption) (string, string) {
-	return options[0].UserValue(s).ID, strings.ReplaceAll(strings.ToLower(options[1].StringValue()), " ", "")
+// GetLinkParams returns the subcommand, and for LinkPlayer the user and color to link, or for LinkForget the name
+// to forget
+func GetLinkParams(s *discordgo.Session, options []*discordgo.ApplicationCommandInteractionDataOption) (action, userID, value string) {
+	action = options[0].Name
+	switch action {
+	case LinkPlayer:
+		opts := options[0].Options
+		return action, opts[0].UserValue(s).ID, strings.ReplaceAll(strings.ToLower(opts[1].StringValue()), " ", "")
+	case LinkForget:
+		if len(options[0].Options) > 0 {
+			return action, "", options[0].Options[0].StringValue()
+		}
+	}
+	return action, "", ""
 }
 
 func LinkResponse(status LinkStatus, userID, color string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
@@ -75,3 +122,61 @@ func LinkResponse(status LinkStatus, userID, color string, sett *settings.GuildS
 		},
 	}
 }
+
+func LinkAliasesResponse(aliases []*storage.PostgresPlayerAlias, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Link.Name+" "+LinkAliases, err, sett)
+	}
+	if len(aliases) == 0 {
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.link.aliases.none",
+			Other: "❌ I don't remember any in-game names for you!",
+		}))
+	}
+	buf := bytes.NewBufferString(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.link.aliases.list",
+		Other: "📇 In-game names you were linked to (name / color / times confirmed / last seen):",
+	}))
+	buf.WriteString("\n```\n")
+	for _, alias := range aliases {
+		color := "-"
+		if alias.PlayerColor != nil {
+			if c := game.GetColorStringForInt(int(*alias.PlayerColor)); c != "" {
+				color = c
+			}
+		}
+		buf.WriteString(fmt.Sprintf("%-10s %-8s %3d %s\n", alias.PlayerName, color, alias.TimesConfirmed,
+			alias.LastSeen.Format("2006-01-02")))
+	}
+	buf.WriteString("```")
+	return PrivateResponse(buf.String())
+}
+
+func LinkForgetResponse(name string, deleted int64, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Link.Name+" "+LinkForget, err, sett)
+	}
+	var content string
+	switch {
+	case deleted == 0:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.link.forget.none",
+			Other: "❌ There was nothing to forget!",
+		})
+	case name == "":
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.link.forget.all",
+			Other: "🗑️ Forgot all {{.Count}} of your in-game names",
+		}, map[string]interface{}{
+			"Count": deleted,
+		})
+	default:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.link.forget.one",
+			Other: "🗑️ Forgot the in-game name `{{.Name}}`",
+		}, map[string]interface{}{
+			"Name": name,
+		})
+	}
+	return PrivateResponse(content)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings" // ホストURL整形用
 
+	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/bwmarrin/discordgo"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -29,6 +30,29 @@ type NewInfo struct {
 var New = discordgo.ApplicationCommand{
 	Name:        "start",
 	Description: "オートミュートを開始します",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "mode",
+			Description: "この試合のモード（省略時はロビーから自動判定、またはサーバーの設定）",
+			Required:    false,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: game.ClassicMode, Value: game.ClassicMode},
+				{Name: game.TownOfUsMode, Value: game.TownOfUsMode},
+				{Name: "hns", Value: game.HideAndSeekMode},
+			},
+		},
+	},
+}
+
+// GetNewParams returns the mode picked for the game, or "" to detect it from the lobby
+func GetNewParams(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	for _, opt := range options {
+		if opt.Name == "mode" {
+			return game.GetGameMode(opt.StringValue()).Name
+		}
+	}
+	return ""
 }
 
 func NewResponse(status NewStatus, info NewInfo, sett *settings.GuildSettings) *discordgo.InteractionResponse {
@@ -0,0 +1,241 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/schedule"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"strconv"
+)
+
+const (
+	ScheduleCreate = "create"
+	ScheduleList   = "list"
+	ScheduleCancel = "cancel"
+)
+
+var Schedule = discordgo.ApplicationCommand{
+	Name:        "schedule",
+	Description: "定期開催のゲームを予約します",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        ScheduleCreate,
+			Description: "ゲームの開催日時を予約します",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "title",
+					Description: "Name of the game night",
+					Required:    true,
+					MaxLength:   100,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "date",
+					Description: "Date of the first game, like 2024-03-08",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "time",
+					Description: "Time the game starts, like 21:00",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "voice",
+					Description:  "Voice channel to play in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "repeat",
+					Description: "How often the game repeats (default: once)",
+					Required:    false,
+					Choices:     recurrenceChoices(),
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "host_role",
+					Description: "Role to ping with the reminder",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "timezone",
+					Description: "Timezone of the date and time, like Asia/Tokyo (default: the bot's timezone)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        ScheduleList,
+			Description: "予約されたゲームを表示します",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        ScheduleCancel,
+			Description: "予約されたゲームを取り消します",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "ID of the scheduled game (see /schedule list)",
+					Required:    true,
+				},
+			},
+		},
+	},
+}
+
+func recurrenceChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, r := range schedule.Recurrences {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  string(r),
+			Value: string(r),
+		})
+	}
+	return choices
+}
+
+type ScheduleParams struct {
+	Title          string
+	Date           string
+	Time           string
+	VoiceChannelID string
+	Recurrence     schedule.Recurrence
+	HostRoleID     string
+	Timezone       string
+	SessionID      int64
+}
+
+// GetScheduleParams returns the subcommand, and its options
+func GetScheduleParams(s *discordgo.Session, options []*discordgo.ApplicationCommandInteractionDataOption) (string, ScheduleParams) {
+	params := ScheduleParams{Recurrence: schedule.Once}
+	for _, opt := range options[0].Options {
+		switch opt.Name {
+		case "title":
+			params.Title = opt.StringValue()
+		case "date":
+			params.Date = opt.StringValue()
+		case "time":
+			params.Time = opt.StringValue()
+		case "voice":
+			params.VoiceChannelID = opt.ChannelValue(s).ID
+		case "repeat":
+			params.Recurrence = schedule.ParseRecurrence(opt.StringValue())
+		case "host_role":
+			params.HostRoleID = opt.RoleValue(s, "").ID
+		case "timezone":
+			params.Timezone = opt.StringValue()
+		case "id":
+			params.SessionID = opt.IntValue()
+		}
+	}
+	return options[0].Name, params
+}
+
+// DiscordTimestamp formats a time so every user sees it in their own timezone
+func DiscordTimestamp(unix int64, style string) string {
+	return fmt.Sprintf("<t:%d:%s>", unix, style)
+}
+
+func ScheduleCreateResponse(session *storage.PostgresScheduledSession, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	switch {
+	case errors.Is(err, schedule.ErrInPast):
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.create.past",
+			Other: "❌ That date and time is already in the past!",
+		}))
+	case err != nil:
+		return PrivateErrorResponse(Schedule.Name+" "+ScheduleCreate, err, sett)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.schedule.create.success",
+				Other: "📅 Scheduled **{{.Title}}** (ID `{{.ID}}`) for {{.Start}} in {{.Voice}}, repeating: {{.Repeat}}",
+			}, map[string]interface{}{
+				"Title":  session.Title,
+				"ID":     session.SessionID,
+				"Start":  DiscordTimestamp(session.StartTime.Unix(), "F"),
+				"Voice":  discord.MentionByChannelID(strconv.FormatUint(session.VoiceChannelID, 10)),
+				"Repeat": session.Recurrence,
+			}),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	}
+}
+
+func ScheduleListResponse(sessions []*storage.PostgresScheduledSession, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Schedule.Name+" "+ScheduleList, err, sett)
+	}
+	if len(sessions) == 0 {
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.list.none",
+			Other: "No games are scheduled. Use `/schedule create` to schedule one!",
+		}))
+	}
+	buf := bytes.NewBufferString("")
+	for _, session := range sessions {
+		buf.WriteString(fmt.Sprintf("`%d` **%s** %s (%s, %s) %s\n", session.SessionID, session.Title,
+			DiscordTimestamp(session.StartTime.Unix(), "F"), session.Recurrence, session.Timezone,
+			discord.MentionByChannelID(strconv.FormatUint(session.VoiceChannelID, 10))))
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.schedule.list.title",
+						Other: "Scheduled games",
+					}),
+					Description: buf.String(),
+					Color:       15844367, // GOLD
+				},
+			},
+		},
+	}
+}
+
+func ScheduleCancelResponse(sessionID, deleted int64, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Schedule.Name+" "+ScheduleCancel, err, sett)
+	}
+	if deleted == 0 {
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.cancel.notfound",
+			Other: "❌ There's no scheduled game with the ID `{{.ID}}`",
+		}, map[string]interface{}{
+			"ID": sessionID,
+		}))
+	}
+	return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.schedule.cancel.success",
+		Other: "🗑️ Cancelled the scheduled game `{{.ID}}`",
+	}, map[string]interface{}{
+		"ID": sessionID,
+	}))
+}
+
+func ScheduleInvalidTimezoneResponse(timezone string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.schedule.create.timezone",
+		Other: "❌ I don't know the timezone `{{.Timezone}}`. Use a name like `Asia/Tokyo` or `America/New_York`",
+	}, map[string]interface{}{
+		"Timezone": timezone,
+	}))
+}
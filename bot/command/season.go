@@ -0,0 +1,215 @@
+package command
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/schedule"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"time"
+)
+
+const (
+	SeasonCreate = "create"
+	SeasonList   = "list"
+	SeasonDelete = "delete"
+)
+
+var Season = discordgo.ApplicationCommand{
+	Name:        "season",
+	Description: "統計を集計するシーズンを設定します",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        SeasonCreate,
+			Description: "シーズンを作成します",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Name of the season",
+					Required:    true,
+					MaxLength:   100,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "start",
+					Description: "First day of the season, like 2024-04-01",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "end",
+					Description: "Last day of the season, like 2024-06-30",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "timezone",
+					Description: "Timezone of the dates, like Asia/Tokyo (default: the bot's timezone)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        SeasonList,
+			Description: "シーズンを表示します",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Name:        SeasonDelete,
+			Description: "シーズンを削除します（ゲームの記録は残ります）",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Name of the season",
+					Required:    true,
+				},
+			},
+		},
+	},
+}
+
+type SeasonParams struct {
+	Name     string
+	Start    string
+	End      string
+	Timezone string
+}
+
+// GetSeasonParams returns the subcommand, and its options
+func GetSeasonParams(options []*discordgo.ApplicationCommandInteractionDataOption) (string, SeasonParams) {
+	var params SeasonParams
+	for _, opt := range options[0].Options {
+		switch opt.Name {
+		case "name":
+			params.Name = opt.StringValue()
+		case "start":
+			params.Start = opt.StringValue()
+		case "end":
+			params.End = opt.StringValue()
+		case "timezone":
+			params.Timezone = opt.StringValue()
+		}
+	}
+	return options[0].Name, params
+}
+
+// SeasonDates shows a season's first and last day; the end is exclusive, so the last day is the one before it
+func SeasonDates(season *storage.PostgresSeason) string {
+	return fmt.Sprintf("%s - %s", DiscordTimestamp(season.StartTime.Unix(), "D"),
+		DiscordTimestamp(season.EndTime.Add(-time.Second).Unix(), "D"))
+}
+
+func SeasonCreateResponse(season *storage.PostgresSeason, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	switch {
+	case errors.Is(err, storage.ErrSeasonExists):
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.season.create.exists",
+			Other: "❌ There's already a season called **{{.Name}}**",
+		}, map[string]interface{}{
+			"Name": season.Name,
+		}))
+	case err != nil:
+		return StatsRangeErrorResponse(Season.Name+" "+SeasonCreate, err, sett)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.season.create.success",
+				Other: "🏆 Created the season **{{.Name}}**, {{.Dates}}. Its stats can be viewed with `/stats view guild season:{{.Name}}`",
+			}, map[string]interface{}{
+				"Name":  season.Name,
+				"Dates": SeasonDates(season),
+			}),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	}
+}
+
+func SeasonListResponse(seasons []*storage.PostgresSeason, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Season.Name+" "+SeasonList, err, sett)
+	}
+	if len(seasons) == 0 {
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.season.list.none",
+			Other: "This server has no seasons. Use `/season create` to create one!",
+		}))
+	}
+	buf := bytes.NewBufferString("")
+	for _, season := range seasons {
+		buf.WriteString(fmt.Sprintf("**%s** %s\n", season.Name, SeasonDates(season)))
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.season.list.title",
+						Other: "Seasons",
+					}),
+					Description: buf.String(),
+					Color:       15844367, // GOLD
+				},
+			},
+		},
+	}
+}
+
+func SeasonDeleteResponse(name string, deleted int64, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if err != nil {
+		return PrivateErrorResponse(Season.Name+" "+SeasonDelete, err, sett)
+	}
+	if deleted == 0 {
+		return SeasonNotFoundResponse(name, sett)
+	}
+	return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.season.delete.success",
+		Other: "🗑️ Deleted the season **{{.Name}}**. Its games still count towards the server's stats",
+	}, map[string]interface{}{
+		"Name": name,
+	}))
+}
+
+func SeasonNotFoundResponse(name string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.season.notfound",
+		Other: "❌ There's no season called **{{.Name}}**. Use `/season list` to see the server's seasons",
+	}, map[string]interface{}{
+		"Name": name,
+	}))
+}
+
+// StatsRangeErrorResponse explains why the dates a season or stats were asked for couldn't be used
+func StatsRangeErrorResponse(command string, err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	var parseErr *time.ParseError
+	switch {
+	case errors.Is(err, ErrSeasonWithDates):
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.season.range.seasonWithDates",
+			Other: "❌ A season already has its own days, so give either `season` or `from`/`to`, not both",
+		}))
+	case errors.Is(err, schedule.ErrEndBeforeStart):
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.season.range.endBeforeStart",
+			Other: "❌ The last day is before the first day!",
+		}))
+	case errors.As(err, &parseErr):
+		return PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.season.range.format",
+			Other: "❌ I couldn't read the date `{{.Date}}`. Use the format `2024-04-01`",
+		}, map[string]interface{}{
+			"Date": parseErr.Value,
+		}))
+	}
+	return PrivateErrorResponse(command, err, sett)
+}
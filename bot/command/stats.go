@@ -1,6 +1,7 @@
 package command
 
 import (
+	"errors"
 	"github.com/automuteus/automuteus/v8/bot/setting"
 	"github.com/bwmarrin/discordgo"
 )
@@ -8,6 +9,32 @@ import (
 const (
 	Match = "match"
 	Guild = "guild"
+	Lobby = "lobby"
+)
+
+const (
+	StatsSeason = "season"
+	StatsFrom   = "from"
+	StatsTo     = "to"
+)
+
+// viewing stats for a season or a range of days
+var (
+	statsSeasonOption = &discordgo.ApplicationCommandOption{
+		Name:        StatsSeason,
+		Description: "Only count games in this season (see /season list)",
+		Type:        discordgo.ApplicationCommandOptionString,
+	}
+	statsFromOption = &discordgo.ApplicationCommandOption{
+		Name:        StatsFrom,
+		Description: "Only count games from this day on, like 2024-04-01",
+		Type:        discordgo.ApplicationCommandOptionString,
+	}
+	statsToOption = &discordgo.ApplicationCommandOption{
+		Name:        StatsTo,
+		Description: "Only count games up to this day, like 2024-06-30",
+		Type:        discordgo.ApplicationCommandOptionString,
+	}
 )
 
 var Stats = discordgo.ApplicationCommand{
@@ -30,6 +57,9 @@ var Stats = discordgo.ApplicationCommand{
 							Type:        discordgo.ApplicationCommandOptionUser,
 							Required:    true,
 						},
+						statsSeasonOption,
+						statsFromOption,
+						statsToOption,
 					},
 				},
 				{
@@ -49,6 +79,16 @@ var Stats = discordgo.ApplicationCommand{
 					Name:        Guild,
 					Description: "View this guild's stats",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						statsSeasonOption,
+						statsFromOption,
+						statsToOption,
+					},
+				},
+				{
+					Name:        Lobby,
+					Description: "View how balanced the players linked to this channel's game are",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
 				},
 			},
 		},
@@ -84,12 +124,45 @@ func GetStatsParams(s *discordgo.Session, guildID string, options []*discordgo.A
 	action = options[0].Name
 	opType = options[0].Options[0].Name
 	switch opType {
-	case User:
-		id = options[0].Options[0].Options[0].UserValue(s).ID
-	case Guild:
+	case Guild, Lobby:
 		id = guildID
-	case Match:
-		id = options[0].Options[0].Options[0].StringValue()
+	}
+	// the season and dates can come before the user
+	for _, opt := range options[0].Options[0].Options {
+		switch opt.Name {
+		case User:
+			id = opt.UserValue(s).ID
+		case Match:
+			id = opt.StringValue()
+		}
 	}
 	return action, opType, id
 }
+
+type StatsRangeParams struct {
+	Season string
+	From   string
+	To     string
+}
+
+var ErrSeasonWithDates = errors.New("a season can't be combined with days")
+
+// GetStatsRangeParams returns the season or the days stats were asked for. Everything is empty for all time
+func GetStatsRangeParams(options []*discordgo.ApplicationCommandInteractionDataOption) (StatsRangeParams, error) {
+	var params StatsRangeParams
+	for _, opt := range options[0].Options[0].Options {
+		switch opt.Name {
+		case StatsSeason:
+			params.Season = opt.StringValue()
+		case StatsFrom:
+			params.From = opt.StringValue()
+		case StatsTo:
+			params.To = opt.StringValue()
+		}
+	}
+	// the season already has its own days
+	if params.Season != "" && (params.From != "" || params.To != "") {
+		return params, ErrSeasonWithDates
+	}
+	return params, nil
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"testing"
+)
+
+func statsViewOptions(options ...*discordgo.ApplicationCommandInteractionDataOption) []*discordgo.ApplicationCommandInteractionDataOption {
+	return []*discordgo.ApplicationCommandInteractionDataOption{
+		&discordgo.ApplicationCommandInteractionDataOption{
+			Name: "view",
+			Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				&discordgo.ApplicationCommandInteractionDataOption{
+					Name:    Guild,
+					Type:    discordgo.ApplicationCommandOptionSubCommand,
+					Options: options,
+				},
+			},
+		},
+	}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+func TestGetStatsRangeParams(t *testing.T) {
+	params, err := GetStatsRangeParams(statsViewOptions(stringOption(StatsFrom, "2024-04-01"), stringOption(StatsTo, "2024-06-30")))
+	if err != nil || params.From != "2024-04-01" || params.To != "2024-06-30" {
+		t.Errorf("expected the days to be read, got %v (%v)", params, err)
+	}
+	params, err = GetStatsRangeParams(statsViewOptions(stringOption(StatsSeason, "Spring")))
+	if err != nil || params.Season != "Spring" {
+		t.Errorf("expected the season to be read, got %v (%v)", params, err)
+	}
+	_, err = GetStatsRangeParams(statsViewOptions(stringOption(StatsSeason, "Spring"), stringOption(StatsFrom, "2024-04-01")))
+	if !errors.Is(err, ErrSeasonWithDates) {
+		t.Errorf("expected a season with days to be rejected, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/automuteus/automuteus/v8/pkg/amongus"
+	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/bwmarrin/discordgo"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
@@ -27,9 +28,16 @@ type GameState struct {
 
 	UserData     UserDataSet       `json:"userData"`
 	DisplayNames map[string]string `json:"displayNames"` // 追加: userID -> 表示名（ニックネーム優先）
-	VoiceChannel string            `json:"voiceChannel"`
-	GameStateMsg GameStateMessage  `json:"gameStateMessage"`
-	GameData     amongus.GameData  `json:"amongUsData"`
+	// in-game name -> a link waiting for confirmation
+	LinkSuggestions map[string]LinkSuggestion `json:"linkSuggestions,omitempty"`
+	// users waiting for a slot in the lobby, in order
+	WaitingList  []string         `json:"waitingList,omitempty"`
+	VoiceChannel string           `json:"voiceChannel"`
+	GameStateMsg GameStateMessage `json:"gameStateMessage"`
+	GameData     amongus.GameData `json:"amongUsData"`
+	// the mode this game is played in, when the capture detected it from the lobby or it was picked with /start.
+	// Empty means the guild's preset
+	GameMode string `json:"gameMode,omitempty"`
 
 	// ===== 追加: AmongUsCapture 接続状態 =====
 	CaptureConnected bool  `json:"captureConnected"`
@@ -54,15 +62,39 @@ func (dgs *GameState) Reset() {
 	dgs.MatchStartUnix = -1
 	dgs.UserData = map[string]UserData{}
 	dgs.DisplayNames = map[string]string{} // 表示名キャッシュもリセット
+	dgs.LinkSuggestions = map[string]LinkSuggestion{}
+	dgs.WaitingList = []string{}
 	dgs.VoiceChannel = ""
 	dgs.GameStateMsg = MakeGameStateMessage()
 	dgs.GameData = amongus.NewGameData()
+	dgs.GameMode = ""
 
 	// ===== 追加: Capture未接続で初期化 =====
 	dgs.CaptureConnected = false
 	dgs.LastCapturePing = 0
 }
 
+// getGameMode is the mode the current game is played in
+func (dgs *GameState) getGameMode(sett *settings.GuildSettings) game.GameMode {
+	if dgs.GameMode != "" {
+		return game.GetGameMode(dgs.GameMode)
+	}
+	return sett.GetGameMode()
+}
+
+// setLobbyMode follows the mode the capture read from the lobby. A lobby that isn't hide-and-seek only undoes a
+// detected hide-and-seek, so the guild's preset (or the mode picked with /start) applies again
+func (dgs *GameState) setLobbyMode(mode game.LobbyMode) {
+	switch mode {
+	case game.HideAndSeekLobbyMode:
+		dgs.GameMode = game.HideAndSeekMode
+	case game.NormalLobbyMode:
+		if dgs.GameMode == game.HideAndSeekMode {
+			dgs.GameMode = ""
+		}
+	}
+}
+
 // ギルドメンバー情報をキャッシュしつつ UserData を作成
 func (dgs *GameState) checkCacheAndAddUser(g *discordgo.Guild, s *discordgo.Session, userID string) (UserData, bool) {
 	if g == nil {
@@ -15,17 +15,58 @@ import (
 	"github.com/go-redis/redis/v8"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"log"
+	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
-type EndGameMessage bool
+// startGameWorker takes ownership of the game and starts processing its capture events, unless another node owns it
+// already. Returns true if this node owns the game
+func (bot *Bot) startGameWorker(guildID, connectCode string) bool {
+	owned := bot.acquireGame(guildID, connectCode)
+	if owned {
+		go bot.SubscribeToGameByConnectCode(guildID, connectCode)
+	}
+	return owned
+}
+
+// runningGameWorker checks if this process is already processing the game
+func (bot *Bot) runningGameWorker(connectCode string) bool {
+	_, running := bot.gameWorkers.Load(connectCode)
+	return running
+}
 
-func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGameChannel chan EndGameMessage) {
+func (bot *Bot) acquireGame(guildID, connectCode string) bool {
+	lease := task.GameLease{GuildID: guildID, ConnectCode: connectCode}
+	owned, err := task.AcquireGameLease(ctx, bot.RedisInterface.client, lease, bot.nodeID)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return owned
+}
+
+// SubscribeToGameByConnectCode processes the capture events for a game until it ends, times out, or this node loses
+// its lease on the game. Use startGameWorker to take the lease first
+func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string) {
+	// the lease is per node, so it doesn't stop this node from starting a second worker for a game it's already running
+	if _, running := bot.gameWorkers.LoadOrStore(connectCode, struct{}{}); running {
+		log.Printf("Already running a Redis Subscription worker for %s\n", connectCode)
+		return
+	}
+	defer bot.gameWorkers.Delete(connectCode)
 	log.Println("Started Redis Subscription worker for " + connectCode)
 
 	notify := task.Subscribe(ctx, bot.RedisInterface.client, connectCode)
+	control := task.SubscribeControl(ctx, bot.RedisInterface.client, connectCode)
+	defer func() {
+		for _, pubsub := range []*redis.PubSub{notify, control} {
+			err := pubsub.Close()
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}()
 
 	timer := time.NewTimer(time.Second * time.Duration(bot.captureTimeout))
 
@@ -33,275 +74,402 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 		GuildID:     guildID,
 		ConnectCode: connectCode,
 	}
+	lease := task.GameLease{GuildID: guildID, ConnectCode: connectCode}
+
+	err := task.EnsureJobGroup(ctx, bot.RedisInterface.client, connectCode)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// pick up anything a previous worker for this game left unacknowledged, plus anything queued before we subscribed
+	bot.reclaimJobs(connectCode)
+	bot.drainJobs(dgsRequest)
+
+	reclaimTicker := time.NewTicker(task.JobReclaimTimeout)
+	defer reclaimTicker.Stop()
+
+	leaseTicker := time.NewTicker(task.GameLeaseRenewInterval)
+	defer leaseTicker.Stop()
 
 	// indicate to the broker that we're online and ready to start processing messages
 	task.Ack(ctx, bot.RedisInterface.client, connectCode)
 
 	for {
 		select {
+		case <-reclaimTicker.C:
+			if bot.reclaimJobs(connectCode) > 0 {
+				bot.drainJobs(dgsRequest)
+			}
+
+		case <-leaseTicker.C:
+			owned, err := task.RenewGameLease(ctx, bot.RedisInterface.client, lease, bot.nodeID)
+			if err != nil {
+				// keep going; the lease is good for a few more renewals
+				log.Println(err)
+			} else if !owned {
+				log.Printf("Lost ownership of game %s to another node, stopping its worker\n", connectCode)
+				return
+			}
+
 		case message := <-notify.Channel():
 			timer.Reset(time.Second * time.Duration(bot.captureTimeout))
 			if message == nil {
 				break
 			}
 
-			// anytime we get a notification message, continue pulling messages off the list until there are no more
-			for {
-				job, err := task.PopJob(ctx, bot.RedisInterface.client, connectCode)
-				if errors.Is(err, redis.Nil) {
-					break
-				} else if err != nil {
+			// anytime we get a notification message, continue pulling jobs off the stream until there are no more
+			bot.drainJobs(dgsRequest)
+
+		case <-timer.C:
+			timer.Stop()
+			log.Printf("Killing game w/ code %s after %d seconds of inactivity!\n", connectCode, bot.captureTimeout)
+			bot.releaseGame(lease)
+			go bot.forceEndGame(dgsRequest)
+			return
+
+		case message := <-control.Channel():
+			if message == nil {
+				break
+			}
+			switch task.ControlMessage(message.Payload) {
+			case task.ControlEnd:
+				log.Println("Redis subscriber received kill signal, closing all pubsubs")
+				bot.releaseGame(lease)
+				bot.forceEndGame(dgsRequest)
+				return
+			case task.ControlPause:
+				err := bot.togglePause(dgsRequest)
+				if err != nil {
 					log.Println(err)
-					break
-				}
-				log.Printf("Popped job of type %d w/ payload %s\n", job.JobType, job.Payload.(string))
-				bot.refreshGameLiveness(connectCode)
-				bot.RedisInterface.RefreshActiveGame(guildID, connectCode)
-
-				gameEvent := storage.PostgresGameEvent{
-					GameID:    -1,
-					UserID:    nil,
-					EventTime: int32(time.Now().Unix()),
-					EventType: int16(job.JobType),
-					Payload:   job.Payload.(string),
 				}
-				correlatedUserID := ""
-				sett := bot.StorageInterface.GetGuildSettings(guildID)
-
-				switch job.JobType {
-
-				// ======================================================
-				// ★ ConnectionJob = Capture の接続/切断通知
-				// ======================================================
-				case task.ConnectionJob:
-					lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
-					for lock == nil {
-						lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
-					}
+			}
+		}
+	}
+}
 
-					// 変更前の接続状態を保持（変化があったときだけ Refresh）
-					prevCapture := dgs.CaptureConnected
+func (bot *Bot) releaseGame(lease task.GameLease) {
+	err := task.ReleaseGameLease(ctx, bot.RedisInterface.client, lease, bot.nodeID)
+	if err != nil {
+		log.Println(err)
+	}
+}
 
-					if job.Payload == "true" {
-						dgs.Linked = true
+// sendGameControl asks the owner of the game to end or pause it. Returns false if no node owns the game, in which case
+// the caller has to handle it
+func (bot *Bot) sendGameControl(connectCode string, msg task.ControlMessage) bool {
+	received, err := task.PublishControl(ctx, bot.RedisInterface.client, connectCode, msg)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	return received > 0
+}
 
-						// ★ Capture 接続確立！
-						dgs.CaptureConnected = true
-						dgs.LastCapturePing = time.Now().Unix()
-					} else {
-						dgs.Linked = false
+// adoptOrphanedGamesWorker takes over the games in this shard's guilds whose owner stopped renewing its lease, for
+// example because its process crashed
+func (bot *Bot) adoptOrphanedGamesWorker() {
+	ticker := time.NewTicker(task.GameLeaseRenewInterval)
+	defer ticker.Stop()
 
-						// ★ Capture 切断
-						dgs.CaptureConnected = false
-						dgs.LastCapturePing = time.Now().Unix()
-					}
+	for range ticker.C {
+		bot.adoptOrphanedGames()
+	}
+}
 
-					dgs.ConnectCode = connectCode
-					bot.RedisInterface.SetDiscordGameState(dgs, lock)
+func (bot *Bot) adoptOrphanedGames() {
+	leases, err := task.ExpiredGameLeases(ctx, bot.RedisInterface.client, time.Now())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, lease := range leases {
+		// games in guilds on other shards are adopted by those shards
+		if _, err := bot.PrimarySession.State.Guild(lease.GuildID); err != nil {
+			continue
+		}
+		dgs := bot.RedisInterface.getDiscordGameState(GameStateRequest{
+			GuildID:     lease.GuildID,
+			ConnectCode: lease.ConnectCode,
+		}, false)
+		if dgs == nil || dgs.ConnectCode != lease.ConnectCode {
+			err = task.ForgetGameLease(ctx, bot.RedisInterface.client, lease)
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		if bot.startGameWorker(lease.GuildID, lease.ConnectCode) {
+			log.Printf("Adopted orphaned game %s in guild %s\n", lease.ConnectCode, lease.GuildID)
+		}
+	}
+}
 
-					bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
+func (bot *Bot) reclaimJobs(connectCode string) int {
+	claimed, err := task.ReclaimJobs(ctx, bot.RedisInterface.client, connectCode, bot.nodeID, task.JobReclaimTimeout)
+	if err != nil {
+		log.Println(err)
+	} else if claimed > 0 {
+		log.Printf("Reclaimed %d unacknowledged jobs for %s\n", claimed, connectCode)
+	}
+	return claimed
+}
 
-					// ★ 接続状態が変化した瞬間だけ「作り直し」
-					//   - false -> true ならボタン出現
-					//   - true -> false ならボタン消える（任意だけど安全）
-					if prevCapture != dgs.CaptureConnected {
-						bot.RefreshGameStateMessage(dgsRequest, sett)
-					} else {
-						bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
-					}
+// drainJobs processes and acks every job currently available to this node for the game
+func (bot *Bot) drainJobs(dgsRequest GameStateRequest) {
+	for {
+		job, err := task.PopJob(ctx, bot.RedisInterface.client, dgsRequest.ConnectCode, bot.nodeID)
+		if errors.Is(err, redis.Nil) {
+			return
+		} else if err != nil {
+			log.Println(err)
+			if job.ID == "" {
+				return
+			}
+			// unparseable payloads will never succeed; ack them so they don't block the stream
+		} else {
+			bot.processJob(dgsRequest, job)
+		}
+		err = task.AckJob(ctx, bot.RedisInterface.client, dgsRequest.ConnectCode, job.ID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
 
-				// ======================================================
-				// ★ Lobby/State/Player Job でも
-				//   「ConnectionJobが来ない保険」で CaptureConnected を true にする
-				// ======================================================
-				case task.LobbyJob:
-					var lobby game.Lobby
-					err = json.Unmarshal([]byte(job.Payload.(string)), &lobby)
-					if err != nil {
-						log.Println(err)
-						break
-					}
-					bot.processLobby(sett, lobby, dgsRequest)
+func (bot *Bot) processJob(dgsRequest GameStateRequest, job task.Job) {
+	var err error
+	guildID := dgsRequest.GuildID
+	connectCode := dgsRequest.ConnectCode
+	log.Printf("Popped job of type %d w/ payload %s\n", job.JobType, job.Payload.(string))
+	bot.refreshGameLiveness(connectCode)
+	bot.RedisInterface.RefreshActiveGame(guildID, connectCode)
+
+	gameEvent := storage.PostgresGameEvent{
+		GameID:    -1,
+		UserID:    nil,
+		EventTime: job.SentAt(),
+		EventType: int16(job.JobType),
+		Payload:   job.Payload.(string),
+	}
+	correlatedUserID := ""
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
 
-				case task.StateJob:
-					num, err := strconv.ParseInt(job.Payload.(string), 10, 64)
-					if err != nil {
-						log.Println(err)
-						break
-					}
-					bot.processTransition(game.Phase(num), dgsRequest)
+	switch job.JobType {
 
-				case task.PlayerJob:
-					var player game.Player
-					err = json.Unmarshal([]byte(job.Payload.(string)), &player)
-					if err != nil {
-						log.Println(err)
-						break
-					}
-					if player.Color > 17 || player.Color < 0 {
-						break
-					}
+	// ======================================================
+	// ★ ConnectionJob = Capture の接続/切断通知
+	// ======================================================
+	case task.ConnectionJob:
+		lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
+		for lock == nil {
+			lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
+		}
 
-					shouldHandleTracked, userID, readOnlyDgs, err := bot.processPlayer(sett, player, dgsRequest)
-					if shouldHandleTracked {
-						bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
-					}
-					if err != nil {
-						bot.PrimarySession.ChannelMessageSend(readOnlyDgs.GameStateMsg.MessageChannelID, sett.LocalizeMessage(&i18n.Message{
-							ID:    "processplayer.error",
-							Other: "Error in muting or deafening {{.User}}. Does the bot have permissions to mute/deafen users in {{.VoiceChannel}}?",
-						},
-							map[string]interface{}{
-								"User":         discord.MentionByUserID(userID),
-								"VoiceChannel": discord.MentionByChannelID(readOnlyDgs.VoiceChannel),
-							},
-						))
-						server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 1)
-					}
-					correlatedUserID = userID
+		// 変更前の接続状態を保持（変化があったときだけ Refresh）
+		prevCapture := dgs.CaptureConnected
 
-				case task.GameOverJob:
-					var gameOverResult game.Gameover
-					err := json.Unmarshal([]byte(job.Payload.(string)), &gameOverResult)
-					if err != nil {
-						log.Println(err)
-						break
-					}
+		if job.Payload == "true" {
+			dgs.Linked = true
 
-					// we only need a read-only state for making the game summary message
-					dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(dgsRequest)
-					if dgs != nil {
-						delTime := sett.GetDeleteGameSummaryMinutes()
-						if delTime != 0 {
-							winners := getWinners(*dgs, gameOverResult)
-							buf := bytes.NewBuffer([]byte{})
-							for i, v := range winners {
-								roleStr := "Crewmate"
-								if v.role == game.ImposterRole {
-									roleStr = "Imposter"
-								}
-								buf.WriteString(fmt.Sprintf("<@%s>", v.userID))
-								if i < len(winners)-1 {
-									buf.WriteRune(',')
-								} else {
-									buf.WriteString(fmt.Sprintf(" won as %s", roleStr))
-								}
-							}
-							embed := gameOverMessage(dgs, bot.StatusEmojis, sett, buf.String())
-							channelID := dgs.GameStateMsg.MessageChannelID
-							if sett.GetMatchSummaryChannelID() != "" {
-								channelID = sett.GetMatchSummaryChannelID()
-							}
-							msg, err := bot.PrimarySession.ChannelMessageSendEmbed(channelID, embed)
-							if delTime > 0 && err == nil {
-								server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 2)
-								go MessageDeleteWorker(bot.PrimarySession, msg.ChannelID, msg.ID, time.Minute*time.Duration(delTime))
-							} else if err == nil {
-								server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 1)
-							}
-						}
-						go dumpGameToPostgres(*dgs, bot.PostgresInterface, gameOverResult)
-
-						// refresh the game message if the setting is marked
-						if sett.AutoRefresh {
-							bot.RefreshGameStateMessage(dgsRequest, sett)
-						}
-
-						lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
-						for lock == nil {
-							lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
-						}
-						dgs.MatchID = -1
-						dgs.MatchStartUnix = -1
-						bot.RedisInterface.SetDiscordGameState(dgs, lock)
-					}
-				}
+			// ★ Capture 接続確立！
+			dgs.CaptureConnected = true
+			dgs.LastCapturePing = time.Now().Unix()
+		} else {
+			dgs.Linked = false
+
+			// ★ Capture 切断
+			dgs.CaptureConnected = false
+			dgs.LastCapturePing = time.Now().Unix()
+		}
+
+		dgs.ConnectCode = connectCode
+		bot.RedisInterface.SetDiscordGameState(dgs, lock)
+
+		bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
 
-				if job.JobType != task.ConnectionJob {
-					go func(userID string, ge storage.PostgresGameEvent) {
-						dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(dgsRequest)
-						if dgs != nil && dgs.MatchID > 0 && dgs.MatchStartUnix > 0 {
-							ge.GameID = dgs.MatchID
-							if userID != "" {
-								num, err := strconv.ParseUint(userID, 10, 64)
-								if err != nil {
-									log.Println(err)
-									ge.UserID = nil
-								} else {
-									ge.UserID = &num
-								}
-								log.Printf("Adding postgres event with user id %d\n", ge.UserID)
-							}
-
-							err := bot.PostgresInterface.AddEvent(&ge)
-							if err != nil {
-								log.Println(err)
-							}
-						}
-					}(correlatedUserID, gameEvent)
+		// ★ 接続状態が変化した瞬間だけ「作り直し」
+		//   - false -> true ならボタン出現
+		//   - true -> false ならボタン消える（任意だけど安全）
+		if prevCapture != dgs.CaptureConnected {
+			bot.RefreshGameStateMessage(dgsRequest, sett)
+		} else {
+			bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
+		}
+
+	// ======================================================
+	// ★ Lobby/State/Player Job でも
+	//   「ConnectionJobが来ない保険」で CaptureConnected を true にする
+	// ======================================================
+	case task.LobbyJob:
+		var lobby game.Lobby
+		err = json.Unmarshal([]byte(job.Payload.(string)), &lobby)
+		if err != nil {
+			log.Println(err)
+			break
+		}
+		bot.processLobby(sett, lobby, dgsRequest)
+
+	case task.StateJob:
+		num, err := strconv.ParseInt(job.Payload.(string), 10, 64)
+		if err != nil {
+			log.Println(err)
+			break
+		}
+		bot.processTransition(game.Phase(num), dgsRequest)
+
+	case task.PlayerJob:
+		var player game.Player
+		err = json.Unmarshal([]byte(job.Payload.(string)), &player)
+		if err != nil {
+			log.Println(err)
+			break
+		}
+		if player.Color > 17 || player.Color < 0 {
+			break
+		}
+
+		shouldHandleTracked, userID, readOnlyDgs, err := bot.processPlayer(sett, player, dgsRequest)
+		if shouldHandleTracked {
+			bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
+		}
+		if err != nil {
+			bot.PrimarySession.ChannelMessageSend(readOnlyDgs.GameStateMsg.MessageChannelID, sett.LocalizeMessage(&i18n.Message{
+				ID:    "processplayer.error",
+				Other: "Error in muting or deafening {{.User}}. Does the bot have permissions to mute/deafen users in {{.VoiceChannel}}?",
+			},
+				map[string]interface{}{
+					"User":         discord.MentionByUserID(userID),
+					"VoiceChannel": discord.MentionByChannelID(readOnlyDgs.VoiceChannel),
+				},
+			))
+			server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 1)
+		}
+		correlatedUserID = userID
+
+	case task.GameOverJob:
+		gameOverResult, err := game.ParseGameover([]byte(job.Payload.(string)))
+		if err != nil {
+			log.Println(err)
+			break
+		}
+
+		// we only need a read-only state for making the game summary message
+		dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(dgsRequest)
+		if dgs != nil {
+			delTime := sett.GetDeleteGameSummaryMinutes()
+			if delTime != 0 {
+				mode := dgs.getGameMode(sett)
+				winners := getWinners(*dgs, gameOverResult, mode)
+				embed := gameOverMessage(dgs, bot.StatusEmojis, sett, winnersSummary(winners, mode))
+				channelID := dgs.GameStateMsg.MessageChannelID
+				if sett.GetMatchSummaryChannelID() != "" {
+					channelID = sett.GetMatchSummaryChannelID()
+				}
+				msg, err := bot.PrimarySession.ChannelMessageSendEmbed(channelID, embed)
+				if delTime > 0 && err == nil {
+					server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 2)
+					go MessageDeleteWorker(bot.PrimarySession, msg.ChannelID, msg.ID, time.Minute*time.Duration(delTime))
+				} else if err == nil {
+					server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageCreateDelete, 1)
 				}
 			}
+			go dumpGameToPostgres(*dgs, bot.PostgresInterface, gameOverResult, dgs.getGameMode(sett))
 
-		case <-timer.C:
-			timer.Stop()
-			log.Printf("Killing game w/ code %s after %d seconds of inactivity!\n", connectCode, bot.captureTimeout)
-			err := notify.Close()
-			if err != nil {
-				log.Println(err)
+			// refresh the game message if the setting is marked
+			if sett.AutoRefresh {
+				bot.RefreshGameStateMessage(dgsRequest, sett)
 			}
-			go bot.forceEndGame(dgsRequest)
-			bot.ChannelsMapLock.Lock()
-			delete(bot.EndGameChannels, connectCode)
-			bot.ChannelsMapLock.Unlock()
 
-			return
-		case <-endGameChannel:
-			log.Println("Redis subscriber received kill signal, closing all pubsubs")
-			err := notify.Close()
-			if err != nil {
-				log.Println(err)
+			lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
+			for lock == nil {
+				lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 			}
-			bot.forceEndGame(dgsRequest)
-			return
+			dgs.MatchID = -1
+			dgs.MatchStartUnix = -1
+			bot.RedisInterface.SetDiscordGameState(dgs, lock)
 		}
 	}
+
+	if job.JobType != task.ConnectionJob {
+		go func(userID string, ge storage.PostgresGameEvent) {
+			dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(dgsRequest)
+			if dgs != nil && dgs.MatchID > 0 && dgs.MatchStartUnix > 0 {
+				ge.GameID = dgs.MatchID
+				if userID != "" {
+					num, err := strconv.ParseUint(userID, 10, 64)
+					if err != nil {
+						log.Println(err)
+						ge.UserID = nil
+					} else {
+						ge.UserID = &num
+					}
+					log.Printf("Adding postgres event with user id %d\n", ge.UserID)
+				}
+
+				err := bot.PostgresInterface.AddEvent(&ge)
+				if err != nil {
+					log.Println(err)
+				}
+			}
+		}(correlatedUserID, gameEvent)
+	}
 }
 
 type winnerRecord struct {
 	userID string
-	role   game.GameRole
+	role   game.Role
 }
 
-func getWinners(dgs GameState, gameOver game.Gameover) []winnerRecord {
+func getWinners(dgs GameState, gameOver game.Gameover, mode game.GameMode) []winnerRecord {
 	var winners []winnerRecord
-
-	imposterWin := gameOver.GameOverReason == game.ImpostorByKill ||
-		gameOver.GameOverReason == game.ImpostorByVote ||
-		gameOver.GameOverReason == game.ImpostorBySabotage ||
-		gameOver.GameOverReason == game.ImpostorDisconnect
-
 	for _, player := range dgs.UserData {
 		if player.GetPlayerName() != amongus.UnlinkedPlayerName {
-			for _, v := range gameOver.PlayerInfos {
-				// only override for the imposters
-				if player.GetPlayerName() == v.Name {
-					if (v.IsImpostor && imposterWin) || (!v.IsImpostor && !imposterWin) {
-						role := game.CrewmateRole
-						if v.IsImpostor {
-							role = game.ImposterRole
-						}
-						winners = append(winners, winnerRecord{
-							userID: player.User.UserID,
-							role:   role,
-						})
-					}
-				}
+			if result, ok := mode.Result(gameOver, player.GetPlayerName()); ok && result.Won {
+				winners = append(winners, winnerRecord{
+					userID: player.User.UserID,
+					role:   result.Role,
+				})
 			}
 		}
 	}
+	sort.Slice(winners, func(i, j int) bool {
+		return winners[i].userID < winners[j].userID
+	})
 	return winners
 }
 
+// winnersSummary is like "<@1>,<@2> (Sheriff) won as Crewmate", naming the roles that aren't just their team's
+func winnersSummary(winners []winnerRecord, mode game.GameMode) string {
+	buf := bytes.NewBuffer([]byte{})
+	for i, v := range winners {
+		buf.WriteString(fmt.Sprintf("<@%s>", v.userID))
+		if v.role.Team == game.NeutralTeam || v.role != mode.DefaultRole(v.role.Team) {
+			buf.WriteString(fmt.Sprintf(" (%s)", v.role.Name))
+		}
+		if i < len(winners)-1 {
+			buf.WriteRune(',')
+		} else {
+			buf.WriteString(fmt.Sprintf(" won as %s", teamName(v.role, mode)))
+		}
+	}
+	return buf.String()
+}
+
+// teamName is what the team is called in the mode, like "Seeker" in hide-and-seek
+func teamName(role game.Role, mode game.GameMode) string {
+	switch role.Team {
+	case game.NeutralTeam:
+		return role.Name
+	case game.ImpostorTeam:
+		if name := mode.DefaultRole(game.ImpostorTeam).Name; name != game.ImpostorDefault.Name {
+			return name
+		}
+		return "Imposter"
+	default:
+		return mode.DefaultRole(game.CrewmateTeam).Name
+	}
+}
+
 func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player, dgsRequest GameStateRequest) (bool, string, *GameState, error) {
 	var err error
 	if player.Name != "" {
@@ -337,16 +505,17 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 			_, _, data := dgs.GameData.UpdatePlayer(player)
 
 			userID := dgs.AttemptPairingByMatchingNames(data)
-			// try pairing via the cached usernames
+			// try pairing via the names users were linked to before
 			if userID == "" {
-				var uids map[string]interface{}
-				uids, err = bot.RedisInterface.GetUsernameOrUserIDMappings(dgs.GuildID, player.Name)
-				userID = dgs.AttemptPairingByUserIDs(data, uids)
+				userID, err = bot.attemptPairingByAliases(dgs, data)
 			} else {
 				err = bot.applyToSingle(dgs, userID, false, false)
 			}
 
 			dgs.GameData.ClearPlayerData(player.Name)
+			if dgs.PruneLinkSuggestions() {
+				bot.EditGameStateComponents(dgs)
+			}
 
 			// only update the message if we're not in the tasks phase (info leaks)
 			if dgs.GameData.GetPhase() != game.TASKS {
@@ -359,21 +528,13 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 		switch {
 		case player.Action == game.JOINED:
 			log.Println("Detected a player joined, refreshing User data mappings")
-			userID := dgs.AttemptPairingByMatchingNames(data)
-			if userID == "" {
-				var uids map[string]interface{}
-				uids, err = bot.RedisInterface.GetUsernameOrUserIDMappings(dgs.GuildID, player.Name)
-				userID = dgs.AttemptPairingByUserIDs(data, uids)
-			}
+			var userID string
+			userID, err = bot.attemptPairing(dgs, data)
 			bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 			return true, userID, dgs, err
 		case updated:
-			userID := dgs.AttemptPairingByMatchingNames(data)
-			if userID == "" {
-				var uids map[string]interface{}
-				uids, err = bot.RedisInterface.GetUsernameOrUserIDMappings(dgs.GuildID, player.Name)
-				userID = dgs.AttemptPairingByUserIDs(data, uids)
-			}
+			var userID string
+			userID, err = bot.attemptPairing(dgs, data)
 			if isAliveUpdated && dgs.GameData.GetPhase() == game.TASKS {
 				if sett.GetUnmuteDeadDuringTasks() || player.Action == game.EXILED {
 					bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
@@ -421,7 +582,7 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 	if oldPhase == game.LOBBY && phase == game.TASKS {
 		matchStart := time.Now().Unix()
 		dgs.MatchStartUnix = matchStart
-		gameID := startGameInPostgres(*dgs, bot.PostgresInterface)
+		gameID := startGameInPostgres(*dgs, bot.PostgresInterface, dgs.getGameMode(sett))
 		dgs.MatchID = int64(gameID)
 		log.Printf("New match has begun. ID %d and starttime %d\n", gameID, matchStart)
 	}
@@ -444,13 +605,13 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 		phase = game.LOBBY
 		fallthrough
 	case game.LOBBY:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		delay := transitionDelay(sett, dgs, oldPhase, phase)
 		bot.handleTrackedMembers(bot.PrimarySession, sett, delay, NoPriority, dgsRequest)
 
 		bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 
 	case game.TASKS:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		delay := transitionDelay(sett, dgs, oldPhase, phase)
 		priority := AlivePriority
 		if oldPhase == game.LOBBY {
 			priority = NoPriority
@@ -460,7 +621,7 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 		bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 
 	case game.DISCUSS:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		delay := transitionDelay(sett, dgs, oldPhase, phase)
 		bot.handleTrackedMembers(bot.PrimarySession, sett, delay, DeadPriority, dgsRequest)
 
 		if sett.AutoRefresh {
@@ -471,6 +632,12 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 	}
 }
 
+// transitionDelay is how long to wait before muting/unmuting for a phase change, so the mutes line up with the
+// animations in game
+func transitionDelay(sett *settings.GuildSettings, dgs *GameState, oldPhase, phase game.Phase) time.Duration {
+	return sett.Delays.Resolve(game.MakeTransition(oldPhase, phase, dgs.GameData.GetPlayMap(), dgs.GameData.Ejected))
+}
+
 func (bot *Bot) processLobby(sett *settings.GuildSettings, lobby game.Lobby, dgsRequest GameStateRequest) {
 	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 	for lock == nil {
@@ -488,6 +655,7 @@ func (bot *Bot) processLobby(sett *settings.GuildSettings, lobby game.Lobby, dgs
 	}
 
 	dgs.GameData.SetRoomRegionMap(lobby.LobbyCode, lobby.Region.ToString(), lobby.PlayMap)
+	dgs.setLobbyMode(lobby.GameMode)
 	bot.RedisInterface.SetDiscordGameState(dgs, lock)
 
 	// ★ 初回接続なら Refresh（ボタン付与）
@@ -498,8 +666,9 @@ func (bot *Bot) processLobby(sett *settings.GuildSettings, lobby game.Lobby, dgs
 	}
 }
 
-func startGameInPostgres(dgs GameState, psql *storage.PsqlInterface) uint64 {
-	if dgs.MatchStartUnix < 0 {
+func startGameInPostgres(dgs GameState, psql *storage.PsqlInterface, mode game.GameMode) uint64 {
+	// no Postgres when replaying games offline
+	if dgs.MatchStartUnix < 0 || psql == nil {
 		return 0
 	}
 	gid, err := strconv.ParseUint(dgs.GuildID, 10, 64)
@@ -511,9 +680,10 @@ func startGameInPostgres(dgs GameState, psql *storage.PsqlInterface) uint64 {
 		GameID:      -1,
 		GuildID:     gid,
 		ConnectCode: dgs.ConnectCode,
-		StartTime:   int32(dgs.MatchStartUnix),
+		StartTime:   time.Unix(dgs.MatchStartUnix, 0),
 		WinType:     -1,
-		EndTime:     -1,
+		EndTime:     nil,
+		GameMode:    mode.Name,
 	}
 	i, err := psql.AddInitialGame(pgame)
 	if err != nil {
@@ -522,19 +692,23 @@ func startGameInPostgres(dgs GameState, psql *storage.PsqlInterface) uint64 {
 	return i
 }
 
-func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver game.Gameover) {
+func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver game.Gameover, mode game.GameMode) {
+	if psql == nil {
+		return
+	}
 	if dgs.MatchID < 0 || dgs.MatchStartUnix < 0 {
 		log.Println("dgs match id or start time is <0; not dumping game to Postgres")
 		return
 	}
-	end := time.Now().Unix()
+	end := time.Now()
 
 	userGames := make([]*storage.PostgresUserGame, 0)
 
-	imposterWin := gameOver.GameOverReason == game.ImpostorByKill ||
-		gameOver.GameOverReason == game.ImpostorBySabotage ||
-		gameOver.GameOverReason == game.ImpostorByVote ||
-		gameOver.GameOverReason == game.ImpostorDisconnect
+	// players the capture didn't report on are crewmates, like they would be in the base game
+	missingResult := game.PlayerResult{
+		Role: mode.DefaultRole(game.CrewmateTeam),
+		Won:  !gameOver.GameOverReason.ImpostorWin() && gameOver.WinningRole == "",
+	}
 
 	for _, v := range dgs.UserData {
 		if v.GetPlayerName() != amongus.UnlinkedPlayerName {
@@ -561,26 +735,21 @@ func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver gam
 				continue
 			}
 
-			won := !imposterWin
-			role := game.CrewmateRole
-			for _, pi := range gameOver.PlayerInfos {
-				if pi.IsImpostor {
-					if strings.EqualFold(pi.Name, inGameData.Name) {
-						role = game.ImposterRole
-						won = imposterWin
-						break
-					}
-				}
+			result, ok := mode.Result(gameOver, inGameData.Name)
+			if !ok {
+				result = missingResult
 			}
 
 			userGames = append(userGames, &storage.PostgresUserGame{
-				UserID:      puser.UserID,
-				GuildID:     gid,
-				GameID:      dgs.MatchID,
-				PlayerName:  inGameData.Name,
-				PlayerColor: int16(inGameData.Color),
-				PlayerRole:  int16(role),
-				PlayerWon:   won,
+				UserID:         puser.UserID,
+				GuildID:        gid,
+				GameID:         dgs.MatchID,
+				PlayerName:     inGameData.Name,
+				PlayerColor:    int16(inGameData.Color),
+				PlayerRole:     int16(result.Role.Team.GameRole()),
+				PlayerWon:      result.Won,
+				PlayerRoleName: result.Role.Name,
+				GameMode:       mode.Name,
 			})
 		}
 	}
@@ -589,5 +758,45 @@ func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver gam
 	err := psql.UpdateGameAndPlayers(dgs.MatchID, int16(gameOver.GameOverReason), end, userGames)
 	if err != nil {
 		log.Println(err)
+		return
+	}
+	err = psql.UpdateRatings(dgs.MatchID)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// attemptPairing tries to link a player to a Discord user by exact name, then by the names they were linked to in the
+// past, then by fuzzy name matching. Uncertain fuzzy matches are offered as buttons on the game state message instead
+func (bot *Bot) attemptPairing(dgs *GameState, data amongus.PlayerData) (string, error) {
+	// whoever got linked is playing now, so they don't need their place in the queue anymore
+	defer dgs.PruneWaitingList()
+	userID := dgs.AttemptPairingByMatchingNames(data)
+	if userID != "" {
+		return userID, nil
+	}
+	userID, err := bot.attemptPairingByAliases(dgs, data)
+	if userID != "" {
+		return userID, err
+	}
+	userID, suggested := dgs.AttemptPairingByFuzzyNames(data)
+	if userID != "" {
+		log.Printf("Linked player %s to user %s by a similar name\n", data.Name, userID)
+	}
+	if pruned := dgs.PruneLinkSuggestions(); suggested || pruned {
+		bot.EditGameStateComponents(dgs)
+	}
+	return userID, err
+}
+
+func (bot *Bot) attemptPairingByAliases(dgs *GameState, data amongus.PlayerData) (string, error) {
+	// no Postgres when replaying games offline
+	if bot.PostgresInterface == nil {
+		return "", nil
+	}
+	aliases, err := bot.PostgresInterface.GetAliasesByName(dgs.GuildID, data.Name)
+	if err != nil {
+		return "", err
 	}
+	return dgs.AttemptPairingByAliases(data, aliases), nil
 }
@@ -2,19 +2,32 @@ package bot
 
 import (
 	"fmt"
+	"log"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/automuteus/automuteus/v8/internal/server"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/task"
 	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
 )
 
 // bumped for public rollout. Don't need to update the status message more than once every 2 secs prob
 const DeferredEditSeconds = 2
+
+// how often each node checks for edits that are due, and how many it sends at most each time
+const editPollInterval = 250 * time.Millisecond
+const maxEditsPerPoll = 50
+
+// how long to wait before sending an edit again, after Discord failed to take it
+const editRetryDelay = 5 * time.Second
+
 const colorSelectID = "select-color"
 
+// discord doesn't allow button labels any longer than this
+const maxButtonLabelLength = 80
+
 type GameStateMessage struct {
 	MessageID        string `json:"messageID"`
 	MessageChannelID string `json:"messageChannelID"`
@@ -52,9 +65,6 @@ func (dgs *GameState) DeleteGameStateMsg(s *discordgo.Session, reset bool) bool
 	return retValue
 }
 
-var DeferredEdits = make(map[string]*discordgo.MessageEmbed)
-var DeferredEditsLock = sync.Mutex{}
-
 // ==== 色情報マスタ ====
 //  key: 英語の色名キーワード（label や value に含まれる文字）
 type colorInfo struct {
@@ -114,21 +124,20 @@ func buildColorButtonMeta(opt discordgo.SelectMenuOption) (label string, useCrew
 }
 
 // Note this is not a pointer; we never expect the underlying DGS to change on an edit
-func (dgs GameState) dispatchEdit(s *discordgo.Session, me *discordgo.MessageEmbed) (newEdit bool) {
+func (dgs GameState) dispatchEdit(client *redis.Client, me *discordgo.MessageEmbed) (newEdit bool) {
 	if !ValidFields(me) {
 		return false
 	}
 
-	DeferredEditsLock.Lock()
-
-	// if it isn't found, then start the worker to wait to start it (this is a UNIQUE edit)
-	if _, ok := DeferredEdits[dgs.GameStateMsg.MessageID]; !ok {
-		go deferredEditWorker(s, dgs.GameStateMsg.MessageChannelID, dgs.GameStateMsg.MessageID)
-		newEdit = true
+	// whether or not an edit is already scheduled, replace the contents with the new message
+	newEdit, err := task.ScheduleEdit(ctx, client, task.PendingEdit{
+		ChannelID: dgs.GameStateMsg.MessageChannelID,
+		MessageID: dgs.GameStateMsg.MessageID,
+		Embed:     me,
+	}, time.Second*time.Duration(DeferredEditSeconds))
+	if err != nil {
+		log.Println(err)
 	}
-	// whether or not it's found, replace the contents with the new message
-	DeferredEdits[dgs.GameStateMsg.MessageID] = me
-	DeferredEditsLock.Unlock()
 	return newEdit
 }
 
@@ -149,22 +158,56 @@ func ValidFields(me *discordgo.MessageEmbed) bool {
 	return true
 }
 
-func RemovePendingDGSEdit(messageID string) {
-	DeferredEditsLock.Lock()
-	delete(DeferredEdits, messageID)
-	DeferredEditsLock.Unlock()
+func (redisInterface *RedisInterface) RemovePendingDGSEdit(messageID string) {
+	err := task.CancelEdit(ctx, redisInterface.client, messageID)
+	if err != nil {
+		log.Println(err)
+	}
 }
 
-func deferredEditWorker(s *discordgo.Session, channelID, messageID string) {
-	time.Sleep(time.Second * time.Duration(DeferredEditSeconds))
+// editSchedulerWorker sends the game state message edits that are due, for every node. Edits to channels that are
+// over Discord's edit rate limit are pushed back until the limit resets
+func (bot *Bot) editSchedulerWorker() {
+	ticker := time.NewTicker(editPollInterval)
+	defer ticker.Stop()
 
-	DeferredEditsLock.Lock()
-	me := DeferredEdits[messageID]
-	delete(DeferredEdits, messageID)
-	DeferredEditsLock.Unlock()
+	for range ticker.C {
+		edits, err := task.ClaimDueEdits(ctx, bot.RedisInterface.client, time.Now(), maxEditsPerPoll)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, edit := range edits {
+			wait, err := task.TakeChannelEditSlot(ctx, bot.RedisInterface.client, edit.ChannelID)
+			if err != nil {
+				log.Println(err)
+			}
+			if wait > 0 {
+				err = task.RescheduleEdit(ctx, bot.RedisInterface.client, edit, time.Now().Add(wait))
+				if err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+			go bot.sendEdit(edit)
+		}
+	}
+}
 
-	if me != nil {
-		editMessageEmbed(s, channelID, messageID, me)
+// sendEdit sends an edit claimed by editSchedulerWorker. It's already off the schedule, so if Discord fails to take
+// it, it's put back with RetryEdit instead of being lost
+func (bot *Bot) sendEdit(edit task.PendingEdit) {
+	me := discordgo.NewMessageEdit(edit.ChannelID, edit.MessageID).SetEmbed(edit.Embed)
+	_, sendErr := bot.PrimarySession.ChannelMessageEditComplex(me)
+	if sendErr == nil {
+		return
+	}
+	log.Println("Error when attempting to edit complex message", sendErr)
+	retried, err := task.RetryEdit(ctx, bot.RedisInterface.client, edit, sendErr, time.Now().Add(editRetryDelay))
+	if err != nil {
+		log.Println(err)
+	} else if !retried {
+		log.Printf("Dropping the edit to message %s after %d attempt(s)\n", edit.MessageID, edit.Attempts+1)
 	}
 }
 
@@ -198,6 +241,22 @@ func (dgs *GameState) CreateMessage(s *discordgo.Session, me *discordgo.MessageE
 	// ★接続済みなら従来通りボタン生成
 	// ======================================================
 
+	components := dgs.gameStateComponents()
+	msg := sendEmbedWithComponents(s, channelID, me, components)
+	if msg != nil {
+		dgs.GameStateMsg.LeaderID = authorID
+		dgs.GameStateMsg.MessageChannelID = msg.ChannelID
+		dgs.GameStateMsg.MessageID = msg.ID
+		dgs.GameStateMsg.CreationTimeUnix = time.Now().Unix()
+		return true
+	}
+	return false
+}
+
+// ===== ここまで CreateMessage =====
+
+// gameStateComponents builds the color buttons, plus a row with the roster buttons and any pending link suggestions
+func (dgs *GameState) gameStateComponents() []discordgo.MessageComponent {
 	// 元々のセレクトメニュー用オプションを流用
 	opts := EmojisToSelectMenuOptions(GlobalAlivenessEmojis[true], X)
 
@@ -235,24 +294,54 @@ func (dgs *GameState) CreateMessage(s *discordgo.Session, me *discordgo.MessageE
 		components = append(components, curRow)
 	}
 
-	msg := sendEmbedWithComponents(s, channelID, me, components)
-	if msg != nil {
-		dgs.GameStateMsg.LeaderID = authorID
-		dgs.GameStateMsg.MessageChannelID = msg.ChannelID
-		dgs.GameStateMsg.MessageID = msg.ID
-		dgs.GameStateMsg.CreationTimeUnix = time.Now().Unix()
-		return true
+	row := dgs.linkSuggestionRow()
+	row.Components = append(rosterButtons(), row.Components...)
+	return append(components, row)
+}
+
+// linkSuggestionRow has a button to confirm each fuzzy name match that wasn't certain enough to be linked automatically
+func (dgs *GameState) linkSuggestionRow() discordgo.ActionsRow {
+	row := discordgo.ActionsRow{}
+	for _, suggestion := range dgs.SortedLinkSuggestions() {
+		if len(row.Components) == MaxLinkSuggestions {
+			break
+		}
+		name := dgs.DisplayNames[suggestion.UserID]
+		if name == "" {
+			if v, ok := dgs.UserData[suggestion.UserID]; ok {
+				name = v.GetNickName()
+				if name == "" {
+					name = v.GetUserName()
+				}
+			}
+		}
+		label := []rune(fmt.Sprintf("🔗 %s = %s ?", suggestion.PlayerName, name))
+		if len(label) > maxButtonLabelLength {
+			label = append(label[:maxButtonLabelLength-1], '…')
+		}
+		row.Components = append(row.Components, discordgo.Button{
+			CustomID: fmt.Sprintf("%s:%d:%s", linkSuggestButtonPrefix, suggestion.Color, suggestion.UserID),
+			Label:    string(label),
+			Style:    discordgo.PrimaryButton,
+		})
 	}
-	return false
+	return row
 }
 
-// ===== ここまで CreateMessage =====
+// EditGameStateComponents replaces the buttons on the game state message, without touching the embed
+func (bot *Bot) EditGameStateComponents(readOnlyDgs *GameState) {
+	if !readOnlyDgs.GameStateMsg.Exists() || !readOnlyDgs.CaptureConnected || readOnlyDgs.shouldRefresh() {
+		return
+	}
+	editMessageComponents(bot.PrimarySession, readOnlyDgs.GameStateMsg.MessageChannelID, readOnlyDgs.GameStateMsg.MessageID, readOnlyDgs.gameStateComponents())
+	server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageEdit, 1)
+}
 
 func (bot *Bot) DispatchRefreshOrEdit(readOnlyDgs *GameState, dgsRequest GameStateRequest, sett *settings.GuildSettings) {
 	if readOnlyDgs.shouldRefresh() {
 		bot.RefreshGameStateMessage(dgsRequest, sett)
 	} else {
-		edited := readOnlyDgs.dispatchEdit(bot.PrimarySession, bot.gameStateResponse(readOnlyDgs, sett))
+		edited := readOnlyDgs.dispatchEdit(bot.RedisInterface.client, bot.gameStateResponse(readOnlyDgs, sett))
 		if edited {
 			server.RecordDiscordRequests(bot.RedisInterface.client, server.MessageEdit, 1)
 		}
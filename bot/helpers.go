@@ -80,11 +80,12 @@ func sendEmbedWithComponents(s *discordgo.Session, channelID string, message *di
 	return msg
 }
 
-func editMessageEmbed(s *discordgo.Session, channelID string, messageID string, message *discordgo.MessageEmbed) *discordgo.Message {
-	me := discordgo.NewMessageEdit(channelID, messageID).SetEmbed(message)
+func editMessageComponents(s *discordgo.Session, channelID string, messageID string, components []discordgo.MessageComponent) *discordgo.Message {
+	me := discordgo.NewMessageEdit(channelID, messageID)
+	me.Components = components
 	msg, err := s.ChannelMessageEditComplex(me)
 	if err != nil {
-		log.Println("Error when attempting to edit complex message", err)
+		log.Println("Error when attempting to edit message components", err)
 	}
 	return msg
 }
@@ -0,0 +1,395 @@
+package bot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"strconv"
+	"strings"
+)
+
+// pages of a match's timeline, as the values of the select menu. Rounds are "round:<number>"
+const (
+	timelineOverviewPage = "overview"
+	timelinePlayersPage  = "players"
+	timelineRoundPage    = "round"
+)
+
+// a select menu holds 25 options; the rest are for the overview and the players
+const maxTimelineRoundPages = 23
+
+var errGameNotFound = errors.New("no game with that match ID on this server")
+
+// matchTimeline builds the timeline of a game on the guild
+func (bot *Bot) matchTimeline(guildID, matchID, connectCode string) (*storage.MatchTimeline, error) {
+	gameData, err := bot.PostgresInterface.GetGame(guildID, connectCode, matchID)
+	if err != nil {
+		return nil, err
+	}
+	if gameData == nil {
+		return nil, errGameNotFound
+	}
+	events, err := bot.PostgresInterface.GetGameEvents(matchID)
+	if err != nil {
+		return nil, err
+	}
+	players, err := bot.PostgresInterface.GetGamePlayers(matchID)
+	if err != nil {
+		return nil, err
+	}
+	return storage.BuildMatchTimeline(gameData, events, players), nil
+}
+
+// GameStatsMessage is a page of the match's timeline, and the menu to flip through the others
+func (bot *Bot) GameStatsMessage(guildID, matchID, connectCode, page string, isPrem bool, sett *settings.GuildSettings) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	if !isPrem {
+		return &discordgo.MessageEmbed{
+			Title: "Insufficient Premium",
+			Description: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.gameStatsEmbed.NoPremium",
+				Other: "Detailed match stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+			}),
+			Color: 10181046, // PURPLE
+		}, nil, nil
+	}
+
+	combinedID := connectCode + ":" + matchID
+	timeline, err := bot.matchTimeline(guildID, matchID, connectCode)
+	if errors.Is(err, errGameNotFound) {
+		return &discordgo.MessageEmbed{
+			Title: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Title",
+				Other: "Game `{{.MatchID}}`",
+			}, map[string]interface{}{
+				"MatchID": combinedID,
+			}),
+			Description: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.NotFound",
+				Other: "There's no game with that match ID on this server",
+			}),
+			Color: 10181046, // PURPLE
+		}, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	var embed *discordgo.MessageEmbed
+	switch {
+	case page == timelinePlayersPage:
+		embed = bot.timelinePlayersEmbed(timeline, combinedID, sett)
+	case strings.HasPrefix(page, timelineRoundPage+":"):
+		number, _ := strconv.Atoi(strings.TrimPrefix(page, timelineRoundPage+":"))
+		if number >= 1 && number <= len(timeline.Rounds) {
+			embed = bot.timelineRoundEmbed(timeline, timeline.Rounds[number-1], combinedID, sett)
+		}
+	}
+	if embed == nil {
+		page = timelineOverviewPage
+		embed = bot.timelineOverviewEmbed(timeline, combinedID, sett)
+	}
+	return embed, timelineComponents(timeline, combinedID, page, sett), nil
+}
+
+func timelineComponents(timeline *storage.MatchTimeline, combinedID, page string, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	options := []discordgo.SelectMenuOption{
+		{
+			Label: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Page.Overview",
+				Other: "Overview",
+			}),
+			Value:   timelineOverviewPage,
+			Emoji:   discordgo.ComponentEmoji{Name: "📋"},
+			Default: page == timelineOverviewPage,
+		},
+		{
+			Label: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Page.Players",
+				Other: "Players",
+			}),
+			Value:   timelinePlayersPage,
+			Emoji:   discordgo.ComponentEmoji{Name: "👥"},
+			Default: page == timelinePlayersPage,
+		},
+	}
+	for i, round := range timeline.Rounds {
+		if i == maxTimelineRoundPages {
+			break
+		}
+		value := fmt.Sprintf("%s:%d", timelineRoundPage, round.Number)
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       roundTitle(round, sett),
+			Value:       value,
+			Description: fmt.Sprintf("%s - %s", round.Start, roundEnd(round)),
+			Emoji:       discordgo.ComponentEmoji{Name: "🔨"},
+			Default:     page == value,
+		})
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID: fmt.Sprintf("%s:%s", matchTimelineSelectPrefix, combinedID),
+					Options:  options,
+				},
+			},
+		},
+	}
+}
+
+func roundTitle(round *storage.TimelineRound, sett *settings.GuildSettings) string {
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.matchStatsEmbed.Round",
+		Other: "Round {{.Number}}",
+	}, map[string]interface{}{
+		"Number": round.Number,
+	})
+}
+
+// roundEnd is when the round's meeting ended, or the round itself if it had none
+func roundEnd(round *storage.TimelineRound) storage.Offset {
+	if round.Meeting != nil {
+		return round.Meeting.End
+	}
+	return round.End
+}
+
+// timelinePlayer is the player's color, name, and who they are if they were linked
+func (bot *Bot) timelinePlayer(name string, color int, alive bool, userID string) string {
+	var buf strings.Builder
+	if emojis := bot.StatusEmojis[alive]; color >= 0 && color < len(emojis) {
+		buf.WriteString(emojis[color].FormatForInline() + " ")
+	}
+	buf.WriteString("**" + name + "**")
+	if userID != "" {
+		buf.WriteString(" " + discord.MentionByUserID(userID))
+	}
+	return buf.String()
+}
+
+func (bot *Bot) timelineEvents(events []storage.TimelineEvent, icon string) string {
+	buf := bytes.NewBufferString("")
+	for _, e := range events {
+		buf.WriteString(fmt.Sprintf("`%s` %s %s\n", e.At, icon, bot.timelinePlayer(e.Name, e.Color, false, "")))
+	}
+	return buf.String()
+}
+
+func (bot *Bot) timelineOverviewEmbed(timeline *storage.MatchTimeline, combinedID string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	fields := make([]*discordgo.MessageEmbedField, 0)
+	for i, round := range timeline.Rounds {
+		if i == maxTimelineRoundPages {
+			break
+		}
+		var summary []string
+		if len(round.Killed) > 0 {
+			summary = append(summary, fmt.Sprintf("☠️ %d", len(round.Killed)))
+		}
+		if len(round.Disconnected) > 0 {
+			summary = append(summary, fmt.Sprintf("🔌 %d", len(round.Disconnected)))
+		}
+		if round.Meeting != nil {
+			if round.Meeting.Ejected != nil {
+				summary = append(summary, "💬 ⏏️ "+round.Meeting.Ejected.Name)
+			} else {
+				summary = append(summary, "💬")
+			}
+		}
+		if len(summary) == 0 {
+			summary = append(summary, "-")
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (%s - %s)", roundTitle(round, sett), round.Start, roundEnd(round)),
+			Value:  strings.Join(summary, " · "),
+			Inline: true,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Title",
+			Other: "Game `{{.MatchID}}`",
+		}, map[string]interface{}{
+			"MatchID": combinedID,
+		}),
+		Description: winTypeDescription(timeline.WinType, sett) + "\n" + sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Summary",
+			Other: "Lasted {{.Duration}}: {{.Rounds}} rounds, {{.Meetings}} meetings, {{.Killed}} killed, {{.Ejected}} ejected, {{.Disconnected}} disconnected",
+		}, map[string]interface{}{
+			"Duration":     timeline.Duration,
+			"Rounds":       len(timeline.Rounds),
+			"Meetings":     timeline.NumMeetings(),
+			"Killed":       timeline.NumWithFate(storage.Killed),
+			"Ejected":      timeline.NumWithFate(storage.Ejected),
+			"Disconnected": timeline.NumWithFate(storage.Disconnected),
+		}),
+		Color:  10181046, // PURPLE
+		Fields: fields,
+	}
+}
+
+func (bot *Bot) timelineRoundEmbed(timeline *storage.MatchTimeline, round *storage.TimelineRound, combinedID string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	tasks := bot.timelineEvents(round.Killed, "☠️") + bot.timelineEvents(round.Disconnected, "🔌")
+	if tasks == "" {
+		tasks = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.NobodyDied",
+			Other: "Nobody died",
+		})
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Tasks",
+				Other: "🔨 Tasks ({{.Start}} - {{.End}})",
+			}, map[string]interface{}{
+				"Start": round.Start,
+				"End":   round.End,
+			}),
+			Value: tasks,
+		},
+	}
+	if round.Meeting != nil {
+		meeting := sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.NobodyEjected",
+			Other: "Nobody was ejected",
+		})
+		if round.Meeting.Ejected != nil {
+			meeting = bot.timelineEvents([]storage.TimelineEvent{*round.Meeting.Ejected}, "⏏️")
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Meeting",
+				Other: "💬 Meeting ({{.Start}} - {{.End}})",
+			}, map[string]interface{}{
+				"Start": round.Meeting.Start,
+				"End":   round.Meeting.End,
+			}),
+			Value: meeting,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Title",
+			Other: "Game `{{.MatchID}}`",
+		}, map[string]interface{}{
+			"MatchID": combinedID,
+		}) + " - " + roundTitle(round, sett),
+		Description: winTypeDescription(timeline.WinType, sett),
+		Color:       10181046, // PURPLE
+		Fields:      fields,
+	}
+}
+
+func (bot *Bot) timelinePlayersEmbed(timeline *storage.MatchTimeline, combinedID string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	buf := bytes.NewBufferString("")
+	for _, player := range timeline.Players {
+		var fate string
+		switch player.Fate {
+		case storage.Survived:
+			fate = sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Fate.Survived",
+				Other: "survived",
+			})
+		case storage.Killed:
+			fate = sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Fate.Killed",
+				Other: "killed in round {{.Round}}",
+			}, map[string]interface{}{
+				"Round": player.Round,
+			})
+		case storage.Ejected:
+			fate = sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Fate.Ejected",
+				Other: "ejected in round {{.Round}}",
+			}, map[string]interface{}{
+				"Round": player.Round,
+			})
+		case storage.Disconnected:
+			fate = sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchStatsEmbed.Fate.Disconnected",
+				Other: "disconnected in round {{.Round}}",
+			}, map[string]interface{}{
+				"Round": player.Round,
+			})
+		}
+		buf.WriteString(fmt.Sprintf("`%s` %s, %s\n", player.TimeAlive,
+			bot.timelinePlayer(player.Name, player.Color, player.Fate == storage.Survived, player.UserID), fate))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Title",
+			Other: "Game `{{.MatchID}}`",
+		}, map[string]interface{}{
+			"MatchID": combinedID,
+		}) + " - " + sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Page.Players",
+			Other: "Players",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.TimeAlive",
+			Other: "How long everyone stayed alive:",
+		}) + "\n" + buf.String(),
+		Color: 10181046, // PURPLE
+	}
+}
+
+func winTypeDescription(result game.GameResult, sett *settings.GuildSettings) string {
+	switch result {
+	case game.HumansByTask:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.HumansByTask",
+			Other: "Crewmates won by completing tasks",
+		})
+	case game.HumansByVote:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.HumansByVote",
+			Other: "Crewmates won by voting off the last Imposter",
+		})
+	case game.HumansDisconnect:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.HumansDisconnect",
+			Other: "Crewmates won because the last Imposter disconnected",
+		})
+	case game.ImpostorDisconnect:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.ImpostorDisconnect",
+			Other: "Imposters won because the last Human disconnected",
+		})
+	case game.ImpostorBySabotage:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.ImpostorBySabotage",
+			Other: "Imposters won by sabotage",
+		})
+	case game.ImpostorByVote:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.ImpostorByVote",
+			Other: "Imposters won by voting off the last Human",
+		})
+	case game.ImpostorByKill:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.ImpostorByKill",
+			Other: "Imposters won by killing the last Human",
+		})
+	case game.HidersByTimer:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.HidersByTimer",
+			Other: "Hiders won by surviving until the timer ran out",
+		})
+	case game.SeekerByKill:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchStatsEmbed.Win.SeekerByKill",
+			Other: "The Seeker won by finding every Hider",
+		})
+	}
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.matchStatsEmbed.Win.Unknown",
+		Other: "The game didn't finish, or how it ended is unknown",
+	})
+}
@@ -24,6 +24,12 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 	}
 	defer snowFlakeLock.Release(ctx)
 
+	// someone left a voice channel; once this change is handled (and the game unlocked), fill their slot from the
+	// waiting list if it's a game's channel
+	if m.BeforeUpdate != nil && m.BeforeUpdate.ChannelID != "" && m.BeforeUpdate.ChannelID != m.ChannelID {
+		defer bot.promoteAfterDeparture(s, m.GuildID, m.BeforeUpdate.ChannelID)
+	}
+
 	prem, days, _ := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, nil, m.GuildID, "")
 	premTier := premium.FreeTier
 	if !premium.IsExpired(prem, days) {
@@ -31,10 +37,7 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 	}
 
 	sett := bot.StorageInterface.GetGuildSettings(m.GuildID)
-	gsr := GameStateRequest{
-		GuildID:      m.GuildID,
-		VoiceChannel: m.ChannelID,
-	}
+	gsr := bot.voiceStateGameRequest(sett, m)
 
 	stateLock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
 	if stateLock == nil {
@@ -63,15 +66,20 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 		userData, _ = dgs.checkCacheAndAddUser(g, s, m.UserID)
 	}
 
-	tracked := m.ChannelID != "" && dgs.VoiceChannel == m.ChannelID
+	tracked := inGameVoiceChannel(sett, dgs, m.ChannelID)
 
-	auData, found := dgs.GameData.GetByName(userData.InGameName)
+	auData, found := dgs.trackedPlayer(userData)
+	roles := memberRoles(s, m.GuildID, m.UserID)
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	hasOverride := sett.HasVoiceOverride(m.UserID, roles)
 
 	var isAlive bool
 
-	// only actually tracked if we're in a tracked channel AND linked to a player
+	// only actually tracked if we're in a tracked channel AND linked to a player (or has an override of their own)
 	if !sett.GetMuteSpectator() {
-		tracked = tracked && found
+		tracked = tracked && (found || hasOverride)
 		isAlive = auData.IsAlive
 	} else {
 		if !found {
@@ -81,9 +89,18 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 			isAlive = auData.IsAlive
 		}
 	}
-	mute, deaf := sett.GetVoiceState(isAlive, tracked, dgs.GameData.GetPhase())
+	mode := dgs.getGameMode(sett)
+	player := voicePlayer(mode, auData)
+	player.IsAlive = isAlive
+	var mute, deaf bool
+	if !found && !sett.GetMuteSpectator() {
+		// only tracked because of an override, so not a player at all
+		mute, deaf = sett.GetUnlinkedVoiceStateFor(m.UserID, roles, tracked, dgs.GameData.GetPhase(), mode.Name)
+	} else {
+		mute, deaf = sett.GetVoiceStateFor(m.UserID, roles, player, tracked, dgs.GameData.GetPhase(), mode.Name)
+	}
 	// check the userdata is linked here to not accidentally undeafen music bots, for example
-	if found && (userData.ShouldBeDeaf != deaf || userData.ShouldBeMute != mute) && (mute != m.Mute || deaf != m.Deaf) {
+	if (found || hasOverride) && (userData.ShouldBeDeaf != deaf || userData.ShouldBeMute != mute) && (mute != m.Mute || deaf != m.Deaf) {
 		userData.SetShouldBeMuteDeaf(mute, deaf)
 
 		dgs.UpdateUserData(m.UserID, userData)
@@ -141,3 +158,40 @@ func (bot *Bot) handleGameStartMessage(guildID, textChannelID, voiceChannelID, u
 	// release the lock
 	bot.RedisInterface.SetDiscordGameState(dgs, lock)
 }
+
+// voiceStateGameRequest finds the game a voice state change is for. Games are found by their voice channel, but the
+// ghost channel is shared by all the guild's games, so a change there is for the game the member was moved from, or
+// failing that, the game they're linked to a player in
+func (bot *Bot) voiceStateGameRequest(sett *settings.GuildSettings, m *discordgo.VoiceStateUpdate) GameStateRequest {
+	gsr := GameStateRequest{
+		GuildID:      m.GuildID,
+		VoiceChannel: m.ChannelID,
+	}
+	if !isGhostChannel(sett, m.ChannelID) {
+		return gsr
+	}
+	if m.BeforeUpdate != nil && m.BeforeUpdate.ChannelID != "" && !isGhostChannel(sett, m.BeforeUpdate.ChannelID) {
+		from := GameStateRequest{GuildID: m.GuildID, VoiceChannel: m.BeforeUpdate.ChannelID}
+		if dgs := bot.RedisInterface.getDiscordGameState(from, false); dgs != nil && dgs.VoiceChannel == from.VoiceChannel {
+			return from
+		}
+	}
+	for _, connCode := range bot.RedisInterface.LoadAllActiveGames(m.GuildID) {
+		dgs := bot.RedisInterface.getDiscordGameState(GameStateRequest{GuildID: m.GuildID, ConnectCode: connCode}, false)
+		if dgs == nil {
+			continue
+		}
+		userData, err := dgs.GetUser(m.UserID)
+		if err != nil {
+			continue
+		}
+		if _, linked := dgs.trackedPlayer(userData); linked {
+			return GameStateRequest{GuildID: m.GuildID, ConnectCode: connCode}
+		}
+	}
+	return gsr
+}
+
+func isGhostChannel(sett *settings.GuildSettings, channelID string) bool {
+	return channelID != "" && channelID == sett.GetGhostChannelID()
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"testing"
+)
+
+func TestVoiceStateGameRequest(t *testing.T) {
+	const gameChannel, ghostChannel, otherChannel = "754465589958803550", "754465589958803551", "754465589958803552"
+	mr := miniredis.RunT(t)
+	bot := &Bot{RedisInterface: &RedisInterface{client: redis.NewClient(&redis.Options{Addr: mr.Addr()})}}
+
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "Alice"})
+	dgs.ConnectCode = "ABCDEFGH"
+	dgs.VoiceChannel = gameChannel
+	userData := dgs.UserData["140000000000000001"]
+	userData.Link(fuzzyTestPlayer(dgs, "Alice", 0))
+	dgs.UserData["140000000000000001"] = userData
+	bot.RedisInterface.SetDiscordGameState(dgs, nil)
+	bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
+
+	sett := settings.MakeGuildSettings()
+	sett.SetGhostChannelID(ghostChannel)
+	update := func(userID, before, after string) *discordgo.VoiceStateUpdate {
+		m := &discordgo.VoiceStateUpdate{VoiceState: &discordgo.VoiceState{GuildID: dgs.GuildID, UserID: userID, ChannelID: after}}
+		if before != "" {
+			m.BeforeUpdate = &discordgo.VoiceState{ChannelID: before}
+		}
+		return m
+	}
+
+	tests := []struct {
+		name   string
+		update *discordgo.VoiceStateUpdate
+		found  bool
+	}{
+		{"joined the game's channel", update("140000000000000001", "", gameChannel), true},
+		{"moved to the ghost channel", update("140000000000000001", gameChannel, ghostChannel), true},
+		{"joined the ghost channel", update("140000000000000001", "", ghostChannel), true},
+		{"came to the ghost channel from elsewhere", update("140000000000000001", otherChannel, ghostChannel), true},
+		{"not a player in the ghost channel", update("140000000000000002", otherChannel, ghostChannel), false},
+		{"another channel", update("140000000000000001", "", otherChannel), false},
+	}
+	for _, tt := range tests {
+		found := bot.RedisInterface.getDiscordGameState(bot.voiceStateGameRequest(sett, tt.update), false)
+		if (found != nil && found.ConnectCode == dgs.ConnectCode) != tt.found {
+			t.Errorf("%s: expected the game to be found=%v, got %v", tt.name, tt.found, found)
+		}
+	}
+}
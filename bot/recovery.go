@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/premium"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"strconv"
+	"time"
+)
+
+// CaptureSilenceTimeout is how long a capture can go without sending anything before a recovered game is considered
+// abandoned. Nobody is going to unmute the players of an abandoned game, so recovery unmutes them all
+const CaptureSilenceTimeout = 5 * time.Minute
+
+// recoverGames picks the guild's active games back up when the guild becomes available, which is on startup or after
+// the bot reconnects. Games that another node, or this one, is still processing are left alone
+func (bot *Bot) recoverGames(g *discordgo.Guild) {
+	for _, connCode := range bot.RedisInterface.LoadAllActiveGames(g.ID) {
+		bot.recoverGame(g, connCode)
+	}
+}
+
+func (bot *Bot) recoverGame(g *discordgo.Guild, connectCode string) {
+	// the guild is sent again after every reconnect, while this node's workers kept running
+	if bot.runningGameWorker(connectCode) {
+		return
+	}
+	gsr := GameStateRequest{
+		GuildID:     g.ID,
+		ConnectCode: connectCode,
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
+	for lock == nil {
+		lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
+	}
+	if dgs == nil || dgs.ConnectCode == "" {
+		lock.Release(ctx)
+		return
+	}
+	// another node is still processing the game, and keeping everyone's voice state right
+	if !bot.acquireGame(g.ID, dgs.ConnectCode) {
+		lock.Release(ctx)
+		return
+	}
+	log.Println("Resubscribing to Redis events for an old game: " + connectCode)
+
+	// fix voice states before processing anything the capture sent while the game had no worker, so those jobs are
+	// applied on top of what players actually are
+	if bot.captureSilent(dgs) {
+		log.Printf("Capture for game %s has gone silent, unmuting everyone\n", connectCode)
+		err := bot.applyToAll(dgs, false, false)
+		if err != nil {
+			log.Println(err)
+		}
+		for userID, userData := range dgs.UserData {
+			userData.SetShouldBeMuteDeaf(false, false)
+			dgs.UserData[userID] = userData
+		}
+	} else if dgs.Running {
+		sett := bot.StorageInterface.GetGuildSettings(g.ID)
+		users := voiceStateDrift(dgs, sett, g.VoiceStates, func(userID string) []string {
+			return memberRoles(bot.PrimarySession, g.ID, userID)
+		})
+		if len(users) > 0 {
+			log.Printf("Reapplying mutes/deafens to %d players in game %s\n", len(users), connectCode)
+			prem, days, _ := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, nil, g.ID, "")
+			premTier := premium.FreeTier
+			if !premium.IsExpired(prem, days) {
+				premTier = prem
+			}
+			req := task.UserModifyRequest{
+				Premium: premTier,
+				Users:   users,
+			}
+			err := bot.TokenProvider.ModifyUsers(g.ID, dgs.ConnectCode, req, nil)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	dgs.Subscribed = true
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	go bot.SubscribeToGameByConnectCode(g.ID, dgs.ConnectCode)
+}
+
+// captureSilent checks if the capture stopped sending anything for the game, including while no node was processing it
+func (bot *Bot) captureSilent(dgs *GameState) bool {
+	last, err := task.LastJobTime(ctx, bot.RedisInterface.client, dgs.ConnectCode)
+	if err != nil {
+		log.Println(err)
+	}
+	if last.IsZero() && dgs.LastCapturePing > 0 {
+		last = time.Unix(dgs.LastCapturePing, 0)
+	}
+	return time.Since(last) > CaptureSilenceTimeout
+}
+
+// voiceStateDrift lists the players whose server mute/deafen in Discord doesn't match what the game state says it
+// should be, for example because a mute was lost while the node processing the game was down
+func voiceStateDrift(dgs *GameState, sett *settings.GuildSettings, voiceStates []*discordgo.VoiceState, roles func(userID string) []string) []task.UserModify {
+	var users []task.UserModify
+	for _, voiceState := range voiceStates {
+		if !inGameVoiceChannel(sett, dgs, voiceState.ChannelID) {
+			continue
+		}
+		userData, err := dgs.GetUser(voiceState.UserID)
+		if err != nil {
+			continue
+		}
+		// same as handleTrackedMembers; only players we'd mute/deafen ourselves, so we don't touch music bots etc.
+		_, linked := dgs.trackedPlayer(userData)
+		if !linked && !sett.GetMuteSpectator() && !sett.HasVoiceOverride(voiceState.UserID, roles(voiceState.UserID)) {
+			continue
+		}
+		if voiceState.Mute != userData.ShouldBeMute || voiceState.Deaf != userData.ShouldBeDeaf {
+			uid, _ := strconv.ParseUint(voiceState.UserID, 10, 64)
+			users = append(users, task.UserModify{
+				UserID: uid,
+				Mute:   userData.ShouldBeMute,
+				Deaf:   userData.ShouldBeDeaf,
+			})
+		}
+	}
+	return users
+}
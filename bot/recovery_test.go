@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+func TestVoiceStateDrift(t *testing.T) {
+	const gameChannel = "754465589958803550"
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+		"140000000000000003": "Carol",
+		"140000000000000004": "MusicBot",
+	})
+	dgs.VoiceChannel = gameChannel
+	for color, userID := range []string{"140000000000000001", "140000000000000002", "140000000000000003"} {
+		userData := dgs.UserData[userID]
+		userData.Link(fuzzyTestPlayer(dgs, userData.GetUserName(), color))
+		userData.SetShouldBeMuteDeaf(true, false)
+		dgs.UserData[userID] = userData
+	}
+	sett := settings.MakeGuildSettings()
+	noRoles := func(string) []string { return nil }
+
+	voiceStates := []*discordgo.VoiceState{
+		// lost their mute while the bot was down
+		{UserID: "140000000000000001", ChannelID: gameChannel},
+		// already right
+		{UserID: "140000000000000002", ChannelID: gameChannel, Mute: true},
+		// not in the game's channel anymore
+		{UserID: "140000000000000003", ChannelID: "754465589958803551"},
+		// not a player; muted by someone else
+		{UserID: "140000000000000004", ChannelID: gameChannel, Mute: true},
+	}
+	users := voiceStateDrift(dgs, sett, voiceStates, noRoles)
+	if len(users) != 1 {
+		t.Fatalf("expected only 1 player to need fixing, got %v", users)
+	}
+	if users[0].UserID != 140000000000000001 || !users[0].Mute || users[0].Deaf {
+		t.Errorf("expected Alice to be muted again, got %+v", users[0])
+	}
+
+	// with spectators muted, unlinked users count too
+	sett.SetMuteSpectator(true)
+	users = voiceStateDrift(dgs, sett, voiceStates, noRoles)
+	if len(users) != 2 || users[1].UserID != 140000000000000004 || users[1].Mute {
+		t.Errorf("expected the spectator to be unmuted to match the game state, got %v", users)
+	}
+}
+
+func TestRecoverRunningGame(t *testing.T) {
+	mr := miniredis.RunT(t)
+	bot := &Bot{
+		nodeID:         "node",
+		RedisInterface: &RedisInterface{client: redis.NewClient(&redis.Options{Addr: mr.Addr()})},
+	}
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "Alice"})
+	dgs.ConnectCode = "ABCDEFGH"
+	bot.RedisInterface.SetDiscordGameState(dgs, nil)
+	bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
+
+	// this node's worker is still running when the guild is sent again after a reconnect
+	bot.gameWorkers.Store(dgs.ConnectCode, struct{}{})
+	bot.recoverGames(&discordgo.Guild{ID: dgs.GuildID})
+	recovered := bot.RedisInterface.getDiscordGameState(GameStateRequest{GuildID: dgs.GuildID, ConnectCode: dgs.ConnectCode}, false)
+	if recovered.Subscribed {
+		t.Error("expected a game this node is running not to be recovered")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		bot.SubscribeToGameByConnectCode(dgs.GuildID, dgs.ConnectCode)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected a second worker for the same game to exit right away")
+	}
+	if !bot.runningGameWorker(dgs.ConnectCode) {
+		t.Error("expected the second worker not to unregister the first")
+	}
+}
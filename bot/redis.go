@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"github.com/automuteus/automuteus/v8/internal/server"
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	storageutils "github.com/automuteus/automuteus/v8/pkg/storage"
 	"github.com/automuteus/automuteus/v8/storage"
 	"github.com/bsm/redislock"
 	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
 	"log"
+	"strconv"
 	"time"
 )
 
@@ -347,90 +349,57 @@ func (redisInterface *RedisInterface) DeleteDiscordGameState(dgs *GameState) {
 	}
 }
 
-func (redisInterface *RedisInterface) GetUsernameOrUserIDMappings(guildID, key string) (map[string]interface{}, error) {
-	cacheHash := rediskey.GuildCacheHash(guildID)
-
-	value, err := redisInterface.client.HGet(ctx, cacheHash, key).Result()
-	if err != nil {
-		if !errors.Is(err, redis.Nil) {
-			return map[string]interface{}{}, err
+// MigrateUsernameLinksToPostgres moves the in-game name links that used to be cached in a Redis hash per guild into
+// the player_aliases table, and deletes the hashes once they're copied. Returns how many aliases were migrated
+func MigrateUsernameLinksToPostgres(redisInterface *RedisInterface, psql *storageutils.PsqlInterface) (int, error) {
+	migrated := 0
+	iter := redisInterface.client.Scan(ctx, 0, rediskey.GuildCacheHashPattern, 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		gid, err := strconv.ParseUint(rediskey.GuildCacheHashGuildID(key), 10, 64)
+		if err != nil {
+			log.Println(err)
+			continue
 		}
-		// redis.Nil (not found) is not *actually* an error, so return nil
-		return map[string]interface{}{}, nil
-	}
-	var ret map[string]interface{}
-	err = json.Unmarshal([]byte(value), &ret)
-	if err != nil {
-		return map[string]interface{}{}, err
-	}
-
-	return ret, nil
-}
-
-func (redisInterface *RedisInterface) AddUsernameLink(guildID, userID, userName string) error {
-	err := redisInterface.appendToHashedEntry(guildID, userID, userName)
-	if err != nil {
-		return err
-	}
-	return redisInterface.appendToHashedEntry(guildID, userName, userID)
-}
-
-func (redisInterface *RedisInterface) DeleteLinksByUserID(guildID, userID string) error {
-	// over all the usernames associated with just this userID, delete the underlying mapping of username->userID
-	usernames, err := redisInterface.GetUsernameOrUserIDMappings(guildID, userID)
-	if err != nil {
-		log.Println(err)
-	} else {
-		for username := range usernames {
-			err := redisInterface.deleteHashSubEntry(guildID, username, userID)
+		entries, err := redisInterface.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return migrated, err
+		}
+		now := time.Now()
+		var aliases []*storageutils.PostgresPlayerAlias
+		for field, value := range entries {
+			// the hash was keyed both ways; userID -> names is enough to rebuild it
+			uid, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				continue
+			}
+			var names map[string]interface{}
+			err = json.Unmarshal([]byte(value), &names)
 			if err != nil {
 				log.Println(err)
+				continue
+			}
+			for name := range names {
+				aliases = append(aliases, &storageutils.PostgresPlayerAlias{
+					GuildID:        gid,
+					UserID:         uid,
+					PlayerName:     name,
+					TimesConfirmed: 1,
+					LastSeen:       now,
+				})
 			}
 		}
+		err = psql.ImportPlayerAliases(aliases)
+		if err != nil {
+			return migrated, err
+		}
+		migrated += len(aliases)
+		err = redisInterface.client.Del(ctx, key).Err()
+		if err != nil {
+			log.Println(err)
+		}
 	}
-
-	// now delete the userID->username list entirely
-	cacheHash := rediskey.GuildCacheHash(guildID)
-	return redisInterface.client.HDel(ctx, cacheHash, userID).Err()
-}
-
-func (redisInterface *RedisInterface) appendToHashedEntry(guildID, key, value string) error {
-	resp, err := redisInterface.GetUsernameOrUserIDMappings(guildID, key)
-	if err != nil {
-		log.Println(err)
-	}
-
-	resp[value] = struct{}{}
-
-	return redisInterface.setUsernameOrUserIDMappings(guildID, key, resp)
-}
-
-func (redisInterface *RedisInterface) deleteHashSubEntry(guildID, key, entry string) error {
-	entries, err := redisInterface.GetUsernameOrUserIDMappings(guildID, key)
-	if err != nil {
-		log.Println(err)
-	} else {
-		delete(entries, entry)
-	}
-
-	return redisInterface.setUsernameOrUserIDMappings(guildID, key, entries)
-}
-
-func (redisInterface *RedisInterface) setUsernameOrUserIDMappings(guildID, key string, values map[string]interface{}) error {
-	cacheHash := rediskey.GuildCacheHash(guildID)
-
-	jBytes, err := json.Marshal(values)
-	if err != nil {
-		return err
-	}
-
-	err = redisInterface.client.HSet(ctx, cacheHash, key, jBytes).Err()
-	// 1 week TTL on username cache
-	if err == nil {
-		redisInterface.client.Expire(ctx, cacheHash, time.Hour*24*7)
-	}
-
-	return err
+	return migrated, iter.Err()
 }
 
 func (redisInterface *RedisInterface) LockSnowflake(snowflake string) *redislock.Lock {
@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/bot/tokenprovider"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	storageutils "github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/automuteus/automuteus/v8/storage"
+	"github.com/bwmarrin/discordgo"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ReplayGuildID        = "100000000000000000"
+	ReplayTextChannelID  = "100000000000000001"
+	ReplayVoiceChannelID = "100000000000000002"
+	ReplayBotUserID      = "100000000000000003"
+	ReplayGhostChannelID = "100000000000000004"
+	ReplayConnectCode    = "REPLAY"
+
+	// the minimum amount of simulated time between two events, so voice locks from the previous event have expired
+	replayMinStep = time.Second
+)
+
+// ReplayDecision is a single mute/deafen request the bot issued to Discord while replaying a match
+type ReplayDecision struct {
+	Event     int          `json:"event"`
+	EventType task.JobType `json:"eventType"`
+	UserID    string       `json:"userID"`
+	Mute      bool         `json:"mute"`
+	Deaf      bool         `json:"deaf"`
+	// ChannelID is set when the user was moved to another voice channel, ie the ghost channel
+	ChannelID string `json:"channelID,omitempty"`
+}
+
+type ReplayOptions struct {
+	// Settings to replay with; the defaults are used if nil
+	Settings *settings.GuildSettings
+	// KeepDelays sleeps for the configured mute delays, instead of replaying them instantly
+	KeepDelays bool
+}
+
+// LoadReplayEventsFromFile reads events in the same JSON shape the bot stores them in Postgres
+func LoadReplayEventsFromFile(path string) ([]*storageutils.PostgresGameEvent, error) {
+	f, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var events []*storageutils.PostgresGameEvent
+	err = json.Unmarshal(f, &events)
+	return events, err
+}
+
+// Replay feeds recorded game_events back through the same processing paths as SubscribeToGameByConnectCode, against
+// an in-memory Redis and a fake Discord session, and returns every mute/deafen decision the bot made along the way
+func Replay(events []*storageutils.PostgresGameEvent, opts ReplayOptions) ([]ReplayDecision, error) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		return nil, err
+	}
+	defer mr.Close()
+
+	transport := &replayTransport{}
+	sess, err := newReplaySession(transport, events)
+	if err != nil {
+		return nil, err
+	}
+
+	var redisInterface RedisInterface
+	var storageInterface storage.StorageInterface
+	params := storage.RedisParameters{Addr: mr.Addr()}
+	_ = redisInterface.Init(params)
+	_ = storageInterface.Init(params)
+	defer redisInterface.Close()
+	defer storageInterface.Close()
+
+	sett := settings.MakeGuildSettings()
+	if opts.Settings != nil {
+		// work on a copy; the caller's settings are left untouched
+		jBytes, err := json.Marshal(opts.Settings)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(jBytes, sett)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !opts.KeepDelays {
+		// no delays at all; every lookup comes back 0
+		sett.Delays = game.GameDelays{}
+	}
+	err = storageInterface.SetGuildSettings(ReplayGuildID, sett)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := &Bot{
+		nodeID:           "replay",
+		StatusEmojis:     emptyStatusEmojis(),
+		PrimarySession:   sess,
+		RedisInterface:   &redisInterface,
+		StorageInterface: &storageInterface,
+		captureTimeout:   GameTimeoutSeconds,
+	}
+	bot.TokenProvider = tokenprovider.NewTokenProvider(redisInterface.client, sess, time.Millisecond, 1)
+	// there's no capture client to ack mutes, so don't even try; every decision goes through the fake session
+	bot.TokenProvider.SetDefaultBackendOrder(tokenprovider.OfficialBackend)
+
+	g, err := sess.State.Guild(ReplayGuildID)
+	if err != nil {
+		return nil, err
+	}
+	bot.handleGameStartMessage(ReplayGuildID, ReplayTextChannelID, ReplayVoiceChannelID, ReplayBotUserID, sett, g, ReplayConnectCode)
+	transport.take()
+
+	dgsRequest := GameStateRequest{
+		GuildID:     ReplayGuildID,
+		ConnectCode: ReplayConnectCode,
+	}
+	var decisions []ReplayDecision
+	for i, event := range events {
+		if i > 0 {
+			step := event.EventTime.Sub(events[i-1].EventTime)
+			if step < replayMinStep {
+				step = replayMinStep
+			}
+			mr.FastForward(step)
+		}
+
+		job := task.Job{
+			ID:      strconv.Itoa(i),
+			JobType: task.JobType(event.EventType),
+			Payload: event.Payload,
+		}
+		bot.processJob(dgsRequest, job)
+
+		for _, v := range transport.take() {
+			v.Event = i
+			v.EventType = job.JobType
+			decisions = append(decisions, v)
+		}
+	}
+	return decisions, nil
+}
+
+// newReplaySession builds a session whose state holds one voice channel with a member for every user that appears
+// in the events. Members are named after their in-game name so they pair automatically, like they did originally
+func newReplaySession(transport *replayTransport, events []*storageutils.PostgresGameEvent) (*discordgo.Session, error) {
+	sess, err := discordgo.New("Bot replay")
+	if err != nil {
+		return nil, err
+	}
+	sess.Client = &http.Client{Transport: transport}
+	transport.sess = sess
+	sess.State.User = &discordgo.User{ID: ReplayBotUserID, Bot: true}
+
+	guild := &discordgo.Guild{
+		ID:   ReplayGuildID,
+		Name: "replay",
+		Channels: []*discordgo.Channel{
+			{ID: ReplayTextChannelID, GuildID: ReplayGuildID, Type: discordgo.ChannelTypeGuildText},
+			{ID: ReplayVoiceChannelID, GuildID: ReplayGuildID, Type: discordgo.ChannelTypeGuildVoice},
+			{ID: ReplayGhostChannelID, GuildID: ReplayGuildID, Type: discordgo.ChannelTypeGuildVoice},
+		},
+	}
+	seen := map[uint64]bool{}
+	for _, event := range events {
+		if event.UserID == nil || seen[*event.UserID] || task.JobType(event.EventType) != task.PlayerJob {
+			continue
+		}
+		var player game.Player
+		if json.Unmarshal([]byte(event.Payload), &player) != nil || player.Name == "" {
+			continue
+		}
+		seen[*event.UserID] = true
+		userID := strconv.FormatUint(*event.UserID, 10)
+		guild.Members = append(guild.Members, &discordgo.Member{
+			GuildID: ReplayGuildID,
+			Nick:    player.Name,
+			User:    &discordgo.User{ID: userID, Username: player.Name},
+		})
+		guild.VoiceStates = append(guild.VoiceStates, &discordgo.VoiceState{
+			GuildID:   ReplayGuildID,
+			ChannelID: ReplayVoiceChannelID,
+			UserID:    userID,
+		})
+	}
+	err = sess.State.GuildAdd(guild)
+	return sess, err
+}
+
+// replayTransport stands in for the Discord REST API. Mute/deafen PATCHes are recorded, everything else succeeds
+type replayTransport struct {
+	// moves are applied to the session's state, like the gateway would
+	sess      *discordgo.Session
+	lock      sync.Mutex
+	pending   []ReplayDecision
+	messageID int
+}
+
+func (rt *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	body := "{}"
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == http.MethodPatch && len(path) >= 2 && path[len(path)-2] == "members":
+		var params task.PatchParams
+		if req.Body != nil {
+			_ = json.NewDecoder(req.Body).Decode(&params)
+		}
+		userID := path[len(path)-1]
+		rt.pending = append(rt.pending, ReplayDecision{
+			UserID:    userID,
+			Mute:      params.Mute,
+			Deaf:      params.Deaf,
+			ChannelID: params.ChannelID,
+		})
+		body = fmt.Sprintf(`{"user":{"id":"%s"},"mute":%v,"deaf":%v}`, userID, params.Mute, params.Deaf)
+		if params.ChannelID != "" && rt.sess != nil {
+			_ = rt.sess.State.OnInterface(rt.sess, &discordgo.VoiceStateUpdate{VoiceState: &discordgo.VoiceState{
+				GuildID:   ReplayGuildID,
+				ChannelID: params.ChannelID,
+				UserID:    userID,
+			}})
+		}
+	default:
+		// message creates and edits; hand back a message so the game state message looks like it exists
+		for i, v := range path {
+			if v == "channels" && i+1 < len(path) {
+				rt.messageID++
+				body = fmt.Sprintf(`{"id":"%d","channel_id":"%s"}`, rt.messageID, path[i+1])
+			}
+		}
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Request:    req,
+	}, nil
+}
+
+// take returns the decisions recorded since the last call, sorted by user so replays are deterministic
+// (mutes within one batch are issued concurrently)
+func (rt *replayTransport) take() []ReplayDecision {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+	d := rt.pending
+	rt.pending = nil
+	sort.SliceStable(d, func(i, j int) bool {
+		return d[i].UserID < d[j].UserID
+	})
+	return d
+}
@@ -0,0 +1,144 @@
+package bot
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	storageutils "github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"testing"
+	"time"
+)
+
+func replayEvent(t int32, jobType task.JobType, userID uint64, payload string) *storageutils.PostgresGameEvent {
+	e := &storageutils.PostgresGameEvent{
+		EventTime: time.Unix(int64(t), 0),
+		EventType: int16(jobType),
+		Payload:   payload,
+	}
+	if userID != 0 {
+		e.UserID = &userID
+	}
+	return e
+}
+
+const (
+	replayAlice = "111111111111111111"
+	replayBob   = "222222222222222222"
+)
+
+// a short game where bob dies in the first round, and alice wins
+func replayTestEvents() []*storageutils.PostgresGameEvent {
+	return []*storageutils.PostgresGameEvent{
+		replayEvent(100, task.LobbyJob, 0, `{"LobbyCode":"ABCDEF","Region":0,"Map":0}`),
+		replayEvent(101, task.StateJob, 0, "0"),
+		replayEvent(102, task.PlayerJob, 111111111111111111, `{"Action":0,"Name":"alice","Color":0,"IsDead":false,"Disconnected":false}`),
+		replayEvent(103, task.PlayerJob, 222222222222222222, `{"Action":0,"Name":"bob","Color":1,"IsDead":false,"Disconnected":false}`),
+		replayEvent(110, task.StateJob, 0, "1"),
+		replayEvent(130, task.PlayerJob, 222222222222222222, `{"Action":2,"Name":"bob","Color":1,"IsDead":true,"Disconnected":false}`),
+		replayEvent(140, task.StateJob, 0, "2"),
+		replayEvent(200, task.StateJob, 0, "1"),
+		replayEvent(260, task.GameOverJob, 0, `{"GameOverReason":0,"PlayerInfos":[{"Name":"alice","IsImpostor":false},{"Name":"bob","IsImpostor":true}]}`),
+		replayEvent(261, task.StateJob, 0, "0"),
+	}
+}
+
+func TestReplay(t *testing.T) {
+	const alice = replayAlice
+	const bob = replayBob
+
+	decisions, err := Replay(replayTestEvents(), ReplayOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReplayDecision{
+		// tasks: everyone alive is muted and deafened
+		{Event: 4, EventType: task.StateJob, UserID: alice, Mute: true, Deaf: true},
+		{Event: 4, EventType: task.StateJob, UserID: bob, Mute: true, Deaf: true},
+		// discussion: bob died, so only he stays muted
+		{Event: 6, EventType: task.StateJob, UserID: alice, Mute: false, Deaf: false},
+		{Event: 6, EventType: task.StateJob, UserID: bob, Mute: true, Deaf: false},
+		// tasks again: the dead can talk amongst themselves
+		{Event: 7, EventType: task.StateJob, UserID: alice, Mute: true, Deaf: true},
+		{Event: 7, EventType: task.StateJob, UserID: bob, Mute: false, Deaf: false},
+		// back to the lobby
+		{Event: 9, EventType: task.StateJob, UserID: alice, Mute: false, Deaf: false},
+	}
+	if len(decisions) != len(expected) {
+		t.Fatalf("expected %d decisions, got %d: %+v", len(expected), len(decisions), decisions)
+	}
+	for i, v := range expected {
+		if decisions[i] != v {
+			t.Errorf("decision %d: expected %+v, got %+v", i, v, decisions[i])
+		}
+	}
+}
+
+func TestReplayGhostChannel(t *testing.T) {
+	const alice = replayAlice
+	const bob = replayBob
+
+	sett := settings.MakeGuildSettings()
+	sett.SetGhostChannelID(ReplayGhostChannelID)
+	decisions, err := Replay(replayTestEvents(), ReplayOptions{Settings: sett})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReplayDecision{
+		{Event: 4, EventType: task.StateJob, UserID: alice, Mute: true, Deaf: true},
+		{Event: 4, EventType: task.StateJob, UserID: bob, Mute: true, Deaf: true},
+		{Event: 6, EventType: task.StateJob, UserID: alice, Mute: false, Deaf: false},
+		{Event: 6, EventType: task.StateJob, UserID: bob, Mute: true, Deaf: false},
+		// tasks again: bob is moved to the ghost channel
+		{Event: 7, EventType: task.StateJob, UserID: alice, Mute: true, Deaf: true},
+		{Event: 7, EventType: task.StateJob, UserID: bob, Mute: false, Deaf: false, ChannelID: ReplayGhostChannelID},
+		// back to the lobby, and bob comes back with everyone else
+		{Event: 9, EventType: task.StateJob, UserID: alice, Mute: false, Deaf: false},
+		{Event: 9, EventType: task.StateJob, UserID: bob, Mute: false, Deaf: false, ChannelID: ReplayVoiceChannelID},
+	}
+	if len(decisions) != len(expected) {
+		t.Fatalf("expected %d decisions, got %d: %+v", len(expected), len(decisions), decisions)
+	}
+	for i, v := range expected {
+		if decisions[i] != v {
+			t.Errorf("decision %d: expected %+v, got %+v", i, v, decisions[i])
+		}
+	}
+}
+
+func TestReplayHideAndSeek(t *testing.T) {
+	const bob = replayBob
+
+	// everybody alive can talk during tasks, except the seeker
+	sett := settings.MakeGuildSettings()
+	sett.SetVoiceRule(true, game.TASKS, game.AliveState, false)
+	sett.SetVoiceRule(false, game.TASKS, game.AliveState, false)
+	events := []*storageutils.PostgresGameEvent{
+		replayEvent(100, task.LobbyJob, 0, `{"LobbyCode":"ABCDEF","Region":0,"Map":0,"GameMode":2}`),
+		replayEvent(101, task.StateJob, 0, "0"),
+		replayEvent(102, task.PlayerJob, 111111111111111111, `{"Action":0,"Name":"alice","Color":0,"IsDead":false,"Disconnected":false}`),
+		replayEvent(103, task.PlayerJob, 222222222222222222, `{"Action":0,"Name":"bob","Color":1,"IsDead":false,"Disconnected":false,"IsImpostor":true}`),
+		replayEvent(110, task.StateJob, 0, "1"),
+		// there are no meetings in hide-and-seek, so nothing changes even if the capture reports one
+		replayEvent(140, task.StateJob, 0, "2"),
+		replayEvent(260, task.StateJob, 0, "0"),
+	}
+	decisions, err := Replay(events, ReplayOptions{Settings: sett})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReplayDecision{
+		{Event: 4, EventType: task.StateJob, UserID: bob, Mute: true, Deaf: false},
+		{Event: 6, EventType: task.StateJob, UserID: bob, Mute: false, Deaf: false},
+	}
+	if len(decisions) != len(expected) {
+		t.Fatalf("expected %d decisions, got %d: %+v", len(expected), len(decisions), decisions)
+	}
+	for i, v := range expected {
+		if decisions[i] != v {
+			t.Errorf("decision %d: expected %+v, got %+v", i, v, decisions[i])
+		}
+	}
+}
@@ -205,6 +205,10 @@ func lobbyMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.GuildSe
 
 	listResp := dgs.ToEmojiEmbedFields(emojis, sett)
 	listResp = append(gameInfoFields, listResp...)
+	// 参加待ちリスト
+	if field := dgs.waitingListField(); field != nil {
+		listResp = append(listResp, field)
+	}
 
 	desc, color := dgs.descriptionAndColor(sett)
 	if color == discord.DEFAULT {
@@ -214,7 +218,7 @@ func lobbyMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.GuildSe
 	msg := discordgo.MessageEmbed{
 		URL:  "",
 		Type: "",
-		Title: "ロビー",
+		Title: modeTitle("ロビー", dgs.getGameMode(sett)),
 		Description: desc,
 		Timestamp:   time.Now().Format(ISO8601),
 		Footer: &discordgo.MessageEmbedFooter{
@@ -266,7 +270,7 @@ func gameOverMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.Guil
 	msg := discordgo.MessageEmbed{
 		URL:         "",
 		Type:        "",
-		Title:       sett.LocalizeMessage(amongus.ToLocale(game.GAMEOVER)),
+		Title:       modeTitle(sett.LocalizeMessage(amongus.ToLocale(game.GAMEOVER)), dgs.getGameMode(sett)),
 		Description: desc,
 		Timestamp:   time.Now().Format(ISO8601),
 		Footer:      footer,
@@ -289,12 +293,18 @@ func getThumbnailFromMap(playMap game.PlayMap, sett *settings.GuildSettings) *di
 // ===== ゲーム中（TASK / DISCUSS） =====
 
 func gamePlayMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.GuildSettings) *discordgo.MessageEmbed {
-	phase := dgs.GameData.GetPhase()
+	mode := dgs.getGameMode(sett)
+	// かくれんぼには会議が無いので、タスク中として表示
+	phase := mode.VoicePhase(dgs.GameData.GetPhase())
 	// send empty fields because we don't need to display those fields during the game...
 	listResp := dgs.ToEmojiEmbedFields(emojis, sett)
 
 	gameInfoFields := lobbyMetaEmbedFields("", "", dgs.GameStateMsg.LeaderID, dgs.VoiceChannel, dgs.GameData.GetNumDetectedPlayers(), dgs.GetCountLinked(), sett)
 	listResp = append(gameInfoFields, listResp...)
+	// 参加待ちリスト
+	if field := dgs.waitingListField(); field != nil {
+		listResp = append(listResp, field)
+	}
 	desc, color := dgs.descriptionAndColor(sett)
 	if color == discord.DEFAULT {
 		switch phase {
@@ -307,12 +317,14 @@ func gamePlayMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.Guil
 
 	// フェーズ名を日本語寄りに
 	title := ""
-	switch phase {
-	case game.TASKS:
+	switch {
+	case phase == game.TASKS && mode.IsHideAndSeek():
+		title = "かくれんぼ中"
+	case phase == game.TASKS:
 		title = "タスク中"
-	case game.DISCUSS:
+	case phase == game.DISCUSS:
 		title = "会議中"
-	case game.GAMEOVER:
+	case phase == game.GAMEOVER:
 		title = "ゲーム終了"
 	default:
 		title = sett.LocalizeMessage(amongus.ToLocale(phase))
@@ -337,6 +349,14 @@ func gamePlayMessage(dgs *GameState, emojis AlivenessEmojis, sett *settings.Guil
 	return &msg
 }
 
+// modeTitle marks the title of games that aren't played like the base game
+func modeTitle(title string, mode game.GameMode) string {
+	if mode.IsHideAndSeek() {
+		return title + "（かくれんぼ）"
+	}
+	return title
+}
+
 // returns the description and color to use, based on the gamestate
 // usage dictates DEFAULT should be overwritten by other state subsequently,
 // whereas RED and DARK_ORANGE are error/flag values that should be passed on
@@ -0,0 +1,267 @@
+package bot
+
+import (
+	"fmt"
+	"github.com/automuteus/automuteus/v8/bot/command"
+	"github.com/automuteus/automuteus/v8/pkg/amongus"
+	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"log"
+	"strings"
+)
+
+// MaxLobbyPlayers is how many players fit in an Among Us lobby
+const MaxLobbyPlayers = 15
+
+const (
+	rosterJoinID  = "roster-join"
+	rosterLeaveID = "roster-leave"
+)
+
+// trackedPlayer is the in-game player the user is linked to. Spectators sat out to let someone else play, so they're
+// treated like any other unlinked user (muted or not depending on MuteSpectator), even if they're still linked from
+// an earlier match
+func (dgs *GameState) trackedPlayer(userData UserData) (amongus.PlayerData, bool) {
+	if userData.Spectator {
+		return amongus.PlayerData{}, false
+	}
+	return dgs.GameData.GetByName(userData.InGameName)
+}
+
+// WaitingPosition is the user's place in the waiting list, starting at 1, or 0 if they aren't waiting
+func (dgs *GameState) WaitingPosition(userID string) int {
+	for i, v := range dgs.WaitingList {
+		if v == userID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// JoinWaitingList puts the user at the end of the waiting list, and flags them as a spectator until they're
+// promoted. Returns their position
+func (dgs *GameState) JoinWaitingList(userID string) int {
+	if v, ok := dgs.UserData[userID]; ok {
+		v.Spectator = true
+		dgs.UserData[userID] = v
+	}
+	if pos := dgs.WaitingPosition(userID); pos > 0 {
+		return pos
+	}
+	dgs.WaitingList = append(dgs.WaitingList, userID)
+	return len(dgs.WaitingList)
+}
+
+// LeaveWaitingList takes the user off the waiting list, and back out of spectating. Returns false if they weren't
+// waiting
+func (dgs *GameState) LeaveWaitingList(userID string) bool {
+	pos := dgs.WaitingPosition(userID)
+	if pos == 0 {
+		return false
+	}
+	dgs.WaitingList = append(dgs.WaitingList[:pos-1], dgs.WaitingList[pos:]...)
+	if v, ok := dgs.UserData[userID]; ok {
+		v.Spectator = false
+		dgs.UserData[userID] = v
+	}
+	return true
+}
+
+// PromoteWaiting takes up to openings users off the front of the waiting list, and returns them
+func (dgs *GameState) PromoteWaiting(openings int) []string {
+	if openings <= 0 || len(dgs.WaitingList) == 0 {
+		return nil
+	}
+	if openings > len(dgs.WaitingList) {
+		openings = len(dgs.WaitingList)
+	}
+	promoted := make([]string, openings)
+	copy(promoted, dgs.WaitingList[:openings])
+	for _, userID := range promoted {
+		dgs.LeaveWaitingList(userID)
+	}
+	return promoted
+}
+
+// PruneWaitingList drops users who got linked to a player some other way (they joined the lobby without waiting for
+// a slot). Returns true if any were dropped
+func (dgs *GameState) PruneWaitingList() bool {
+	pruned := false
+	for _, userID := range append([]string{}, dgs.WaitingList...) {
+		if v, ok := dgs.UserData[userID]; ok && !v.Spectator {
+			dgs.LeaveWaitingList(userID)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+// rosterSize counts the users in the game's voice channel that are playing, rather than spectating. Dead players moved
+// to the ghost channel are still in the game, so they count too
+func (dgs *GameState) rosterSize(sett *settings.GuildSettings, voiceStates []*discordgo.VoiceState) int {
+	size := 0
+	for _, voiceState := range voiceStates {
+		if !inGameVoiceChannel(sett, dgs, voiceState.ChannelID) {
+			continue
+		}
+		if v, ok := dgs.UserData[voiceState.UserID]; ok && v.Spectator {
+			continue
+		}
+		size++
+	}
+	return size
+}
+
+// promoteToOpenings moves as many waiting users onto the roster as there's room for
+func (dgs *GameState) promoteToOpenings(sett *settings.GuildSettings, g *discordgo.Guild) []string {
+	return dgs.PromoteWaiting(MaxLobbyPlayers - dgs.rosterSize(sett, g.VoiceStates))
+}
+
+// rosterButtons lead the last row of the game state message; labels match the rest of the message's buttons
+func rosterButtons() []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.Button{
+			CustomID: rosterJoinID,
+			Style:    discordgo.SuccessButton,
+			Label:    "参加待ちに並ぶ",
+			Emoji:    discordgo.ComponentEmoji{Name: "🙋"},
+		},
+		discordgo.Button{
+			CustomID: rosterLeaveID,
+			Style:    discordgo.SecondaryButton,
+			Label:    "列から抜ける",
+		},
+	}
+}
+
+// waitingListField lists the waiting users on the game state message, or nil if nobody is waiting
+func (dgs *GameState) waitingListField() *discordgo.MessageEmbedField {
+	if len(dgs.WaitingList) == 0 {
+		return nil
+	}
+	mentions := make([]string, len(dgs.WaitingList))
+	for i, userID := range dgs.WaitingList {
+		mentions[i] = discord.MentionByUserID(userID)
+	}
+	return &discordgo.MessageEmbedField{
+		Name:   fmt.Sprintf("参加待ち（%d人）", len(dgs.WaitingList)),
+		Value:  strings.Join(mentions, " "),
+		Inline: false,
+	}
+}
+
+// announcePromotions pings the promoted users in the game's text channel, so they know to get in the lobby
+func (bot *Bot) announcePromotions(dgs *GameState, promoted []string, sett *settings.GuildSettings) {
+	if len(promoted) == 0 {
+		return
+	}
+	mentions := make([]string, len(promoted))
+	for i, userID := range promoted {
+		mentions[i] = discord.MentionByUserID(userID)
+	}
+	_, err := bot.PrimarySession.ChannelMessageSend(dgs.GameStateMsg.MessageChannelID, sett.LocalizeMessage(&i18n.Message{
+		ID:    "roster.promoted",
+		Other: "🎟️ {{.Users}} a slot opened up in {{.Voice}}, you're in!",
+	}, map[string]interface{}{
+		"Users": strings.Join(mentions, " "),
+		"Voice": discord.MentionByChannelID(dgs.VoiceChannel),
+	}))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// promoteAfterDeparture fills the slot freed up when someone leaves the game's voice channel
+func (bot *Bot) promoteAfterDeparture(s *discordgo.Session, guildID, channelID string) {
+	gsr := GameStateRequest{
+		GuildID:      guildID,
+		VoiceChannel: channelID,
+	}
+	// don't create a game state just for checking; most voice channels don't have a game
+	if dgs := bot.RedisInterface.getDiscordGameState(gsr, false); dgs == nil || len(dgs.WaitingList) == 0 {
+		return
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when promoting from the waiting list for guild %s\n", guildID)
+		return
+	}
+	g, err := s.State.Guild(guildID)
+	if err != nil || !dgs.GameStateMsg.Exists() || dgs.VoiceChannel != channelID {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return
+	}
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	promoted := dgs.promoteToOpenings(sett, g)
+	if len(promoted) == 0 {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return
+	}
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+
+	bot.announcePromotions(dgs, promoted, sett)
+	bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+}
+
+// handleRosterButton handles the join/leave queue buttons on the game state message
+func (bot *Bot) handleRosterButton(s *discordgo.Session, g *discordgo.Guild, gsr GameStateRequest, userID string, join bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		return command.DeadlockGameStateResponse("roster", sett)
+	}
+	if !dgs.GameStateMsg.Exists() {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return command.NoGameResponse(sett)
+	}
+	if _, err := dgs.GetUser(userID); err != nil {
+		dgs.checkCacheAndAddUser(g, s, userID)
+	}
+
+	var msg string
+	var promoted []string
+	if join {
+		pos := dgs.JoinWaitingList(userID)
+		promoted = dgs.promoteToOpenings(sett, g)
+		msg = sett.LocalizeMessage(&i18n.Message{
+			ID:    "roster.joined",
+			Other: "🙋 You're #{{.Position}} in the queue. I'll ping you when a slot opens up",
+		}, map[string]interface{}{
+			"Position": pos,
+		})
+		for _, v := range promoted {
+			if v == userID {
+				msg = sett.LocalizeMessage(&i18n.Message{
+					ID:    "roster.joined.room",
+					Other: "🎟️ There's room in the lobby, you're in!",
+				})
+			}
+		}
+	} else {
+		if !dgs.LeaveWaitingList(userID) {
+			bot.RedisInterface.SetDiscordGameState(nil, lock)
+			return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+				ID:    "roster.left.notWaiting",
+				Other: "You're not in the queue",
+			}))
+		}
+		promoted = dgs.promoteToOpenings(sett, g)
+		msg = sett.LocalizeMessage(&i18n.Message{
+			ID:    "roster.left",
+			Other: "👋 You left the queue",
+		})
+	}
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+
+	// whoever pressed the button already knows
+	var others []string
+	for _, v := range promoted {
+		if v != userID {
+			others = append(others, v)
+		}
+	}
+	bot.announcePromotions(dgs, others, sett)
+	bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+	return command.PrivateResponse(msg)
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"reflect"
+	"testing"
+)
+
+func TestWaitingList(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+		"140000000000000003": "Carol",
+	})
+
+	if pos := dgs.JoinWaitingList("140000000000000001"); pos != 1 {
+		t.Errorf("expected the first user to be 1st in line, got %d", pos)
+	}
+	if pos := dgs.JoinWaitingList("140000000000000002"); pos != 2 {
+		t.Errorf("expected the second user to be 2nd in line, got %d", pos)
+	}
+	if pos := dgs.JoinWaitingList("140000000000000001"); pos != 1 {
+		t.Errorf("joining twice shouldn't lose your place, got %d", pos)
+	}
+	dgs.JoinWaitingList("140000000000000003")
+	if !dgs.UserData["140000000000000002"].Spectator {
+		t.Error("expected waiting users to be spectating")
+	}
+
+	if !dgs.LeaveWaitingList("140000000000000002") || dgs.UserData["140000000000000002"].Spectator {
+		t.Error("expected leaving the queue to stop spectating")
+	}
+	if dgs.LeaveWaitingList("140000000000000002") {
+		t.Error("users who aren't waiting can't leave the queue")
+	}
+
+	if promoted := dgs.PromoteWaiting(5); !reflect.DeepEqual(promoted, []string{"140000000000000001", "140000000000000003"}) {
+		t.Errorf("expected the users to be promoted in queue order, got %v", promoted)
+	}
+	if len(dgs.WaitingList) != 0 || dgs.UserData["140000000000000003"].Spectator {
+		t.Errorf("expected promoted users to be playing, got %v", dgs.WaitingList)
+	}
+	if promoted := dgs.PromoteWaiting(-1); promoted != nil {
+		t.Errorf("a full lobby has no openings, got %v", promoted)
+	}
+}
+
+func TestPruneWaitingList(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+	})
+	dgs.JoinWaitingList("140000000000000001")
+	dgs.JoinWaitingList("140000000000000002")
+	if dgs.PruneWaitingList() {
+		t.Error("nobody got linked, so nobody should be pruned")
+	}
+
+	// Alice got in the lobby without waiting for her turn
+	if userID := dgs.AttemptPairingByMatchingNames(fuzzyTestPlayer(dgs, "Alice", 0)); userID != "140000000000000001" {
+		t.Fatalf("expected Alice to be linked, got \"%s\"", userID)
+	}
+	if !dgs.PruneWaitingList() || !reflect.DeepEqual(dgs.WaitingList, []string{"140000000000000002"}) {
+		t.Errorf("expected users who got linked to leave the queue, got %v", dgs.WaitingList)
+	}
+}
+
+func TestSpectatorsAreNotTracked(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "Alice"})
+	dgs.VoiceChannel = "754465589958803549"
+	dgs.AttemptPairingByMatchingNames(fuzzyTestPlayer(dgs, "Alice", 0))
+	if _, found := dgs.trackedPlayer(dgs.UserData["140000000000000001"]); !found {
+		t.Fatal("expected a linked player to be tracked")
+	}
+
+	// sitting out the next match to let someone else play
+	dgs.JoinWaitingList("140000000000000001")
+	if _, found := dgs.trackedPlayer(dgs.UserData["140000000000000001"]); found {
+		t.Error("expected a spectator not to be tracked, even if they're still linked")
+	}
+
+	voiceStates := []*discordgo.VoiceState{
+		{UserID: "140000000000000001", ChannelID: "754465589958803549"},
+		{UserID: "140000000000000002", ChannelID: "754465589958803549"},
+		{UserID: "140000000000000003", ChannelID: "754465589958803550"},
+	}
+	if size := dgs.rosterSize(settings.MakeGuildSettings(), voiceStates); size != 1 {
+		t.Errorf("expected spectators and other channels not to count towards the roster, got %d", size)
+	}
+}
+
+func TestRosterSizeWithGhostChannel(t *testing.T) {
+	const gameChannel, ghostChannel = "754465589958803549", "754465589958803551"
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "Alice", "140000000000000002": "Bob"})
+	dgs.VoiceChannel = gameChannel
+	sett := settings.MakeGuildSettings()
+	sett.SetGhostChannelID(ghostChannel)
+
+	voiceStates := []*discordgo.VoiceState{
+		{UserID: "140000000000000001", ChannelID: gameChannel},
+		{UserID: "140000000000000002", ChannelID: gameChannel},
+	}
+	for i := 3; len(voiceStates) < MaxLobbyPlayers; i++ {
+		voiceStates = append(voiceStates, &discordgo.VoiceState{UserID: fmt.Sprintf("1400000000000000%02d", i), ChannelID: gameChannel})
+	}
+	dgs.JoinWaitingList("140000000000000099")
+
+	// Bob died, and was moved to the ghost channel for tasks; he's still playing, so the lobby is still full
+	voiceStates[1].ChannelID = ghostChannel
+	if size := dgs.rosterSize(sett, voiceStates); size != MaxLobbyPlayers {
+		t.Errorf("expected dead players in the ghost channel to count towards the roster, got %d", size)
+	}
+	if promoted := dgs.promoteToOpenings(sett, &discordgo.Guild{VoiceStates: voiceStates}); len(promoted) != 0 {
+		t.Errorf("expected nobody to be promoted into a full lobby when a player dies, got %v", promoted)
+	}
+
+	// Bob actually left
+	voiceStates = append(voiceStates[:1], voiceStates[2:]...)
+	if promoted := dgs.promoteToOpenings(sett, &discordgo.Guild{VoiceStates: voiceStates}); len(promoted) != 1 {
+		t.Errorf("expected the next in line to be promoted when a player leaves, got %v", promoted)
+	}
+}
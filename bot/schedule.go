@@ -0,0 +1,282 @@
+package bot
+
+import (
+	"fmt"
+	"github.com/automuteus/automuteus/v8/bot/command"
+	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/schedule"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ScheduleCheckInterval = 30 * time.Second
+
+// ScheduleReminderLead is how long before a scheduled game its reminder (with the RSVP button) is posted
+const ScheduleReminderLead = 30 * time.Minute
+
+// ScheduleStartGrace is how late a scheduled game can still be started, if the bot was down when it was due. Later
+// than this, the occurrence is skipped
+const ScheduleStartGrace = 15 * time.Minute
+
+// scheduleWorker posts reminders for, and starts, the scheduled games in this shard's guilds, and posts the summaries
+// of their seasons once they end
+func (bot *Bot) scheduleWorker() {
+	ticker := time.NewTicker(ScheduleCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		bot.remindScheduledSessions()
+		bot.startScheduledSessions()
+		bot.postSeasonSummaries()
+	}
+}
+
+func (bot *Bot) ownsGuild(guildID string) bool {
+	_, err := bot.PrimarySession.State.Guild(guildID)
+	return err == nil
+}
+
+func (bot *Bot) remindScheduledSessions() {
+	now := time.Now()
+	sessions, err := bot.PostgresInterface.GetSessionsToRemind(now.Add(ScheduleReminderLead))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, session := range sessions {
+		guildID := strconv.FormatUint(session.GuildID, 10)
+		// already due; it's about to be started, there's no point reminding anyone
+		if !bot.ownsGuild(guildID) || !session.StartTime.After(now) {
+			continue
+		}
+		claimed, err := bot.PostgresInterface.ClaimSessionReminder(session)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		sett := bot.StorageInterface.GetGuildSettings(guildID)
+		msg, err := bot.PrimarySession.ChannelMessageSendComplex(strconv.FormatUint(session.TextChannelID, 10),
+			scheduleReminderMessage(session, nil, sett))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		err = bot.PostgresInterface.SetSessionReminder(session.SessionID, msg.ID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (bot *Bot) startScheduledSessions() {
+	now := time.Now()
+	sessions, err := bot.PostgresInterface.GetSessionsToStart(now)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, session := range sessions {
+		if !bot.ownsGuild(strconv.FormatUint(session.GuildID, 10)) {
+			continue
+		}
+		rsvps, err := bot.PostgresInterface.GetSessionRSVPs(session.SessionID)
+		if err != nil {
+			log.Println(err)
+		}
+		loc, err := schedule.LoadLocation(session.Timezone)
+		if err != nil {
+			log.Println(err)
+			loc = time.Local
+		}
+		var next *time.Time
+		if n, ok := schedule.Next(session.StartTime, schedule.ParseRecurrence(session.Recurrence), loc, now); ok {
+			next = &n
+		}
+		claimed, err := bot.PostgresInterface.AdvanceScheduledSession(session, next)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		bot.closeScheduleReminder(session)
+
+		if now.Sub(session.StartTime) > ScheduleStartGrace {
+			log.Printf("Skipping scheduled game %d in guild %d, it was due at %s\n", session.SessionID, session.GuildID, session.StartTime)
+			continue
+		}
+		bot.startScheduledGame(session, rsvps)
+	}
+}
+
+// closeScheduleReminder takes the RSVP button off the reminder once the game starts
+func (bot *Bot) closeScheduleReminder(session *storage.PostgresScheduledSession) {
+	if session.ReminderMessageID == nil || *session.ReminderMessageID == 0 {
+		return
+	}
+	edit := discordgo.NewMessageEdit(strconv.FormatUint(session.TextChannelID, 10), strconv.FormatUint(*session.ReminderMessageID, 10))
+	edit.Components = []discordgo.MessageComponent{}
+	_, err := bot.PrimarySession.ChannelMessageEditComplex(edit)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// startScheduledGame does what /start does, as the user who scheduled the game. A game that's already running in the
+// channel is left alone
+func (bot *Bot) startScheduledGame(session *storage.PostgresScheduledSession, rsvps []uint64) {
+	guildID := strconv.FormatUint(session.GuildID, 10)
+	textChannelID := strconv.FormatUint(session.TextChannelID, 10)
+	voiceChannelID := strconv.FormatUint(session.VoiceChannelID, 10)
+	hostID := strconv.FormatUint(session.CreatedBy, 10)
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+
+	g, err := bot.PrimarySession.State.Guild(guildID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	gsr := GameStateRequest{
+		GuildID:     guildID,
+		TextChannel: textChannelID,
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when starting scheduled game %d for guild %s\n", session.SessionID, guildID)
+		return
+	}
+	if dgs.GameStateMsg.Exists() {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		log.Printf("Not starting scheduled game %d, a game is already running in channel %s\n", session.SessionID, textChannelID)
+		return
+	}
+	status, _ := bot.newGame(dgs)
+	if status != command.NewSuccess {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		log.Printf("Couldn't start scheduled game %d for guild %s\n", session.SessionID, guildID)
+		return
+	}
+	// release the lock
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
+	bot.startGameWorker(guildID, dgs.ConnectCode)
+
+	bot.handleGameStartMessage(guildID, textChannelID, voiceChannelID, hostID, sett, g, dgs.ConnectCode)
+
+	// the capture connection info is private, so it goes to the host, like /start's ephemeral response
+	hyperlink, apiHyperlink, minimalURL := formCaptureURL(bot.url, dgs.ConnectCode)
+	resp := command.NewResponse(command.NewSuccess, command.NewInfo{
+		Hyperlink:    hyperlink,
+		ApiHyperlink: apiHyperlink,
+		MinimalURL:   minimalURL,
+		ConnectCode:  dgs.ConnectCode,
+	}, sett)
+	dm, err := bot.PrimarySession.UserChannelCreate(hostID)
+	if err == nil {
+		_, err = bot.PrimarySession.ChannelMessageSendEmbeds(dm.ID, resp.Data.Embeds)
+	}
+	if err != nil {
+		log.Println(err)
+	}
+
+	_, err = bot.PrimarySession.ChannelMessageSendComplex(textChannelID, &discordgo.MessageSend{
+		Content: sett.LocalizeMessage(&i18n.Message{
+			ID:    "schedule.start",
+			Other: "{{.Mentions}}\n🎮 **{{.Title}}** is starting in {{.Voice}}! {{.Host}}, I sent you the capture connection info by DM.",
+		}, map[string]interface{}{
+			"Mentions": scheduleMentions(session, rsvps),
+			"Title":    session.Title,
+			"Voice":    discord.MentionByChannelID(voiceChannelID),
+			"Host":     discord.MentionByUserID(hostID),
+		}),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// scheduleMentions pings the host role and everyone who RSVP'd
+func scheduleMentions(session *storage.PostgresScheduledSession, rsvps []uint64) string {
+	var mentions []string
+	if session.HostRoleID != nil {
+		mentions = append(mentions, discord.MentionByRoleID(strconv.FormatUint(*session.HostRoleID, 10)))
+	}
+	for _, userID := range rsvps {
+		mentions = append(mentions, discord.MentionByUserID(strconv.FormatUint(userID, 10)))
+	}
+	return strings.Join(mentions, " ")
+}
+
+func scheduleReminderEmbed(session *storage.PostgresScheduledSession, rsvps []uint64, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	going := sett.LocalizeMessage(&i18n.Message{
+		ID:    "schedule.reminder.nobody",
+		Other: "Nobody yet",
+	})
+	if len(rsvps) > 0 {
+		var mentions []string
+		for _, userID := range rsvps {
+			mentions = append(mentions, discord.MentionByUserID(strconv.FormatUint(userID, 10)))
+		}
+		going = strings.Join(mentions, " ")
+	}
+	return &discordgo.MessageEmbed{
+		Title: "📅 " + session.Title,
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "schedule.reminder.description",
+			Other: "Starts {{.Relative}} ({{.Start}}) in {{.Voice}}",
+		}, map[string]interface{}{
+			"Relative": command.DiscordTimestamp(session.StartTime.Unix(), "R"),
+			"Start":    command.DiscordTimestamp(session.StartTime.Unix(), "F"),
+			"Voice":    discord.MentionByChannelID(strconv.FormatUint(session.VoiceChannelID, 10)),
+		}),
+		Color: 15844367, // GOLD
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "schedule.reminder.going",
+					Other: "Going ({{.Count}})",
+				}, map[string]interface{}{
+					"Count": len(rsvps),
+				}),
+				Value: going,
+			},
+		},
+	}
+}
+
+func scheduleReminderComponents(sessionID int64, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s:%d", scheduleRSVPButtonPrefix, sessionID),
+					Style:    discordgo.PrimaryButton,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "schedule.reminder.rsvp",
+						Other: "I'm in / I'm out",
+					}),
+				},
+			},
+		},
+	}
+}
+
+func scheduleReminderMessage(session *storage.PostgresScheduledSession, rsvps []uint64, sett *settings.GuildSettings) *discordgo.MessageSend {
+	var content string
+	if session.HostRoleID != nil {
+		content = discord.MentionByRoleID(strconv.FormatUint(*session.HostRoleID, 10))
+	}
+	return &discordgo.MessageSend{
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{scheduleReminderEmbed(session, rsvps, sett)},
+		Components: scheduleReminderComponents(session.SessionID, sett),
+	}
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/bot/command"
+	"github.com/automuteus/automuteus/v8/pkg/premium"
+	"github.com/automuteus/automuteus/v8/pkg/schedule"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"log"
+	"strconv"
+	"time"
+)
+
+var errUnknownSeason = errors.New("no season by that name")
+
+// statsRange is the games stats were asked for (a season, or a range of days in the bot's timezone), and how to
+// describe them in the embed. The label is empty for all time
+func (bot *Bot) statsRange(guildID string, params command.StatsRangeParams) (storage.StatsRange, string, error) {
+	if params.Season != "" {
+		season, err := bot.PostgresInterface.GetSeason(guildID, params.Season)
+		if err != nil {
+			return storage.StatsRange{}, "", err
+		}
+		if season == nil {
+			return storage.StatsRange{}, "", errUnknownSeason
+		}
+		return season.Range(), fmt.Sprintf("**%s** (%s)", season.Name, command.SeasonDates(season)), nil
+	}
+	start, end, err := schedule.ParseDateRange(params.From, params.To, time.Local)
+	if err != nil {
+		return storage.StatsRange{}, "", err
+	}
+	statsRange := storage.StatsRange{Start: start, End: end}
+	if statsRange.IsAllTime() {
+		return statsRange, "", nil
+	}
+	from, to := "…", "…"
+	if start != nil {
+		from = command.DiscordTimestamp(start.Unix(), "D")
+	}
+	if end != nil {
+		to = command.DiscordTimestamp(end.Add(-time.Second).Unix(), "D")
+	}
+	return statsRange, from + " - " + to, nil
+}
+
+// postSeasonSummaries posts the guild stats for the seasons in this shard's guilds that just ended, to the guild's
+// match summary channel
+func (bot *Bot) postSeasonSummaries() {
+	seasons, err := bot.PostgresInterface.GetEndedSeasons(time.Now())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, season := range seasons {
+		guildID := strconv.FormatUint(season.GuildID, 10)
+		if !bot.ownsGuild(guildID) {
+			continue
+		}
+		// claimed even without a channel to post to, so the season isn't looked at again
+		claimed, err := bot.PostgresInterface.ClaimSeasonSummary(season.SeasonID)
+		if err != nil || !claimed {
+			if err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		sett := bot.StorageInterface.GetGuildSettings(guildID)
+		channelID := sett.GetMatchSummaryChannelID()
+		if channelID == "" {
+			continue
+		}
+		tier, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, guildID, "")
+		if err != nil {
+			log.Println(err)
+		}
+		embed := bot.GuildStatsEmbed(guildID, season.Range(),
+			fmt.Sprintf("**%s** (%s)", season.Name, command.SeasonDates(season)), sett, !premium.IsExpired(tier, days))
+		embed.Title = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.seasonSummary.Title",
+			Other: "🏆 Season {{.Name}} is over!",
+		}, map[string]interface{}{
+			"Name": season.Name,
+		})
+		_, err = bot.PrimarySession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+			Embeds:          []*discordgo.MessageEmbed{embed},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		})
+		if err != nil {
+			log.Println(err)
+			// try again next time instead of losing the summary
+			err = bot.PostgresInterface.ReleaseSeasonSummary(season.SeasonID)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"math"
 	"strconv"
+	"strings"
 )
 
+// FnDelays views or sets the delay for a phase change. There's no phase for the start of the game: the first round of
+// tasks uses the `Lobby` to `Tasks` delay (the role reveal), even when the capture only connected while the game was
+// already starting (see game.MakeTransition)
 func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 	if sett == nil {
 		return nil, false
@@ -17,7 +22,8 @@ func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 		return sett.LocalizeMessage(&i18n.Message{
 			ID: "settings.SettingDelays.missingPhases",
 			Other: "The list of game phases are `Lobby`, `Tasks` and `Discussion`.\n" +
-				"You need to type both phases the game is transitioning from and to to change the delay.",
+				"You need to type both phases the game is transitioning from and to to change the delay.\n" +
+				"`Lobby` to `Tasks` is the delay before the first round of tasks, while roles are revealed.",
 		}), false // find a better wording for this at some point
 	}
 	// now to find the actual game state from the string they passed
@@ -41,9 +47,44 @@ func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 			}), false
 	}
 
-	oldDelay := sett.GetDelay(gamePhase1, gamePhase2)
-	if len(args) == 2 {
+	// the rest of the arguments are optional, in any order: the delay, how the meeting ended, and the map
+	var outcome game.MeetingOutcome
+	var mapName, delayArg string
+	for _, arg := range args[2:] {
+		if o := game.GetMeetingOutcome(arg); o != "" {
+			outcome = o
+		} else if playMap, ok := game.GetPlayMapFromString(arg); ok {
+			mapName = game.MapNames[playMap]
+		} else {
+			delayArg = arg
+		}
+	}
+	if outcome != "" && (gamePhase1 != game.DISCUSS || gamePhase2 != game.TASKS) {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingDelays.outcomeNotMeeting",
+			Other: "`{{.Outcome}}` is how a meeting ended, so it only applies going from `Discussion` to `Tasks`",
+		},
+			map[string]interface{}{
+				"Outcome": outcome,
+			}), false
+	}
+	qualifier := delayQualifier(outcome, mapName)
+
+	oldDelay := currentDelay(&sett.Delays, gamePhase1, gamePhase2, outcome, mapName)
+	if delayArg == "" {
 		// no number was passed, User was querying the delay
+		if qualifier != "" {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingDelays.delayBetweenPhasesQualified",
+				Other: "Currently, the delay when passing from `{{.PhaseA}}` to `{{.PhaseB}}` ({{.Qualifier}}) is {{.OldDelay}}.",
+			},
+				map[string]interface{}{
+					"PhaseA":    args[0],
+					"PhaseB":    args[1],
+					"Qualifier": qualifier,
+					"OldDelay":  oldDelay,
+				}), false
+		}
 		return sett.LocalizeMessage(&i18n.Message{
 			ID:    "settings.SettingDelays.delayBetweenPhases",
 			Other: "Currently, the delay when passing from `{{.PhaseA}}` to `{{.PhaseB}}` is {{.OldDelay}}.",
@@ -55,18 +96,31 @@ func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 			}), false
 	}
 
-	newDelay, err := strconv.Atoi(args[2])
-	if err != nil || newDelay < 0 {
+	newDelay, err := strconv.ParseFloat(delayArg, 64)
+	if err != nil || math.IsNaN(newDelay) || newDelay < MinDelay || newDelay > MaxDelay {
 		return sett.LocalizeMessage(&i18n.Message{
 			ID:    "settings.SettingDelays.wrongNumber",
 			Other: "`{{.Number}}` is not a valid number! Please try again",
 		},
 			map[string]interface{}{
-				"Number": args[2],
+				"Number": delayArg,
 			}), false
 	}
 
-	sett.SetDelay(gamePhase1, gamePhase2, newDelay)
+	sett.Delays.Profile(mapName).SetDelay(gamePhase1, gamePhase2, outcome, newDelay)
+	if qualifier != "" {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingDelays.setDelayBetweenPhasesQualified",
+			Other: "The delay when passing from `{{.PhaseA}}` to `{{.PhaseB}}` ({{.Qualifier}}) changed from {{.OldDelay}} to {{.NewDelay}}.",
+		},
+			map[string]interface{}{
+				"PhaseA":    args[0],
+				"PhaseB":    args[1],
+				"Qualifier": qualifier,
+				"OldDelay":  oldDelay,
+				"NewDelay":  newDelay,
+			}), true
+	}
 	return sett.LocalizeMessage(&i18n.Message{
 		ID:    "settings.SettingDelays.setDelayBetweenPhases",
 		Other: "The delay when passing from `{{.PhaseA}}` to `{{.PhaseB}}` changed from {{.OldDelay}} to {{.NewDelay}}.",
@@ -78,3 +132,33 @@ func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 			"NewDelay": newDelay,
 		}), true
 }
+
+// currentDelay is the delay in effect for what's being queried, in the same order as GameDelays.Resolve: the map's
+// profile first, then the guild's delays. Within each, how the meeting ended comes before the phases
+func currentDelay(delays *game.GameDelays, origin, dest game.Phase, outcome game.MeetingOutcome, mapName string) float64 {
+	profiles := []*game.GameDelays{delays}
+	if mapName != "" {
+		profiles = []*game.GameDelays{delays.Maps[mapName], delays}
+	}
+	for _, profile := range profiles {
+		if v, ok := profile.Get(origin, dest, outcome); ok {
+			return v
+		}
+		if v, ok := profile.Get(origin, dest, ""); outcome != "" && ok {
+			return v
+		}
+	}
+	return 0
+}
+
+// delayQualifier describes which delay is meant besides the phases, like "ejection, Airship"
+func delayQualifier(outcome game.MeetingOutcome, mapName string) string {
+	var parts []string
+	if outcome != "" {
+		parts = append(parts, string(outcome))
+	}
+	if mapName != "" {
+		parts = append(parts, mapName)
+	}
+	return strings.Join(parts, ", ")
+}
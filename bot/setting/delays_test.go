@@ -33,4 +33,61 @@ func TestFnDelays(t *testing.T) {
 	if sett.GetDelay(game.LOBBY, game.TASKS) != 8 {
 		t.Error("Delay was not set properly")
 	}
+
+	_, valid = FnDelays(sett, []string{"lobby", "tasks", "6.5"})
+	if !valid || sett.GetDelay(game.LOBBY, game.TASKS) != 6.5 {
+		t.Error("Sub-second delays should be allowed")
+	}
+
+	_, valid = FnDelays(sett, []string{"lobby", "tasks", "11"})
+	if valid {
+		t.Error("Delays above the maximum should never result in valid settings change")
+	}
+
+	_, valid = FnDelays(sett, []string{"lobby", "tasks", "NaN"})
+	if valid {
+		t.Error("Sending invalid args should never result in valid settings change")
+	}
+}
+
+func TestFnDelaysQualified(t *testing.T) {
+	sett, err := testSettingsFn(FnDelays)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, valid := FnDelays(sett, []string{"lobby", "tasks", "ejection", "2"})
+	if valid {
+		t.Error("Meeting outcomes should only apply from discussion to tasks")
+	}
+
+	_, valid = FnDelays(sett, []string{"discussion", "tasks", "ejection", "9.5"})
+	if !valid {
+		t.Error("Sending valid args should result in valid settings change")
+	}
+	if v, ok := sett.Delays.Get(game.DISCUSS, game.TASKS, game.Ejection); !ok || v != 9.5 {
+		t.Error("Ejection delay was not set properly")
+	}
+	if _, ok := sett.Delays.Get(game.DISCUSS, game.TASKS, game.Skipped); ok {
+		t.Error("Setting the ejection delay shouldn't set the delay for skipped meetings")
+	}
+
+	_, valid = FnDelays(sett, []string{"lobby", "tasks", "9", "airship"})
+	if !valid {
+		t.Error("Sending valid args should result in valid settings change")
+	}
+	if v, ok := sett.Delays.Maps["Airship"].Get(game.LOBBY, game.TASKS, ""); !ok || v != 9 {
+		t.Error("Airship delay was not set properly")
+	}
+	if sett.GetDelay(game.LOBBY, game.TASKS) == 9 {
+		t.Error("Setting a map's delay shouldn't change the guild's delay")
+	}
+
+	_, valid = FnDelays(sett, []string{"discussion", "tasks", "skipped", "Polus"})
+	if valid {
+		t.Error("Querying a delay should never result in valid settings change")
+	}
+	if currentDelay(&sett.Delays, game.DISCUSS, game.TASKS, game.Skipped, "Polus") != sett.GetDelay(game.DISCUSS, game.TASKS) {
+		t.Error("Without a map or skipped delay, the delay between the phases should be in effect")
+	}
 }
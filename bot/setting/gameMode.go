@@ -0,0 +1,38 @@
+package setting
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"strings"
+)
+
+func FnGameMode(sett *settings.GuildSettings, args []string) (interface{}, bool) {
+	s := GetSettingByName(GameMode)
+	if sett == nil {
+		return nil, false
+	}
+	if len(args) == 0 {
+		return ConstructEmbedForSetting(sett.GetGameMode().Name, s, sett), false
+	}
+
+	val := strings.ToLower(args[0])
+	if _, ok := game.GameModes[val]; !ok {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingGameMode.Unrecognized",
+			Other: "{{.Arg}} is not an expected value. See `/settings game-mode` for usage",
+		},
+			map[string]interface{}{
+				"Arg": val,
+			}), false
+	}
+
+	sett.SetGameMode(val)
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "settings.SettingGameMode.Success",
+		Other: "From now on, I will attribute wins using the `{{.Arg}}` roles",
+	},
+		map[string]interface{}{
+			"Arg": val,
+		}), true
+}
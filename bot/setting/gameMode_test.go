@@ -0,0 +1,29 @@
+package setting
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"testing"
+)
+
+func TestFnGameMode(t *testing.T) {
+	sett, err := testSettingsFn(FnGameMode)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, valid := FnGameMode(sett, []string{"invalid"})
+	if valid {
+		t.Error("Sending invalid args should never result in valid settings change")
+	}
+	if sett.GetGameMode().Name != game.ClassicMode {
+		t.Error("The game mode should default to classic")
+	}
+
+	_, valid = FnGameMode(sett, []string{"Town-Of-Us"})
+	if !valid {
+		t.Error("Sending a valid game mode should result in settings change")
+	}
+	if sett.GetGameMode().Name != game.TownOfUsMode {
+		t.Error("GameMode should be set to town-of-us after successful change")
+	}
+}
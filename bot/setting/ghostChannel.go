@@ -0,0 +1,55 @@
+package setting
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/discord"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func FnGhostChannel(sett *settings.GuildSettings, args []string) (interface{}, bool) {
+	s := GetSettingByName(GhostChannel)
+	if sett == nil {
+		return nil, false
+	}
+	if len(args) == 0 {
+		current := ""
+		if sett.GetGhostChannelID() != "" {
+			current = discord.MentionByChannelID(sett.GetGhostChannelID())
+		}
+		return ConstructEmbedForSetting(current, s, sett), false
+	}
+
+	if args[0] == Clear || args[0] == "c" {
+		if sett.GetGhostChannelID() == "" {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingGhostChannel.alreadyCleared",
+				Other: "Dead players already stay in the game's voice channel!",
+			}), false
+		}
+		sett.SetGhostChannelID("")
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingGhostChannel.cleared",
+			Other: "Dead players will no longer be moved to a ghost voice channel",
+		}), true
+	}
+
+	channelID, err := discord.ExtractChannelIDFromText(args[0])
+	if err != nil {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingGhostChannel.invalidChannelID",
+			Other: "{{.channelID}} is not a valid voice channel ID or mention!",
+		},
+			map[string]interface{}{
+				"channelID": args[0],
+			}), false
+	}
+
+	sett.SetGhostChannelID(channelID)
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "settings.SettingGhostChannel.withChannelID",
+		Other: "Dead players will now be moved to {{.channelID}} during tasks, and back for discussions!\n**Note, I need the Move Members permission in both voice channels**",
+	},
+		map[string]interface{}{
+			"channelID": discord.MentionByChannelID(channelID),
+		}), true
+}
@@ -2,6 +2,7 @@ package setting
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
@@ -50,6 +51,8 @@ const (
 	LeaderboardMin      = "leaderboard-min"
 	MuteSpectators      = "mute-spectators"
 	DisplayRoomCode     = "display-room-code"
+	GhostChannel        = "ghost-channel"
+	GameMode            = "game-mode"
 	Show                = "show"
 	List                = "list"
 	Reset               = "reset"
@@ -72,6 +75,8 @@ func ToString(option *discordgo.ApplicationCommandInteractionDataOption) string
 		return option.StringValue()
 	case discordgo.ApplicationCommandOptionInteger:
 		return fmt.Sprintf("%d", option.IntValue())
+	case discordgo.ApplicationCommandOptionNumber:
+		return strconv.FormatFloat(option.FloatValue(), 'f', -1, 64)
 	case discordgo.ApplicationCommandOptionUser:
 		return option.UserValue(nil).Mention()
 	case discordgo.ApplicationCommandOptionRole:
@@ -107,6 +112,74 @@ var phaseChoices = []*discordgo.ApplicationCommandOptionChoice{
 	},
 }
 
+var gameModeChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{
+		Name:  game.ClassicMode,
+		Value: game.ClassicMode,
+	},
+	{
+		Name:  game.TownOfUsMode,
+		Value: game.TownOfUsMode,
+	},
+	{
+		Name:  game.HideAndSeekMode,
+		Value: game.HideAndSeekMode,
+	},
+}
+
+var teamChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{
+		Name:  string(game.CrewmateTeam),
+		Value: string(game.CrewmateTeam),
+	},
+	{
+		Name:  string(game.ImpostorTeam),
+		Value: string(game.ImpostorTeam),
+	},
+	{
+		Name:  string(game.NeutralTeam),
+		Value: string(game.NeutralTeam),
+	},
+}
+
+var meetingOutcomeChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{
+		Name:  string(game.Ejection),
+		Value: string(game.Ejection),
+	},
+	{
+		Name:  string(game.Skipped),
+		Value: string(game.Skipped),
+	},
+}
+
+var mapChoices = []*discordgo.ApplicationCommandOptionChoice{
+	{
+		Name:  game.MapNames[game.SKELD],
+		Value: game.MapNames[game.SKELD],
+	},
+	{
+		Name:  game.MapNames[game.MIRA],
+		Value: game.MapNames[game.MIRA],
+	},
+	{
+		Name:  game.MapNames[game.POLUS],
+		Value: game.MapNames[game.POLUS],
+	},
+	{
+		Name:  game.MapNames[game.DLEKS],
+		Value: game.MapNames[game.DLEKS],
+	},
+	{
+		Name:  game.MapNames[game.AIRSHIP],
+		Value: game.MapNames[game.AIRSHIP],
+	},
+	{
+		Name:  game.MapNames[game.FUNGLE],
+		Value: game.MapNames[game.FUNGLE],
+	},
+}
+
 var AllSettings = []Setting{
 	{
 		Name:      List,
@@ -166,6 +239,14 @@ var AllSettings = []Setting{
 						Name:  "dead",
 						Value: "dead",
 					},
+					{
+						Name:  game.ExiledState,
+						Value: game.ExiledState,
+					},
+					{
+						Name:  game.KilledState,
+						Value: game.KilledState,
+					},
 				},
 				Required: true,
 			},
@@ -174,6 +255,39 @@ var AllSettings = []Setting{
 				Name:        "value",
 				Description: "value",
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        User,
+				Description: "Override the rule for a single user",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionRole,
+				Name:        Role,
+				Description: "Override the rule for everyone with a role",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        Clear,
+				Description: "Remove the rule, or the user/role override",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  Clear,
+						Value: Clear,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "team",
+				Description: "Only for players whose role plays for this team",
+				Choices:     teamChoices,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "Only in games of this mode",
+				Choices:     gameModeChoices,
+			},
 		},
 		Premium: false,
 	},
@@ -280,12 +394,24 @@ var AllSettings = []Setting{
 				Required:    true,
 			},
 			{
-				Type:        discordgo.ApplicationCommandOptionInteger,
+				Type:        discordgo.ApplicationCommandOptionNumber,
 				Name:        "delay",
 				Description: "delay",
 				MinValue:    &MinDelay,
 				MaxValue:    MaxDelay,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "meeting",
+				Description: "meeting",
+				Choices:     meetingOutcomeChoices,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "map",
+				Description: "map",
+				Choices:     mapChoices,
+			},
 		},
 		Premium: false,
 	},
@@ -303,6 +429,30 @@ var AllSettings = []Setting{
 		},
 		Premium: true,
 	},
+	{
+		Name:      GhostChannel,
+		ShortDesc: "Voice channel for dead players",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         "channel",
+				Description:  "channel",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        Clear,
+				Description: "Stop moving dead players",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{
+						Name:  Clear,
+						Value: Clear,
+					},
+				},
+			},
+		},
+		Premium: false,
+	},
 	{
 		Name:      MatchSummaryChannel,
 		ShortDesc: "Channel for Match Summaries",
@@ -380,6 +530,19 @@ var AllSettings = []Setting{
 		},
 		Premium: true,
 	},
+	{
+		Name:      GameMode,
+		ShortDesc: "Roles and win conditions for modded games",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "mode",
+				Description: "mode",
+				Choices:     gameModeChoices,
+			},
+		},
+		Premium: false,
+	},
 	{
 		Name:      DisplayRoomCode,
 		ShortDesc: "Visibility for the ROOM CODE",
@@ -1,9 +1,11 @@
 package setting
 
 import (
+	"github.com/automuteus/automuteus/v8/pkg/discord"
 	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"strings"
 )
 
 func FnVoiceRules(sett *settings.GuildSettings, args []string) (interface{}, bool) {
@@ -31,19 +33,55 @@ func FnVoiceRules(sett *settings.GuildSettings, args []string) (interface{}, boo
 			}), false
 	}
 
-	if args[2] != "alive" && args[2] != "dead" {
+	if !isVoiceState(args[2]) {
 		return sett.LocalizeMessage(&i18n.Message{
 			ID:    "settings.SettingVoiceRules.neitherAliveDead",
-			Other: "`{{.Arg}}` is neither `alive` or `dead`!",
+			Other: "`{{.Arg}}` is not `alive`, `dead`, `exiled` or `killed`!",
 		},
 			map[string]interface{}{
 				"Arg": args[2],
 			}), false
 	}
 
+	// the optional arguments (value, user, role, clear, team, mode) can arrive in any order, so tell them apart by
+	// their contents
+	var value, hasValue, clear bool
+	target, mode := "", ""
+	var team game.Team
+	for _, arg := range args[3:] {
+		switch {
+		case arg == "true" || arg == "false":
+			value = arg == "true"
+			hasValue = true
+		case arg == Clear:
+			clear = true
+		case strings.HasPrefix(arg, "<@"):
+			target = arg
+		case isTeam(arg):
+			team = game.Team(arg)
+		default:
+			if _, ok := game.GameModes[arg]; ok {
+				mode = arg
+			}
+		}
+	}
+	qualified := team != "" || mode != "" || (args[2] != game.AliveState && args[2] != game.DeadState)
+	if target != "" {
+		if qualified {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.override.qualified",
+				Other: "Overrides for a user or role can only be for `alive` or `dead` players, in every team and game mode",
+			}), false
+		}
+		return fnVoiceOverride(sett, args, gamePhase, target, value, hasValue, clear)
+	}
+	if qualified {
+		return fnQualifiedVoiceRule(sett, args, gamePhase, game.VoiceStateKey(team, args[2]), mode, value, hasValue, clear)
+	}
+
 	oldValue := sett.GetVoiceRule(args[0] == "muted", gamePhase, args[2])
 
-	if len(args) == 3 {
+	if !hasValue {
 		// User was only querying
 		if oldValue {
 			return sett.LocalizeMessage(&i18n.Message{
@@ -67,7 +105,7 @@ func FnVoiceRules(sett *settings.GuildSettings, args []string) (interface{}, boo
 				}), false
 		}
 	}
-	newValue := args[3] == "true"
+	newValue := value
 
 	if newValue == oldValue {
 		if newValue {
@@ -121,3 +159,173 @@ func FnVoiceRules(sett *settings.GuildSettings, args []string) (interface{}, boo
 			}), true
 	}
 }
+
+// fnVoiceOverride handles voice-rules for a single user or role, which take precedence over the guild rules
+func fnVoiceOverride(sett *settings.GuildSettings, args []string, gamePhase game.Phase, target string, value, hasValue, clear bool) (interface{}, bool) {
+	isMute := args[0] == "muted"
+	isRole := strings.HasPrefix(target, "<@&")
+	var id string
+	var err error
+	if isRole {
+		id, err = discord.ExtractRoleIDFromText(target)
+	} else {
+		id, err = discord.ExtractUserIDFromText(target)
+	}
+	if err != nil || id == "" {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.override.notFound",
+			Other: "Sorry, I don't know who `{{.Target}}` is. You can pass in a user or role @mention",
+		},
+			map[string]interface{}{
+				"Target": target,
+			}), false
+	}
+	templateData := map[string]interface{}{
+		"PhaseName":          args[1],
+		"PlayerGameState":    args[2],
+		"PlayerDiscordState": args[0],
+		"Target":             target,
+	}
+
+	oldValue, overridden := sett.GetVoiceOverride(isRole, id, isMute, gamePhase, args[2])
+
+	if clear {
+		if !overridden {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.override.noneToClear",
+				Other: "{{.Target}} has no override when {{.PlayerGameState}} in `{{.PhaseName}}` phase; the guild rule already applies.",
+			}, templateData), false
+		}
+		sett.ClearVoiceOverride(isRole, id, isMute, gamePhase, args[2])
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.override.cleared",
+			Other: "Removed the override for {{.Target}}; when {{.PlayerGameState}} in `{{.PhaseName}}` phase, the guild rule applies again.",
+		}, templateData), true
+	}
+
+	if !hasValue {
+		switch {
+		case !overridden:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.override.queryingNone",
+				Other: "{{.Target}} has no override when {{.PlayerGameState}} in `{{.PhaseName}}` phase; the guild rule applies.",
+			}, templateData), false
+		case oldValue:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.override.queryingValues",
+				Other: "When {{.PlayerGameState}} in `{{.PhaseName}}` phase, {{.Target}} is always {{.PlayerDiscordState}}.",
+			}, templateData), false
+		default:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.override.queryingUnValues",
+				Other: "When {{.PlayerGameState}} in `{{.PhaseName}}` phase, {{.Target}} is never {{.PlayerDiscordState}}.",
+			}, templateData), false
+		}
+	}
+
+	if overridden && oldValue == value {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.override.already",
+			Other: "That override is already set for {{.Target}}!",
+		}, templateData), false
+	}
+
+	sett.SetVoiceOverride(isRole, id, isMute, gamePhase, args[2], value)
+	if value {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.override.setValues",
+			Other: "From now on, when {{.PlayerGameState}} in `{{.PhaseName}}` phase, {{.Target}} will always be {{.PlayerDiscordState}}.",
+		}, templateData), true
+	}
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "settings.SettingVoiceRules.override.setUnValues",
+		Other: "From now on, when {{.PlayerGameState}} in `{{.PhaseName}}` phase, {{.Target}} will never be {{.PlayerDiscordState}}.",
+	}, templateData), true
+}
+
+func isVoiceState(arg string) bool {
+	for _, state := range game.VoiceStates {
+		if arg == state {
+			return true
+		}
+	}
+	return false
+}
+
+func isTeam(arg string) bool {
+	return arg == string(game.CrewmateTeam) || arg == string(game.ImpostorTeam) || arg == string(game.NeutralTeam)
+}
+
+// describeVoiceRule is who a qualified rule is for, like "impostor exiled players in hide-and-seek games"
+func describeVoiceRule(key, mode string) string {
+	rule := strings.ReplaceAll(key, "-", " ") + " players"
+	if mode != "" {
+		rule += " in " + mode + " games"
+	}
+	return rule
+}
+
+// fnQualifiedVoiceRule handles voice-rules that are more specific than alive or dead players: for a team, for exiled
+// or killed players, or in a game mode. Unlike the alive/dead rules, they can be cleared to fall back to those
+func fnQualifiedVoiceRule(sett *settings.GuildSettings, args []string, gamePhase game.Phase, key, mode string, value, hasValue, clear bool) (interface{}, bool) {
+	isMute := args[0] == "muted"
+	templateData := map[string]interface{}{
+		"PhaseName":          args[1],
+		"PlayerDiscordState": args[0],
+		"Rule":               describeVoiceRule(key, mode),
+	}
+
+	oldValue, set := sett.GetModeVoiceRule(isMute, gamePhase, key, mode)
+
+	if clear {
+		if !sett.ClearModeVoiceRule(isMute, gamePhase, key, mode) {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.qualified.noneToClear",
+				Other: "There's no rule for {{.Rule}} in `{{.PhaseName}}` phase to remove.",
+			}, templateData), false
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.qualified.cleared",
+			Other: "Removed the rule for {{.Rule}} in `{{.PhaseName}}` phase; the less specific rules apply again.",
+		}, templateData), true
+	}
+
+	if !hasValue {
+		switch {
+		case !set:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.qualified.queryingNone",
+				Other: "There's no rule for {{.Rule}} in `{{.PhaseName}}` phase; the less specific rules apply.",
+			}, templateData), false
+		case oldValue:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.qualified.queryingValues",
+				Other: "When in `{{.PhaseName}}` phase, {{.Rule}} are currently {{.PlayerDiscordState}}.",
+			}, templateData), false
+		default:
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.SettingVoiceRules.qualified.queryingUnValues",
+				Other: "When in `{{.PhaseName}}` phase, {{.Rule}} are currently NOT {{.PlayerDiscordState}}.",
+			}, templateData), false
+		}
+	}
+
+	if set && oldValue == value {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.qualified.already",
+			Other: "That rule is already set for {{.Rule}}!",
+		}, templateData), false
+	}
+
+	sett.SetModeVoiceRule(isMute, gamePhase, key, mode, value)
+	if value {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.SettingVoiceRules.qualified.setValues",
+			Other: "From now on, when in `{{.PhaseName}}` phase, {{.Rule}} will be {{.PlayerDiscordState}}.",
+		}, templateData), true
+	}
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "settings.SettingVoiceRules.qualified.setUnValues",
+		Other: "From now on, when in `{{.PhaseName}}` phase, {{.Rule}} will be un{{.PlayerDiscordState}}.",
+	}, templateData), true
+}
@@ -56,3 +56,131 @@ func TestFnVoiceRules(t *testing.T) {
 		t.Error("Valid VR rule change was not changed successfully!")
 	}
 }
+
+func TestFnVoiceRulesOverride(t *testing.T) {
+	sett, err := testSettingsFn(FnVoiceRules)
+	if err != nil {
+		t.Error(err)
+	}
+
+	_, valid := FnVoiceRules(sett, []string{"deafened", "tasks", "alive", "<@140581066283941888>"})
+	if valid {
+		t.Error("Querying a VR override should never result in a valid settings change")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"deafened", "tasks", "alive", "<@140581066283941888>", Clear})
+	if valid {
+		t.Error("Clearing a VR override that doesn't exist should never result in a valid settings change")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"deafened", "tasks", "alive", "false", "<@140581066283941888>"})
+	if !valid {
+		t.Error("Valid VR override should result in a valid settings change")
+	}
+	if v, ok := sett.GetVoiceOverride(false, "140581066283941888", false, game.TASKS, "alive"); !ok || v {
+		t.Error("VR override for a user was not set successfully!")
+	}
+	// the guild rule must be untouched
+	if !sett.VoiceRules.DeafRules[game.PhaseNames[game.TASKS]]["alive"] {
+		t.Error("Setting a VR override should not change the guild rule")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"deafened", "tasks", "alive", "false", "<@140581066283941888>"})
+	if valid {
+		t.Error("Setting a VR override to the existing value should never result in a valid settings change")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"muted", "discussion", "dead", "false", "<@&754465589958803548>"})
+	if !valid {
+		t.Error("Valid VR role override should result in a valid settings change")
+	}
+	if v, ok := sett.GetVoiceOverride(true, "754465589958803548", true, game.DISCUSS, "dead"); !ok || v {
+		t.Error("VR override for a role was not set successfully!")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"deafened", "tasks", "alive", Clear, "<@140581066283941888>"})
+	if !valid {
+		t.Error("Clearing a VR override should result in a valid settings change")
+	}
+	if _, ok := sett.GetVoiceOverride(false, "140581066283941888", false, game.TASKS, "alive"); ok {
+		t.Error("VR override was not cleared successfully!")
+	}
+
+	_, valid = FnVoiceRules(sett, []string{"deafened", "tasks", "alive", "true", "<@notauser>"})
+	if valid {
+		t.Error("An invalid VR override target should never result in a valid settings change")
+	}
+}
+
+func TestApplyVoiceOverrides(t *testing.T) {
+	sett, err := testSettingsFn(FnVoiceRules)
+	if err != nil {
+		t.Error(err)
+	}
+	// streamers are never deafened, casters are always muted, and the user override beats both
+	sett.SetVoiceOverride(true, "streamer", false, game.TASKS, "alive", false)
+	sett.SetVoiceOverride(true, "caster", true, game.TASKS, "dead", true)
+	sett.SetVoiceOverride(false, "user", true, game.TASKS, "dead", false)
+
+	tests := []struct {
+		name       string
+		userID     string
+		roles      []string
+		alive      bool
+		mute, deaf bool
+	}{
+		{"no override", "other", nil, true, true, true},
+		{"streamer", "other", []string{"streamer"}, true, true, false},
+		{"caster", "other", []string{"caster"}, false, true, false},
+		{"user beats role", "user", []string{"caster"}, false, false, false},
+	}
+	for _, tt := range tests {
+		mute, deaf := sett.GetVoiceStateFor(tt.userID, tt.roles, game.VoicePlayer{IsAlive: tt.alive}, true, game.TASKS, "")
+		if mute != tt.mute || deaf != tt.deaf {
+			t.Errorf("%s: expected mute=%v deaf=%v, got mute=%v deaf=%v", tt.name, tt.mute, tt.deaf, mute, deaf)
+		}
+	}
+}
+
+func TestFnVoiceRulesQualified(t *testing.T) {
+	sett, err := testSettingsFn(FnVoiceRules)
+	if err != nil {
+		t.Error(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		valid bool
+	}{
+		{"query without a rule", []string{"muted", "tasks", "alive", "impostor"}, false},
+		{"team rule", []string{"muted", "tasks", "alive", "false", "impostor"}, true},
+		{"same team rule again", []string{"muted", "tasks", "alive", "impostor", "false"}, false},
+		{"exiled rule", []string{"muted", "discussion", "exiled", "false"}, true},
+		{"mode rule", []string{"deafened", "tasks", "dead", "true", "town-of-us"}, true},
+		{"override with a team", []string{"muted", "tasks", "alive", "true", "impostor", "<@140581066283941888>"}, false},
+		{"override for exiled players", []string{"muted", "tasks", "exiled", "true", "<@140581066283941888>"}, false},
+		{"clear the exiled rule", []string{"muted", "discussion", "exiled", Clear}, true},
+		{"clear it again", []string{"muted", "discussion", "exiled", Clear}, false},
+		{"clear an alive/dead rule", []string{"muted", "tasks", "alive", Clear, "crewmate"}, false},
+	}
+	for _, tt := range tests {
+		if _, valid := FnVoiceRules(sett, tt.args); valid != tt.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tt.name, tt.valid, valid)
+		}
+	}
+
+	impostor := game.VoicePlayer{IsAlive: true, Team: game.ImpostorTeam}
+	if mute, _ := sett.GetVoiceStateFor("140581066283941888", nil, impostor, true, game.TASKS, ""); mute {
+		t.Error("expected the impostor rule to unmute impostors during tasks")
+	}
+	if mute, _ := sett.GetVoiceStateFor("140581066283941888", nil, game.VoicePlayer{IsAlive: true}, true, game.TASKS, ""); !mute {
+		t.Error("expected players without a known team to follow the alive rule")
+	}
+	if _, deaf := sett.GetVoiceStateFor("140581066283941888", nil, game.VoicePlayer{}, true, game.TASKS, game.TownOfUsMode); !deaf {
+		t.Error("expected the mode's rule to deafen dead players")
+	}
+	if _, ok := sett.GetModeVoiceRule(true, game.DISCUSS, game.ExiledState, ""); ok {
+		t.Error("expected the exiled rule to be cleared")
+	}
+}
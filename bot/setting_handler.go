@@ -28,6 +28,10 @@ func (bot *Bot) HandleSettingsCommand(guildID string, sett *settings.GuildSettin
 		sendMsg, isValid = setting.FnDelays(sett, args)
 	case setting.VoiceRules:
 		sendMsg, isValid = setting.FnVoiceRules(sett, args)
+	case setting.GhostChannel:
+		sendMsg, isValid = setting.FnGhostChannel(sett, args)
+	case setting.GameMode:
+		sendMsg, isValid = setting.FnGameMode(sett, args)
 	case setting.MatchSummary:
 		if !prem {
 			return nonPremiumSettingResponse(sett)
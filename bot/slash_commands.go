@@ -16,8 +16,11 @@ import (
     "github.com/automuteus/automuteus/v8/bot/setting"
     redis_common "github.com/automuteus/automuteus/v8/common"
     "github.com/automuteus/automuteus/v8/pkg/discord"
+    "github.com/automuteus/automuteus/v8/pkg/game"
     "github.com/automuteus/automuteus/v8/pkg/premium"
+    "github.com/automuteus/automuteus/v8/pkg/schedule"
     "github.com/automuteus/automuteus/v8/pkg/settings"
+    "github.com/automuteus/automuteus/v8/pkg/task"
     "github.com/bwmarrin/discordgo"
     "github.com/nicksnyder/go-i18n/v2/i18n"
 )
@@ -65,6 +68,18 @@ const (
     // ===== 追加: /link 色選択ボタン用 =====
     // CustomID: "link-color:<starterUserID>:<targetUserID>:<ColorName>"
     linkColorButtonPrefix = "link-color"
+
+    // fuzzy name match waiting for confirmation on the game state message
+    // CustomID: "link-suggest:<color>:<userID>"
+    linkSuggestButtonPrefix = "link-suggest"
+
+    // RSVP on a scheduled game's reminder
+    // CustomID: "schedule-rsvp:<sessionID>"
+    scheduleRSVPButtonPrefix = "schedule-rsvp"
+
+    // page of a match's timeline on /stats view match
+    // CustomID: "match-timeline:<connectCode>:<matchID>"
+    matchTimelineSelectPrefix = "match-timeline"
 )
 
 // ===== 追加: /new(/start) のエフェメラルに付ける /link & /stop ボタン =====
@@ -177,10 +192,12 @@ func (bot *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.Inter
                     if content == "" {
                         content = "\u200b"
                     }
+                    // slow responses are the likeliest to have files, like the stats images
                     followUpMsg, err = s.FollowupMessageEdit(i.Interaction, followUpMsg.ID, &discordgo.WebhookEdit{
                         Content:    &content,
                         Components: &resp.Data.Components,
                         Embeds:     &resp.Data.Embeds,
+                        Files:      resp.Data.Files,
                     })
                 } else {
                     //TODO if this shows up in logs regularly, print more context
@@ -293,10 +310,20 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             return command.InfoResponse(botInfo, i.GuildID, sett)
 
         case command.Link.Name:
+            action, userID, value := command.GetLinkParams(s, i.ApplicationCommandData().Options)
+            // anyone can view and prune their own aliases
+            switch action {
+            case command.LinkAliases:
+                aliases, err := bot.PostgresInterface.GetAliasesForUser(i.GuildID, i.Member.User.ID)
+                return command.LinkAliasesResponse(aliases, err, sett)
+            case command.LinkForget:
+                deleted, err := bot.PostgresInterface.DeleteAliasesForUser(i.GuildID, i.Member.User.ID, value)
+                return command.LinkForgetResponse(value, deleted, err, sett)
+            }
             if !isPermissioned {
                 return command.InsufficientPermissionsResponse(sett)
             }
-            userID, color := command.GetLinkParams(s, i.ApplicationCommandData().Options)
+            color := value
 
             lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
             if lock == nil {
@@ -370,18 +397,13 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 
             status, activeGames := bot.newGame(dgs)
             if status == command.NewSuccess {
+                dgs.GameMode = command.GetNewParams(i.ApplicationCommandData().Options)
                 // release the lock
                 bot.RedisInterface.SetDiscordGameState(dgs, lock)
 
                 bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
 
-                killChan := make(chan EndGameMessage)
-
-                go bot.SubscribeToGameByConnectCode(i.GuildID, dgs.ConnectCode, killChan)
-
-                bot.ChannelsMapLock.Lock()
-                bot.EndGameChannels[dgs.ConnectCode] = killChan
-                bot.ChannelsMapLock.Unlock()
+                bot.startGameWorker(i.GuildID, dgs.ConnectCode)
 
                 hyperlink, apiHyperlink, minimalURL := formCaptureURL(bot.url, dgs.ConnectCode)
 
@@ -426,24 +448,23 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             if !isPermissioned {
                 return command.InsufficientPermissionsResponse(sett)
             }
-            lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
-            if lock == nil {
-                log.Printf("No lock could be obtained when pausing game for guild %s, channel %s\n", i.GuildID, i.ChannelID)
+            dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
+            if dgs == nil {
                 return command.DeadlockGameStateResponse(command.Pause.Name, sett)
             }
             if !dgs.GameStateMsg.Exists() {
-                bot.RedisInterface.SetDiscordGameState(nil, lock)
                 return command.NoGameResponse(sett)
             }
 
-            dgs.Running = !dgs.Running
-
-            bot.RedisInterface.SetDiscordGameState(dgs, lock)
-            // if we paused the game, unmute/undeafen all players
-            if !dgs.Running {
-                err = bot.applyToAll(dgs, false, false)
+            // the node that owns the game pauses it, so it can't race with the capture events it's processing
+            if bot.sendGameControl(dgs.ConnectCode, task.ControlPause) {
+                return command.PrivateResponse(ThumbsUp)
+            }
+            err = bot.togglePause(GameStateRequest{GuildID: dgs.GuildID, ConnectCode: dgs.ConnectCode})
+            if errors.Is(err, errGameStateLocked) {
+                log.Printf("No lock could be obtained when pausing game for guild %s, channel %s\n", i.GuildID, i.ChannelID)
+                return command.DeadlockGameStateResponse(command.Pause.Name, sett)
             }
-            bot.DispatchRefreshOrEdit(dgs, gsr, sett)
             if err != nil {
                 return command.PrivateErrorResponse(command.Pause.Name, err, sett)
             }
@@ -459,10 +480,10 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
                     return command.NoGameResponse(sett)
                 }
 
-                if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
-                    v <- true
+                // nobody is processing the game (its owner died before anyone adopted it), so end it here
+                if !bot.sendGameControl(dgs.ConnectCode, task.ControlEnd) {
+                    go bot.forceEndGame(GameStateRequest{GuildID: dgs.GuildID, ConnectCode: dgs.ConnectCode})
                 }
-                delete(bot.EndGameChannels, dgs.ConnectCode)
 
                 err = bot.applyToAll(dgs, false, false)
                 if err != nil {
@@ -478,18 +499,13 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             case command.PrivacyInfo:
                 return command.PrivacyResponse(privArg, nil, nil, nil, sett)
 
-            case command.PrivacyOptOut:
-                err = bot.RedisInterface.DeleteLinksByUserID(i.GuildID, i.Member.User.ID)
-                if err != nil {
-                    return command.PrivacyResponse(privArg, nil, nil, err, sett)
-                }
-                fallthrough
-            case command.PrivacyOptIn:
+            // opting out also forgets every in-game name the user was linked to
+            case command.PrivacyOptIn, command.PrivacyOptOut:
                 err = bot.PostgresInterface.OptUserByString(i.Member.User.ID, privArg == command.PrivacyOptIn)
                 return command.PrivacyResponse(privArg, nil, nil, err, sett)
 
             case command.PrivacyShowMe:
-                cached, _ := bot.RedisInterface.GetUsernameOrUserIDMappings(i.GuildID, i.Member.User.ID)
+                cached, _ := bot.cachedPlayerNames(i.GuildID, i.Member.User.ID)
                 user, err := bot.PostgresInterface.GetUserByString(i.Member.User.ID)
                 return command.PrivacyResponse(privArg, cached, user, err, sett)
             }
@@ -510,15 +526,44 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             }
             if action == setting.View {
                 var embed *discordgo.MessageEmbed
+                var components []discordgo.MessageComponent
+                var files []*discordgo.File
                 switch opType {
-                case command.User:
-                    embed = bot.UserStatsEmbed(id, i.GuildID, sett, prem)
-                case command.Guild:
-                    embed = bot.GuildStatsEmbed(i.GuildID, sett, prem)
+                case command.User, command.Guild:
+                    rangeParams, err := command.GetStatsRangeParams(i.ApplicationCommandData().Options)
+                    if err != nil {
+                        return command.StatsRangeErrorResponse(command.Stats.Name+" "+opType, err, sett)
+                    }
+                    statsRange, period, err := bot.statsRange(i.GuildID, rangeParams)
+                    if errors.Is(err, errUnknownSeason) {
+                        return command.SeasonNotFoundResponse(rangeParams.Season, sett)
+                    } else if err != nil {
+                        return command.StatsRangeErrorResponse(command.Stats.Name+" "+opType, err, sett)
+                    }
+                    if opType == command.User {
+                        embed = bot.UserStatsEmbed(id, i.GuildID, statsRange, period, sett, prem)
+                        files = bot.UserStatsImages(id, i.GuildID, statsRange, prem)
+                    } else {
+                        embed = bot.GuildStatsEmbed(i.GuildID, statsRange, period, sett, prem)
+                        files = bot.GuildStatsImages(i.GuildID, statsRange, prem)
+                    }
+                case command.Lobby:
+                    dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
+                    if dgs == nil {
+                        return command.DeadlockGameStateResponse(command.Stats.Name+" "+command.Lobby, sett)
+                    }
+                    if !dgs.GameStateMsg.Exists() {
+                        return command.NoGameResponse(sett)
+                    }
+                    embed = bot.LobbyStatsEmbed(dgs, sett, prem)
                 case command.Match:
                     if MatchIDRegex.Match([]byte(id)) {
                         tokens := strings.Split(id, ":")
-                        embed = bot.GameStatsEmbed(i.GuildID, tokens[1], tokens[0], prem, sett)
+                        var err error
+                        embed, components, err = bot.GameStatsMessage(i.GuildID, tokens[1], tokens[0], timelineOverviewPage, prem, sett)
+                        if err != nil {
+                            return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
+                        }
                     } else {
                         err := fmt.Errorf("invalid match code provided: %s, should resemble something like `1A2B3C4D:12345`", id)
                         return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
@@ -528,9 +573,9 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
                     return &discordgo.InteractionResponse{
                         Type: discordgo.InteractionResponseChannelMessageWithSource,
                         Data: &discordgo.InteractionResponseData{
-                            Embeds: []*discordgo.MessageEmbed{
-                                embed,
-                            },
+                            Embeds:     attachStatsImages(embed, files),
+                            Components: components,
+                            Files:      files,
                         },
                     }
                 }
@@ -586,7 +631,7 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             action, opType, id := command.GetDebugParams(bot.PrimarySession, i.Member.User.ID, i.ApplicationCommandData().Options)
             if action == setting.View {
                 if opType == command.User {
-                    cached, err := bot.RedisInterface.GetUsernameOrUserIDMappings(i.GuildID, id)
+                    cached, err := bot.cachedPlayerNames(i.GuildID, id)
                     log.Println("View user cache")
                     return command.DebugResponse(setting.View, cached, nil, id, err, sett)
                 } else if opType == command.GameState {
@@ -605,7 +650,7 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
                             return command.InsufficientPermissionsResponse(sett)
                         }
                     }
-                    err := bot.RedisInterface.DeleteLinksByUserID(i.GuildID, id)
+                    _, err := bot.PostgresInterface.DeleteAliasesForUser(i.GuildID, id, "")
                     return command.DebugResponse(setting.Clear, nil, nil, id, err, sett)
                 }
             } else if action == command.Unmute {
@@ -715,6 +760,94 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
                     Components: components,
                 },
             }
+
+        case command.Schedule.Name:
+            action, params := command.GetScheduleParams(s, i.ApplicationCommandData().Options)
+            switch action {
+            case command.ScheduleCreate:
+                if !isPermissioned {
+                    return command.InsufficientPermissionsResponse(sett)
+                }
+                perm, err = bot.PrimarySession.State.UserChannelPermissions(s.State.User.ID, params.VoiceChannelID)
+                missingPerms = checkPermissions(perm, VoicePermissions)
+                if missingPerms > 0 {
+                    return command.ReinviteMeResponse(missingPerms, params.VoiceChannelID, sett)
+                }
+                loc, err := schedule.LoadLocation(params.Timezone)
+                if err != nil {
+                    return command.ScheduleInvalidTimezoneResponse(params.Timezone, sett)
+                }
+                start, err := schedule.ParseStart(params.Date, params.Time, loc, time.Now())
+                if err != nil {
+                    return command.ScheduleCreateResponse(nil, err, sett)
+                }
+                // snowflakes from discord always parse
+                guildID, _ := strconv.ParseUint(i.GuildID, 10, 64)
+                textChannelID, _ := strconv.ParseUint(i.ChannelID, 10, 64)
+                voiceChannelID, _ := strconv.ParseUint(params.VoiceChannelID, 10, 64)
+                createdBy, _ := strconv.ParseUint(i.Member.User.ID, 10, 64)
+                session := &storage.PostgresScheduledSession{
+                    GuildID:        guildID,
+                    TextChannelID:  textChannelID,
+                    VoiceChannelID: voiceChannelID,
+                    CreatedBy:      createdBy,
+                    Title:          params.Title,
+                    StartTime:      start,
+                    Timezone:       loc.String(),
+                    Recurrence:     string(params.Recurrence),
+                }
+                if params.HostRoleID != "" {
+                    roleID, _ := strconv.ParseUint(params.HostRoleID, 10, 64)
+                    session.HostRoleID = &roleID
+                }
+                session.SessionID, err = bot.PostgresInterface.AddScheduledSession(session)
+                return command.ScheduleCreateResponse(session, err, sett)
+            case command.ScheduleList:
+                sessions, err := bot.PostgresInterface.GetScheduledSessions(i.GuildID)
+                return command.ScheduleListResponse(sessions, err, sett)
+            case command.ScheduleCancel:
+                if !isPermissioned {
+                    return command.InsufficientPermissionsResponse(sett)
+                }
+                deleted, err := bot.PostgresInterface.DeleteScheduledSession(i.GuildID, params.SessionID)
+                return command.ScheduleCancelResponse(params.SessionID, deleted, err, sett)
+            }
+
+        case command.Season.Name:
+            action, params := command.GetSeasonParams(i.ApplicationCommandData().Options)
+            switch action {
+            case command.SeasonCreate:
+                if !isPermissioned {
+                    return command.InsufficientPermissionsResponse(sett)
+                }
+                loc, err := schedule.LoadLocation(params.Timezone)
+                if err != nil {
+                    return command.ScheduleInvalidTimezoneResponse(params.Timezone, sett)
+                }
+                start, end, err := schedule.ParseDateRange(params.Start, params.End, loc)
+                if err != nil {
+                    return command.StatsRangeErrorResponse(command.Season.Name+" "+command.SeasonCreate, err, sett)
+                }
+                // snowflakes from discord always parse
+                guildID, _ := strconv.ParseUint(i.GuildID, 10, 64)
+                season := &storage.PostgresSeason{
+                    GuildID:   guildID,
+                    Name:      params.Name,
+                    StartTime: *start,
+                    EndTime:   *end,
+                }
+                season.SeasonID, err = bot.PostgresInterface.AddSeason(season)
+                return command.SeasonCreateResponse(season, err, sett)
+            case command.SeasonList:
+                seasons, err := bot.PostgresInterface.GetSeasons(i.GuildID)
+                return command.SeasonListResponse(seasons, err, sett)
+            case command.SeasonDelete:
+                if !isPermissioned {
+                    return command.InsufficientPermissionsResponse(sett)
+                }
+                deleted, err := bot.PostgresInterface.DeleteSeason(i.GuildID, params.Name)
+                return command.SeasonDeleteResponse(params.Name, deleted, err, sett)
+            }
         }
 
     } else if i.Type == discordgo.InteractionMessageComponent {
@@ -919,6 +1052,51 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             }
             return resp
 
+        // ========= 追加: 名前が似ているプレイヤーのリンク候補ボタン =========
+        case strings.HasPrefix(customID, linkSuggestButtonPrefix):
+            // CustomID: "link-suggest:<color>:<userID>"
+            parts := strings.SplitN(customID, ":", 3)
+            if len(parts) < 3 {
+                return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+                    ID:    "commands.link.suggest.invalid",
+                    Other: "リンク候補のボタン情報が不正です。",
+                }))
+            }
+            color, err := strconv.Atoi(parts[1])
+            if err != nil {
+                return command.PrivateErrorResponse(command.Link.Name, err, sett)
+            }
+            targetUserID := parts[2]
+
+            gsr := GameStateRequest{
+                GuildID:     i.GuildID,
+                TextChannel: i.ChannelID,
+            }
+            lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+            if lock == nil {
+                log.Printf("No lock could be obtained when linking for guild %s, channel %s\n", i.GuildID, i.ChannelID)
+                return command.DeadlockGameStateResponse(command.Link.Name, sett)
+            }
+
+            // 候補に挙がった本人か、ゲームを開始した人だけが確定できる
+            if i.Member.User.ID != targetUserID && i.Member.User.ID != dgs.GameStateMsg.LeaderID {
+                bot.RedisInterface.SetDiscordGameState(nil, lock)
+                return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+                    ID:    "commands.link.suggest.notAllowed",
+                    Other: "このリンク候補を確定できるのは、候補のユーザー本人かゲームを開始した人だけです。",
+                }))
+            }
+
+            resp, success := bot.linkOrUnlinkAndRespond(dgs, targetUserID, game.GetColorStringForInt(color), sett)
+            if success {
+                bot.RedisInterface.SetDiscordGameState(dgs, lock)
+                bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+            } else {
+                // only release the lock; no changes
+                bot.RedisInterface.SetDiscordGameState(nil, lock)
+            }
+            return resp
+
         // ========= 既存: /stop ボタン =========
         case strings.HasPrefix(customID, stopButtonIDPrefix):
             // CustomID: "stop-game:<starterUserID>"
@@ -948,10 +1126,10 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
                     return command.NoGameResponse(sett)
                 }
 
-                if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
-                    v <- true
+                // nobody is processing the game (its owner died before anyone adopted it), so end it here
+                if !bot.sendGameControl(dgs.ConnectCode, task.ControlEnd) {
+                    go bot.forceEndGame(GameStateRequest{GuildID: dgs.GuildID, ConnectCode: dgs.ConnectCode})
                 }
-                delete(bot.EndGameChannels, dgs.ConnectCode)
 
                 err = bot.applyToAll(dgs, false, false)
                 if err != nil {
@@ -961,6 +1139,71 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
             }
             return command.DeadlockGameStateResponse(command.End.Name, sett)
 
+        // join/leave the waiting list on the game state message
+        case customID == rosterJoinID, customID == rosterLeaveID:
+            return bot.handleRosterButton(s, g, gsr, i.Member.User.ID, customID == rosterJoinID, sett)
+
+        // RSVP on a scheduled game's reminder
+        case strings.HasPrefix(customID, scheduleRSVPButtonPrefix):
+            // CustomID: "schedule-rsvp:<sessionID>"
+            parts := strings.SplitN(customID, ":", 2)
+            if len(parts) < 2 {
+                return nil
+            }
+            sessionID, err := strconv.ParseInt(parts[1], 10, 64)
+            if err != nil {
+                return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+            }
+            session, err := bot.PostgresInterface.GetScheduledSession(sessionID)
+            if err != nil {
+                return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+            }
+            if session == nil {
+                return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+                    ID:    "schedule.reminder.cancelled",
+                    Other: "❌ This game isn't scheduled anymore",
+                }))
+            }
+            _, err = bot.PostgresInterface.ToggleSessionRSVP(sessionID, i.Member.User.ID)
+            if err != nil {
+                return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+            }
+            rsvps, err := bot.PostgresInterface.GetSessionRSVPs(sessionID)
+            if err != nil {
+                return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+            }
+            return &discordgo.InteractionResponse{
+                Type: discordgo.InteractionResponseUpdateMessage,
+                Data: &discordgo.InteractionResponseData{
+                    Embeds:     []*discordgo.MessageEmbed{scheduleReminderEmbed(session, rsvps, sett)},
+                    Components: scheduleReminderComponents(session.SessionID, sett),
+                },
+            }
+
+        // flip to another page of a match's timeline
+        case strings.HasPrefix(customID, matchTimelineSelectPrefix):
+            // CustomID: "match-timeline:<connectCode>:<matchID>"
+            parts := strings.SplitN(customID, ":", 3)
+            values := i.MessageComponentData().Values
+            if len(parts) < 3 || len(values) == 0 {
+                return nil
+            }
+            tier, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, i.GuildID, i.Member.User.ID)
+            if err != nil {
+                log.Println("Error in match timeline getPremium:", err)
+            }
+            embed, components, err := bot.GameStatsMessage(i.GuildID, parts[2], parts[1], values[0], !premium.IsExpired(tier, days), sett)
+            if err != nil {
+                return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
+            }
+            return &discordgo.InteractionResponse{
+                Type: discordgo.InteractionResponseUpdateMessage,
+                Data: &discordgo.InteractionResponseData{
+                    Embeds:     []*discordgo.MessageEmbed{embed},
+                    Components: components,
+                },
+            }
+
         // ========= 色ボタン（元からある自分用 select-color） =========
         case strings.HasPrefix(customID, colorSelectID):
             // CustomID: "select-color:Red" 形式
@@ -1232,10 +1475,19 @@ func downloadErrorResponse(sett *settings.GuildSettings, err error) *discordgo.I
 }
 
 func (bot *Bot) linkOrUnlinkAndRespond(dgs *GameState, userID, testValue string, sett *settings.GuildSettings) (*discordgo.InteractionResponse, bool) {
+    // linking can settle a pending suggestion, so the suggestion buttons may need updating too
+    suggestions := len(dgs.LinkSuggestions)
+    defer func() {
+        if dgs.PruneLinkSuggestions() || len(dgs.LinkSuggestions) != suggestions {
+            bot.EditGameStateComponents(dgs)
+        }
+        // the caller refreshes the message, with the waiting list
+        dgs.PruneWaitingList()
+    }()
     if testValue != "" {
         // don't care if it's successful, just always unlink before linking
         unlinkPlayer(dgs, userID)
-        status, err := linkPlayer(bot.RedisInterface, dgs, userID, testValue)
+        status, err := linkPlayer(bot.PostgresInterface, dgs, userID, testValue)
         if err != nil {
             log.Println(err)
         }
@@ -1246,6 +1498,16 @@ func (bot *Bot) linkOrUnlinkAndRespond(dgs *GameState, userID, testValue string,
     }
 }
 
+// cachedPlayerNames are the in-game names a user was linked to on the guild, as a set
+func (bot *Bot) cachedPlayerNames(guildID, userID string) (map[string]interface{}, error) {
+    aliases, err := bot.PostgresInterface.GetAliasesForUser(guildID, userID)
+    names := make(map[string]interface{}, len(aliases))
+    for _, alias := range aliases {
+        names[alias.PlayerName] = struct{}{}
+    }
+    return names, err
+}
+
 // deleteComponentInParentMessage deletes any components from parent messages.
 // this is required for safety. if the resetting process takes over 2 seconds,
 // since RESET/Cancel buttons remain forever once the button has been clicked.
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/amongus"
+	"github.com/automuteus/automuteus/v8/pkg/rating"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/automuteus/automuteus/v8/pkg/storage"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -16,9 +19,9 @@ import (
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 )
 
-func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
-	gamesPlayed := bot.PostgresInterface.NumGamesPlayedByUserOnServer(userID, guildID)
-	wins := bot.PostgresInterface.NumWinsOnServer(userID, guildID)
+func (bot *Bot) UserStatsEmbed(userID, guildID string, statsRange storage.StatsRange, period string, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+	gamesPlayed := bot.PostgresInterface.NumGamesPlayedByUserOnServer(userID, guildID, statsRange)
+	wins := bot.PostgresInterface.NumWinsOnServer(userID, guildID, statsRange)
 
 	avatarURL := ""
 	mem, err := bot.PrimarySession.GuildMember(guildID, userID)
@@ -57,6 +60,11 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 		Value:  fmt.Sprintf("%d/%d | %.0f%%", wins, gamesPlayed, winrate),
 		Inline: true,
 	}
+	ratings, err := bot.PostgresInterface.RatingsForPlayerOnServer(userID, guildID, statsRange)
+	if err != nil {
+		log.Println(err)
+	}
+	fields = append(fields, ratingFields(ratings, sett)...)
 
 	extraDesc := sett.LocalizeMessage(&i18n.Message{
 		ID:    "responses.userStatsEmbed.NoPremium",
@@ -70,7 +78,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 		//	ID:    "responses.userStatsEmbed.Premium",
 		//	Other: "Showing additional Premium Stats!\n(Note: stats are still in **BETA**, and will be likely be inaccurate while we work to improve them).",
 		//})
-		colorRankings := bot.PostgresInterface.ColorRankingForPlayerOnServer(userID, guildID)
+		colorRankings := bot.PostgresInterface.ColorRankingForPlayerOnServer(userID, guildID, statsRange)
 		if len(colorRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i := 0; i < len(colorRankings) && i < leaderBoardSize; i++ {
@@ -90,7 +98,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 				Inline: true,
 			})
 		}
-		nameRankings := bot.PostgresInterface.NamesRankingForPlayerOnServer(userID, guildID)
+		nameRankings := bot.PostgresInterface.NamesRankingForPlayerOnServer(userID, guildID, statsRange)
 		if len(nameRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i := 0; i < len(nameRankings) && i < leaderBoardSize; i++ {
@@ -142,9 +150,9 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		totalCrewmateGames := bot.PostgresInterface.NumGamesAsRoleOnServer(userID, guildID, int16(game.CrewmateRole))
+		totalCrewmateGames := bot.PostgresInterface.NumGamesAsRoleOnServer(userID, guildID, int16(game.CrewmateRole), statsRange)
 		if totalCrewmateGames > 0 {
-			crewmateWins := bot.PostgresInterface.NumWinsAsRoleOnServer(userID, guildID, int16(game.CrewmateRole))
+			crewmateWins := bot.PostgresInterface.NumWinsAsRoleOnServer(userID, guildID, int16(game.CrewmateRole), statsRange)
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name: sett.LocalizeMessage(&i18n.Message{
 					ID:    "responses.userStatsEmbed.CrewmateWins",
@@ -165,9 +173,9 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 				Inline: true,
 			})
 		}
-		totalImposterGames := bot.PostgresInterface.NumGamesAsRoleOnServer(userID, guildID, int16(game.ImposterRole))
+		totalImposterGames := bot.PostgresInterface.NumGamesAsRoleOnServer(userID, guildID, int16(game.ImposterRole), statsRange)
 		if totalImposterGames > 0 {
-			imposterWins := bot.PostgresInterface.NumWinsAsRoleOnServer(userID, guildID, int16(game.ImposterRole))
+			imposterWins := bot.PostgresInterface.NumWinsAsRoleOnServer(userID, guildID, int16(game.ImposterRole), statsRange)
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name: sett.LocalizeMessage(&i18n.Message{
 					ID:    "responses.userStatsEmbed.ImposterWins",
@@ -199,7 +207,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			Inline: false,
 		})
 
-		playerRankings := bot.PostgresInterface.OtherPlayersRankingForPlayerOnServer(userID, guildID)
+		playerRankings := bot.PostgresInterface.OtherPlayersRankingForPlayerOnServer(userID, guildID, statsRange)
 		if len(playerRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range playerRankings {
@@ -217,7 +225,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			}
 		}
 
-		bestImpostorTeammateRankings := bot.PostgresInterface.BestTeammateByRole(userID, guildID, int16(game.ImposterRole), 2)
+		bestImpostorTeammateRankings := bot.PostgresInterface.BestTeammateByRole(userID, guildID, int16(game.ImposterRole), 2, statsRange)
 		if len(bestImpostorTeammateRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range bestImpostorTeammateRankings {
@@ -243,7 +251,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		worstImpostorTeammateRankings := bot.PostgresInterface.WorstTeammateByRole(userID, guildID, int16(game.ImposterRole), 2)
+		worstImpostorTeammateRankings := bot.PostgresInterface.WorstTeammateByRole(userID, guildID, int16(game.ImposterRole), 2, statsRange)
 		if len(worstImpostorTeammateRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range worstImpostorTeammateRankings {
@@ -274,7 +282,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		bestCrewmateTeammateRankings := bot.PostgresInterface.BestTeammateByRole(userID, guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin())
+		bestCrewmateTeammateRankings := bot.PostgresInterface.BestTeammateByRole(userID, guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin(), statsRange)
 		if len(bestCrewmateTeammateRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range bestCrewmateTeammateRankings {
@@ -300,7 +308,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		worstCrewmateTeammateRankings := bot.PostgresInterface.WorstTeammateByRole(userID, guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin())
+		worstCrewmateTeammateRankings := bot.PostgresInterface.WorstTeammateByRole(userID, guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin(), statsRange)
 		if len(bestCrewmateTeammateRankings) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range worstCrewmateTeammateRankings {
@@ -326,7 +334,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		userExiledAsImpostor := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.EXILED)), int16(game.ImposterRole))
+		userExiledAsImpostor := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.EXILED)), int16(game.ImposterRole), statsRange)
 		if len(userExiledAsImpostor) > 0 {
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   "\u200b",
@@ -360,7 +368,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		userExiledAsCrewmate := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.EXILED)), int16(game.CrewmateRole))
+		userExiledAsCrewmate := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.EXILED)), int16(game.CrewmateRole), statsRange)
 		if len(userExiledAsImpostor) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range userExiledAsCrewmate {
@@ -389,7 +397,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		userKilledAsCrewmate := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.DIED)), int16(game.CrewmateRole))
+		userKilledAsCrewmate := bot.PostgresInterface.UserWinByActionAndRole(userID, guildID, strconv.Itoa(int(game.DIED)), int16(game.CrewmateRole), statsRange)
 		if len(userKilledAsCrewmate) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range userKilledAsCrewmate {
@@ -418,7 +426,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		userFirstTimeKilled := bot.PostgresInterface.UserFrequentFirstTarget(userID, guildID, strconv.Itoa(int(game.DIED)), sett.GetLeaderboardSize())
+		userFirstTimeKilled := bot.PostgresInterface.UserFrequentFirstTarget(userID, guildID, strconv.Itoa(int(game.DIED)), sett.GetLeaderboardSize(), statsRange)
 		if len(userFirstTimeKilled) > 0 {
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   "\u200b",
@@ -443,7 +451,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			})
 		}
 
-		userMostFrequentKilledBy := bot.PostgresInterface.UserMostFrequentKilledBy(userID, guildID)
+		userMostFrequentKilledBy := bot.PostgresInterface.UserMostFrequentKilledBy(userID, guildID, statsRange)
 		if len(userMostFrequentKilledBy) > 0 {
 			buf := bytes.NewBuffer([]byte{})
 			for i, v := range userMostFrequentKilledBy {
@@ -463,6 +471,12 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 				Inline: true,
 			})
 		}
+
+		roleRankings, err := bot.PostgresInterface.RoleRankingForPlayerOnServer(userID, guildID, statsRange)
+		if err != nil {
+			log.Println(err)
+		}
+		fields = appendRoleRankings(fields, roleRankings, sett)
 	}
 
 	fields = TrimEmbedFields(fields)
@@ -479,7 +493,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 			Other: "User stats for {{.User}}",
 		}, map[string]interface{}{
 			"User": "<@!" + userID + ">",
-		}) + "\n\n" + extraDesc,
+		}) + periodDesc(period, sett) + "\n\n" + extraDesc,
 		Timestamp: "",
 		Color:     3066993, // GREEN
 		Image:     nil,
@@ -535,7 +549,7 @@ func (bot *Bot) MentionWithCacheData(userID, guildID string, sett *settings.Guil
 	return "<@" + userID + ">"
 }
 
-func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+func (bot *Bot) GuildStatsEmbed(guildID string, statsRange storage.StatsRange, period string, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
 	gname := ""
 	avatarURL := ""
 	g, err := bot.PrimarySession.Guild(guildID)
@@ -548,7 +562,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 		avatarURL = g.IconURL("256")
 	}
 
-	gamesPlayed := bot.PostgresInterface.NumGamesPlayedOnGuild(guildID)
+	gamesPlayed := bot.PostgresInterface.NumGamesPlayedOnGuild(guildID, statsRange)
 
 	fields := make([]*discordgo.MessageEmbedField, 1)
 	fields[0] = &discordgo.MessageEmbedField{
@@ -561,8 +575,8 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 	}
 
 	if gamesPlayed > 0 {
-		crewmateWins := bot.PostgresInterface.NumGamesWonAsRoleOnServer(guildID, game.CrewmateRole)
-		imposterWins := bot.PostgresInterface.NumGamesWonAsRoleOnServer(guildID, game.ImposterRole)
+		crewmateWins := bot.PostgresInterface.NumGamesWonAsRoleOnServer(guildID, game.CrewmateRole, statsRange)
+		imposterWins := bot.PostgresInterface.NumGamesWonAsRoleOnServer(guildID, game.ImposterRole, statsRange)
 
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name: sett.LocalizeMessage(&i18n.Message{
@@ -597,7 +611,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 		//})
 		gid, err := strconv.ParseUint(guildID, 10, 64)
 		if err == nil {
-			totalGameRankings := bot.PostgresInterface.TotalGamesRankingForServer(gid)
+			totalGameRankings := bot.PostgresInterface.TotalGamesRankingForServer(gid, statsRange)
 
 			buf := bytes.NewBuffer([]byte{})
 			for i := 0; i < len(totalGameRankings) && i < leaderboardSize; i++ {
@@ -619,7 +633,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			overallGameRankings := bot.PostgresInterface.TotalWinRankingForServer(gid)
+			overallGameRankings := bot.PostgresInterface.TotalWinRankingForServer(gid, statsRange)
 			buf = bytes.NewBuffer([]byte{})
 			count := 0
 			for i := 0; i < len(overallGameRankings) && count < leaderboardSize; i++ {
@@ -651,7 +665,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				Inline: false,
 			})
 
-			crewmateGameRankings := bot.PostgresInterface.TotalWinRankingForServerByRole(gid, 0)
+			crewmateGameRankings := bot.PostgresInterface.TotalWinRankingForServerByRole(gid, 0, statsRange)
 			buf = bytes.NewBuffer([]byte{})
 			count = 0
 			for i := 0; i < len(crewmateGameRankings) && count < leaderboardSize; i++ {
@@ -678,7 +692,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			imposterGameRankings := bot.PostgresInterface.TotalWinRankingForServerByRole(gid, 1)
+			imposterGameRankings := bot.PostgresInterface.TotalWinRankingForServerByRole(gid, 1, statsRange)
 			buf = bytes.NewBuffer([]byte{})
 			count = 0
 			for i := 0; i < len(imposterGameRankings) && count < leaderboardSize; i++ {
@@ -705,13 +719,49 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
+			crewmateRatingRankings, err := bot.PostgresInterface.RatingRankingForServer(guildID, int16(game.CrewmateRole), leaderboardMin, leaderboardSize, statsRange)
+			if err != nil {
+				log.Println(err)
+			}
+			imposterRatingRankings, err := bot.PostgresInterface.RatingRankingForServer(guildID, int16(game.ImposterRole), leaderboardMin, leaderboardSize, statsRange)
+			if err != nil {
+				log.Println(err)
+			}
+			if len(crewmateRatingRankings) > 0 || len(imposterRatingRankings) > 0 {
+				fields = append(fields, &discordgo.MessageEmbedField{
+					Name:   "\u200b",
+					Value:  "\u200b",
+					Inline: false,
+				})
+				fields = append(fields, &discordgo.MessageEmbedField{
+					Name: sett.LocalizeMessage(&i18n.Message{
+						ID:    "responses.guildStatsEmbed.CrewmateRating",
+						Other: "Crewmate Rating ({{.Min}}+ Games)",
+					}, map[string]interface{}{
+						"Min": leaderboardMin,
+					}),
+					Value:  bot.ratingLeaderboard(crewmateRatingRankings, guildID, sett),
+					Inline: true,
+				})
+				fields = append(fields, &discordgo.MessageEmbedField{
+					Name: sett.LocalizeMessage(&i18n.Message{
+						ID:    "responses.guildStatsEmbed.ImposterRating",
+						Other: "Imposter Rating ({{.Min}}+ Games)",
+					}, map[string]interface{}{
+						"Min": leaderboardMin,
+					}),
+					Value:  bot.ratingLeaderboard(imposterRatingRankings, guildID, sett),
+					Inline: true,
+				})
+			}
+
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   "\u200b",
 				Value:  "\u200b",
 				Inline: false,
 			})
 
-			bestImpostorTeammateForServerRankings := bot.PostgresInterface.BestTeammateForServerByRole(guildID, int16(game.ImposterRole), 2)
+			bestImpostorTeammateForServerRankings := bot.PostgresInterface.BestTeammateForServerByRole(guildID, int16(game.ImposterRole), 2, statsRange)
 			if len(bestImpostorTeammateForServerRankings) > 0 {
 				buf := bytes.NewBuffer([]byte{})
 				for i, v := range bestImpostorTeammateForServerRankings {
@@ -733,7 +783,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			worstImpostorTeammateServerRankings := bot.PostgresInterface.WorstTeammateForServerByRole(guildID, int16(game.ImposterRole), 2)
+			worstImpostorTeammateServerRankings := bot.PostgresInterface.WorstTeammateForServerByRole(guildID, int16(game.ImposterRole), 2, statsRange)
 			if len(worstImpostorTeammateServerRankings) > 0 {
 				buf := bytes.NewBuffer([]byte{})
 				for i, v := range worstImpostorTeammateServerRankings {
@@ -760,7 +810,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			bestCrewmateTeammateServerRankings := bot.PostgresInterface.BestTeammateForServerByRole(guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin())
+			bestCrewmateTeammateServerRankings := bot.PostgresInterface.BestTeammateForServerByRole(guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin(), statsRange)
 			if len(bestCrewmateTeammateServerRankings) > 0 {
 				buf := bytes.NewBuffer([]byte{})
 				for i, v := range bestCrewmateTeammateServerRankings {
@@ -782,7 +832,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			worstCrewmateTeammateRankings := bot.PostgresInterface.WorstTeammateForServerByRole(guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin())
+			worstCrewmateTeammateRankings := bot.PostgresInterface.WorstTeammateForServerByRole(guildID, int16(game.CrewmateRole), sett.GetLeaderboardMin(), statsRange)
 			if len(worstCrewmateTeammateRankings) > 0 {
 				buf := bytes.NewBuffer([]byte{})
 				for i, v := range worstCrewmateTeammateRankings {
@@ -804,7 +854,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			userMostFirstTimeKilledForServer := bot.PostgresInterface.UserMostFrequentFirstTargetForServer(guildID, strconv.Itoa(int(game.DIED)), sett.GetLeaderboardSize())
+			userMostFirstTimeKilledForServer := bot.PostgresInterface.UserMostFrequentFirstTargetForServer(guildID, strconv.Itoa(int(game.DIED)), sett.GetLeaderboardSize(), statsRange)
 			if len(userMostFirstTimeKilledForServer) > 0 {
 				fields = append(fields, &discordgo.MessageEmbedField{
 					Name:   "\u200b",
@@ -830,7 +880,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 
-			userMostFrequentKilledByServer := bot.PostgresInterface.UserMostFrequentKilledByServer(guildID)
+			userMostFrequentKilledByServer := bot.PostgresInterface.UserMostFrequentKilledByServer(guildID, statsRange)
 			if len(userMostFrequentKilledByServer) > 0 {
 				buf := bytes.NewBuffer([]byte{})
 				for i, v := range userMostFrequentKilledByServer {
@@ -856,6 +906,12 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 				})
 			}
 		}
+
+		roleRankings, err := bot.PostgresInterface.RoleRankingForServer(guildID, statsRange)
+		if err != nil {
+			log.Println(err)
+		}
+		fields = appendRoleRankings(fields, roleRankings, sett)
 	}
 
 	fields = TrimEmbedFields(fields)
@@ -872,7 +928,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 			Other: "Guild stats for {{.GuildName}}",
 		}, map[string]interface{}{
 			"GuildName": gname,
-		}) + "\n\n" + extraDesc,
+		}) + periodDesc(period, sett) + "\n\n" + extraDesc,
 		Timestamp: "",
 		Color:     3066993, // GREEN
 		Image:     nil,
@@ -890,43 +946,321 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 	return &embed
 }
 
-func (bot *Bot) GameStatsEmbed(guildID, matchID, connectCode string, isPrem bool, sett *settings.GuildSettings) *discordgo.MessageEmbed {
-	if !isPrem {
-		return &discordgo.MessageEmbed{
-			URL:   "",
-			Type:  "",
-			Title: "Insufficient Premium",
-			Description: sett.LocalizeMessage(&i18n.Message{
-				ID:    "responses.gameStatsEmbed.NoPremium",
-				Other: "Detailed match stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
-			}),
-			Timestamp: "",
-			Color:     10181046, // PURPLE
-			Footer:    nil,
-			Image:     nil,
-			Thumbnail: nil,
-			Video:     nil,
-			Provider:  nil,
-			Author:    nil,
-			Fields:    nil,
+// LobbyStatsEmbed is how likely the impostors are to win a game between the players linked to the game, going by how
+// they've done on the guild, and which of them (or which pairs of them) do far better or worse than usual
+func (bot *Bot) LobbyStatsEmbed(dgs *GameState, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+	userIDs := make([]string, 0)
+	for _, v := range dgs.UserData {
+		if v.GetPlayerName() != amongus.UnlinkedPlayerName {
+			userIDs = append(userIDs, v.User.UserID)
 		}
 	}
+	sort.Strings(userIDs)
 
-	gameData, err := bot.PostgresInterface.GetGame(guildID, connectCode, matchID)
+	baseline := 0.5
+	gamesPlayed := bot.PostgresInterface.NumGamesPlayedOnGuild(dgs.GuildID, storage.StatsRange{})
+	if gamesPlayed > 0 {
+		imposterWins := bot.PostgresInterface.NumGamesWonAsRoleOnServer(dgs.GuildID, game.ImposterRole, storage.StatsRange{})
+		if imposterWins >= 0 {
+			baseline = float64(imposterWins) / float64(gamesPlayed)
+		}
+	}
+
+	records, err := bot.PostgresInterface.RoleRecordsForPlayersOnServer(dgs.GuildID, userIDs, storage.StatsRange{})
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
+	}
+	players := make(map[string]*rating.LobbyPlayer, len(userIDs))
+	for _, id := range userIDs {
+		players[id] = &rating.LobbyPlayer{ID: id}
 	}
+	for _, v := range records {
+		p, ok := players[strconv.FormatUint(v.UserID, 10)]
+		if !ok {
+			continue
+		}
+		record := rating.Record{Wins: v.WinCount, Games: v.Count}
+		if game.GameRole(v.PlayerRole) == game.ImposterRole {
+			p.Impostor = record
+		} else {
+			p.Crewmate = record
+		}
+	}
+	lobby := make([]rating.LobbyPlayer, 0, len(userIDs))
+	for _, id := range userIDs {
+		lobby = append(lobby, *players[id])
+	}
+	chance := rating.ImpostorWinChance(lobby, baseline)
 
-	var events []*storage.PostgresGameEvent
-	if gameData != nil {
-		events, err = bot.PostgresInterface.GetGameEvents(matchID)
-		if err != nil {
-			log.Fatal(err)
+	verdict := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.lobbyStatsEmbed.Balanced",
+		Other: "Balanced",
+	})
+	if chance-baseline >= rating.LopsidedMargin {
+		verdict = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.FavorsImposters",
+			Other: "⚠️ Favors the imposters",
+		})
+	} else if baseline-chance >= rating.LopsidedMargin {
+		verdict = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.FavorsCrewmates",
+			Other: "⚠️ Favors the crewmates",
+		})
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.lobbyStatsEmbed.ImposterWinChance",
+				Other: "Imposter Win Chance",
+			}),
+			Value: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.lobbyStatsEmbed.ImposterWinChanceValue",
+				Other: "{{.Chance}}% (guild average {{.Baseline}}%)",
+			}, map[string]interface{}{
+				"Chance":   fmt.Sprintf("%.0f", 100*chance),
+				"Baseline": fmt.Sprintf("%.0f", 100*baseline),
+			}),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.lobbyStatsEmbed.Verdict",
+				Other: "Verdict",
+			}),
+			Value:  verdict,
+			Inline: true,
+		},
+		{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: false,
+		},
+	}
+
+	leaderboardMin := int64(sett.GetLeaderboardMin())
+	crewmateOutliers := bytes.NewBuffer([]byte{})
+	imposterOutliers := bytes.NewBuffer([]byte{})
+	for _, p := range lobby {
+		if p.Crewmate.IsOutlier(1-baseline, leaderboardMin) {
+			crewmateOutliers.WriteString(fmt.Sprintf("%d/%d | %.0f%% | %s\n", p.Crewmate.Wins, p.Crewmate.Games,
+				100*float64(p.Crewmate.Wins)/float64(p.Crewmate.Games), bot.MentionWithCacheData(p.ID, dgs.GuildID, sett)))
+		}
+		if p.Impostor.IsOutlier(baseline, leaderboardMin) {
+			imposterOutliers.WriteString(fmt.Sprintf("%d/%d | %.0f%% | %s\n", p.Impostor.Wins, p.Impostor.Games,
+				100*float64(p.Impostor.Wins)/float64(p.Impostor.Games), bot.MentionWithCacheData(p.ID, dgs.GuildID, sett)))
 		}
 	}
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.CrewmateOutliers",
+			Other: "Crewmate Outliers ({{.Min}}+ Games)",
+		}, map[string]interface{}{
+			"Min": leaderboardMin,
+		}),
+		Value:  crewmateOutliers.String(),
+		Inline: true,
+	})
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.ImposterOutliers",
+			Other: "Imposter Outliers ({{.Min}}+ Games)",
+		}, map[string]interface{}{
+			"Min": leaderboardMin,
+		}),
+		Value:  imposterOutliers.String(),
+		Inline: true,
+	})
+
+	extraDesc := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.lobbyStatsEmbed.NoPremium",
+		Other: "How well players do together is only available for AutoMuteUs Premium users; type `/premium` to learn more",
+	})
+	if isPrem {
+		extraDesc = ""
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: false,
+		})
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.lobbyStatsEmbed.CrewmatePairs",
+				Other: "Crewmate Pairs",
+			}),
+			Value:  bot.lobbyPairs(dgs.GuildID, lobby, game.CrewmateRole, 1-baseline, sett.GetLeaderboardMin(), sett),
+			Inline: true,
+		})
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.lobbyStatsEmbed.ImposterPairs",
+				Other: "Imposter Pairs",
+			}),
+			Value:  bot.lobbyPairs(dgs.GuildID, lobby, game.ImposterRole, baseline, 2, sett),
+			Inline: true,
+		})
+	}
+
+	fields = TrimEmbedFields(fields)
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.Title",
+			Other: "Lobby Balance",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.lobbyStatsEmbed.Desc",
+			Other: "Going by how the {{.Players}} linked players have done on this guild",
+		}, map[string]interface{}{
+			"Players": len(userIDs),
+		}) + "\n\n" + extraDesc,
+		Color:  3066993, // GREEN
+		Fields: fields,
+	}
+}
+
+// lobbyPairs lists the pairs of players in the lobby that win far more, or far less, than expected when they're on the
+// same team as the role
+func (bot *Bot) lobbyPairs(guildID string, lobby []rating.LobbyPlayer, role game.GameRole, expected float64, minGames int, sett *settings.GuildSettings) string {
+	inLobby := make(map[uint64]bool, len(lobby))
+	for _, p := range lobby {
+		id, err := strconv.ParseUint(p.ID, 10, 64)
+		if err == nil {
+			inLobby[id] = true
+		}
+	}
+	seen := make(map[[2]uint64]bool)
+	pair := func(userID, teammateID uint64) bool {
+		if !inLobby[teammateID] {
+			return false
+		}
+		key := [2]uint64{userID, teammateID}
+		if teammateID < userID {
+			key = [2]uint64{teammateID, userID}
+		}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
 
-	stats := storage.StatsFromGameAndEvents(gameData, events)
-	return stats.ToDiscordEmbed(connectCode+":"+matchID, sett)
+	buf := bytes.NewBuffer([]byte{})
+	for _, p := range lobby {
+		for _, v := range bot.PostgresInterface.BestTeammateByRole(p.ID, guildID, int16(role), minGames, storage.StatsRange{}) {
+			record := rating.Record{Wins: v.WinCount, Games: v.Count}
+			if v.WinRate/100 > expected && record.IsOutlier(expected, int64(minGames)) && pair(v.UserID, v.TeammateID) {
+				buf.WriteString(fmt.Sprintf("▲ %d/%d | %.0f%% | %s | %s\n", v.WinCount, v.Count, v.WinRate,
+					bot.MentionWithCacheData(strconv.FormatUint(v.UserID, 10), guildID, sett),
+					bot.MentionWithCacheData(strconv.FormatUint(v.TeammateID, 10), guildID, sett)))
+			}
+		}
+		for _, v := range bot.PostgresInterface.WorstTeammateByRole(p.ID, guildID, int16(role), minGames, storage.StatsRange{}) {
+			record := rating.Record{Wins: v.Count - v.LooseCount, Games: v.Count}
+			if v.LooseRate/100 > 1-expected && record.IsOutlier(expected, int64(minGames)) && pair(v.UserID, v.TeammateID) {
+				buf.WriteString(fmt.Sprintf("▼ %d/%d | %.0f%% | %s | %s\n", record.Wins, v.Count, 100-v.LooseRate,
+					bot.MentionWithCacheData(strconv.FormatUint(v.UserID, 10), guildID, sett),
+					bot.MentionWithCacheData(strconv.FormatUint(v.TeammateID, 10), guildID, sett)))
+			}
+		}
+	}
+	return buf.String()
+}
+
+// periodDesc is the line saying which games the stats are for, if they aren't for all time
+func periodDesc(period string, sett *settings.GuildSettings) string {
+	if period == "" {
+		return ""
+	}
+	return "\n" + sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.statsEmbed.Period",
+		Other: "📅 Games played in {{.Period}}",
+	}, map[string]interface{}{
+		"Period": period,
+	})
+}
+
+// ratingFields is a row with the user's crewmate and impostor ratings, or nothing if they were never rated
+func ratingFields(ratings []*storage.PostgresPlayerRating, sett *settings.GuildSettings) []*discordgo.MessageEmbedField {
+	if len(ratings) == 0 {
+		return nil
+	}
+	row := make([]*discordgo.MessageEmbedField, 3)
+	for i := range row {
+		row[i] = &discordgo.MessageEmbedField{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: true,
+		}
+	}
+	for _, v := range ratings {
+		value := fmt.Sprintf("%.0f (%+.0f) | %d %s", v.Rating, v.LastChange, v.Games,
+			sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.stats.Games",
+				Other: "Games",
+			}))
+		switch game.GameRole(v.PlayerRole) {
+		case game.CrewmateRole:
+			row[0] = &discordgo.MessageEmbedField{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.CrewmateRating",
+					Other: "Crewmate Rating",
+				}),
+				Value:  value,
+				Inline: true,
+			}
+		case game.ImposterRole:
+			row[1] = &discordgo.MessageEmbedField{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.ImposterRating",
+					Other: "Imposter Rating",
+				}),
+				Value:  value,
+				Inline: true,
+			}
+		}
+	}
+	return row
+}
+
+func (bot *Bot) ratingLeaderboard(rankings []*storage.PostgresPlayerRating, guildID string, sett *settings.GuildSettings) string {
+	buf := bytes.NewBuffer([]byte{})
+	for i, v := range rankings {
+		buf.WriteString(fmt.Sprintf("%d. %.0f | %s", i+1, v.Rating,
+			bot.MentionWithCacheData(strconv.FormatUint(v.UserID, 10), guildID, sett)))
+		if i < len(rankings)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.String()
+}
+
+// maxEmbedFields is as many fields as Discord allows in one embed
+const maxEmbedFields = 25
+
+// appendRoleRankings adds the win rate of every role played, when there's more to it than crewmate and impostor
+func appendRoleRankings(fields []*discordgo.MessageEmbedField, rankings []*storage.PostgresRoleRanking, sett *settings.GuildSettings) []*discordgo.MessageEmbedField {
+	modded := false
+	for _, v := range rankings {
+		if v.RoleName != game.CrewmateDefault.Name && v.RoleName != game.ImpostorDefault.Name {
+			modded = true
+		}
+	}
+	if !modded || len(fields) >= maxEmbedFields {
+		return fields
+	}
+	buf := bytes.NewBuffer([]byte{})
+	for _, v := range rankings {
+		buf.WriteString(fmt.Sprintf("%s: %d/%d | %.0f%%\n", v.RoleName, v.WinCount, v.Count, v.WinRate))
+	}
+	return append(fields, &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.RoleWinrates",
+			Other: "Winrate by Role",
+		}),
+		Value:  buf.String(),
+		Inline: false,
+	})
 }
 
 func TrimEmbedFields(fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbedField {
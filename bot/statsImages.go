@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"bytes"
+	"github.com/automuteus/automuteus/v8/pkg/render"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"image"
+	"log"
+)
+
+// Stats images are drawn for premium stats, where the embeds run into Discord's field limits. They're attached to the
+// stats response, and shown in the embeds with attachment:// urls (see attachStatsImages)
+
+const (
+	winRateImage = "winrate.png"
+	colorsImage  = "colors.png"
+)
+
+func (bot *Bot) UserStatsImages(userID, guildID string, statsRange storage.StatsRange, isPrem bool) []*discordgo.File {
+	if !isPrem {
+		return nil
+	}
+	var files []*discordgo.File
+	days := bot.PostgresInterface.WinsOverTimeForPlayerOnServer(userID, guildID, statsRange)
+	if len(days) > 0 {
+		files = appendImage(files, winRateImage, render.WinRateChart(winRatePoints(days)))
+	}
+	colors := bot.PostgresInterface.ColorRankingForPlayerOnServer(userID, guildID, statsRange)
+	if len(colors) > 0 {
+		files = appendImage(files, colorsImage, render.ColorPieChart(colorCounts(colors)))
+	}
+	return files
+}
+
+func (bot *Bot) GuildStatsImages(guildID string, statsRange storage.StatsRange, isPrem bool) []*discordgo.File {
+	if !isPrem {
+		return nil
+	}
+	var files []*discordgo.File
+	days := bot.PostgresInterface.CrewmateWinsOverTimeForServer(guildID, statsRange)
+	if len(days) > 0 {
+		files = appendImage(files, winRateImage, render.WinRateChart(winRatePoints(days)))
+	}
+	colors := bot.PostgresInterface.ColorRankingForServer(guildID, statsRange)
+	if len(colors) > 0 {
+		files = appendImage(files, colorsImage, render.ColorPieChart(colorCounts(colors)))
+	}
+	return files
+}
+
+// attachStatsImages shows the first image in the embed, and each of the others in an embed of its own
+func attachStatsImages(embed *discordgo.MessageEmbed, files []*discordgo.File) []*discordgo.MessageEmbed {
+	embeds := []*discordgo.MessageEmbed{embed}
+	for i, file := range files {
+		img := &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name}
+		if i == 0 {
+			embed.Image = img
+		} else {
+			embeds = append(embeds, &discordgo.MessageEmbed{Color: embed.Color, Image: img})
+		}
+	}
+	return embeds
+}
+
+func appendImage(files []*discordgo.File, name string, img image.Image) []*discordgo.File {
+	b, err := render.EncodePNG(img)
+	if err != nil {
+		log.Println(err)
+		return files
+	}
+	return append(files, &discordgo.File{
+		Name:        name,
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(b),
+	})
+}
+
+func winRatePoints(days []*storage.PostgresWinsOnDay) []render.WinRatePoint {
+	points := make([]render.WinRatePoint, len(days))
+	for i, day := range days {
+		points[i] = render.WinRatePoint{Day: day.Day, Wins: int(day.WinCount), Games: int(day.Count)}
+	}
+	return points
+}
+
+func colorCounts(colors []*storage.Int16ModeCount) []render.ColorCount {
+	counts := make([]render.ColorCount, len(colors))
+	for i, c := range colors {
+		counts[i] = render.ColorCount{Color: int(c.Mode), Count: c.Count}
+	}
+	return counts
+}
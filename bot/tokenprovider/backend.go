@@ -0,0 +1,241 @@
+package tokenprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/premium"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"log"
+	"strings"
+	"sync"
+)
+
+type BackendKind string
+
+const (
+	// WorkerBackend issues requests using the secondary (premium) bot tokens
+	WorkerBackend BackendKind = "worker"
+	// CaptureBackend asks the capture client to issue the request, and waits for it to ack
+	CaptureBackend BackendKind = "capture"
+	// OfficialBackend issues requests using the primary bot session
+	OfficialBackend BackendKind = "official"
+)
+
+// ErrBackendUnavailable is returned by a VoiceBackend that can't handle a request right now (no tokens, rate-limited,
+// unsupported action...), meaning the next backend should be tried
+var ErrBackendUnavailable = errors.New("voice backend unavailable")
+
+// VoiceBackend is a way of muting/deafening and moving users in a guild
+type VoiceBackend interface {
+	Kind() BackendKind
+	ApplyMuteDeaf(mctx *ModifyContext, request task.UserModify) error
+	ApplyMove(mctx *ModifyContext, request task.UserModify) error
+	// RateLimited reports if the backend is known to be rate-limited (or blacklisted) for the guild, without using
+	// any of its budget
+	RateLimited(mctx *ModifyContext) bool
+}
+
+// ModifyContext holds the state of a single ModifyUsers call, shared by all the backends that take part in it
+type ModifyContext struct {
+	GuildID     string
+	GID         uint64
+	ConnectCode string
+	// Limit is the number of secondary tokens the guild's premium tier is allowed to use
+	Limit int
+	// Plan is the secondary token each user was assigned to ahead of time, if any
+	Plan ModifyPlan
+
+	tokenLock        sync.RWMutex
+	uniqueTokensUsed map[string]struct{}
+}
+
+func newModifyContext(guildID string, gid uint64, connectCode string, limit int) *ModifyContext {
+	return &ModifyContext{
+		GuildID:          guildID,
+		GID:              gid,
+		ConnectCode:      connectCode,
+		Limit:            limit,
+		uniqueTokensUsed: make(map[string]struct{}),
+	}
+}
+
+// tokenSubset returns the tokens a request is restricted to, or nil if any token may be used.
+// Once the guild has used as many tokens as its tier allows, it sticks to those
+func (mctx *ModifyContext) tokenSubset() map[string]struct{} {
+	mctx.tokenLock.RLock()
+	defer mctx.tokenLock.RUnlock()
+	if len(mctx.uniqueTokensUsed) < mctx.Limit {
+		return nil
+	}
+	subset := make(map[string]struct{}, len(mctx.uniqueTokensUsed))
+	for k := range mctx.uniqueTokensUsed {
+		subset[k] = struct{}{}
+	}
+	return subset
+}
+
+func (mctx *ModifyContext) markTokenUsed(hToken string) {
+	mctx.tokenLock.Lock()
+	mctx.uniqueTokensUsed[hToken] = struct{}{}
+	mctx.tokenLock.Unlock()
+}
+
+// TokensUsed returns the hashed secondary tokens used successfully during the call
+func (mctx *ModifyContext) TokensUsed() map[string]struct{} {
+	mctx.tokenLock.RLock()
+	defer mctx.tokenLock.RUnlock()
+	used := make(map[string]struct{}, len(mctx.uniqueTokensUsed))
+	for k := range mctx.uniqueTokensUsed {
+		used[k] = struct{}{}
+	}
+	return used
+}
+
+// DefaultBackendOrder is the order backends are tried in, for tiers without an order of their own
+var DefaultBackendOrder = []BackendKind{WorkerBackend, CaptureBackend, OfficialBackend}
+
+// ParseBackendOrder reads per-tier backend orders of the form "free=capture,official;gold=worker,official".
+// Tiers are matched case-insensitively against premium.TierStrings; "default" sets the order for every other tier
+func ParseBackendOrder(str string) (map[premium.Tier][]BackendKind, []BackendKind, error) {
+	orders := make(map[premium.Tier][]BackendKind)
+	var def []BackendKind
+	for _, entry := range strings.Split(strings.ReplaceAll(str, " ", ""), ";") {
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("backend order \"%s\" should look like tier=backend,backend", entry)
+		}
+		var kinds []BackendKind
+		for _, k := range strings.Split(parts[1], ",") {
+			kind := BackendKind(strings.ToLower(k))
+			if kind != WorkerBackend && kind != CaptureBackend && kind != OfficialBackend {
+				return nil, nil, fmt.Errorf("unknown voice backend \"%s\"", k)
+			}
+			kinds = append(kinds, kind)
+		}
+		if strings.EqualFold(parts[0], "default") {
+			def = kinds
+			continue
+		}
+		found := false
+		for i, name := range premium.TierStrings {
+			if strings.EqualFold(parts[0], name) {
+				orders[premium.Tier(i)] = kinds
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("unknown premium tier \"%s\"", parts[0])
+		}
+	}
+	return orders, def, nil
+}
+
+// workerBackend mutes/deafens using any of the secondary tokens the guild's tier has access to
+type workerBackend struct {
+	tp *TokenProvider
+}
+
+func (wb *workerBackend) Kind() BackendKind {
+	return WorkerBackend
+}
+
+func (wb *workerBackend) ApplyMuteDeaf(mctx *ModifyContext, request task.UserModify) error {
+	if mctx.Limit < 1 {
+		return ErrBackendUnavailable
+	}
+	if hToken, ok := mctx.Plan.TokenFor(request.UserID); ok && wb.tp.attemptOnPlannedToken(mctx.GuildID, hToken, request) {
+		mctx.markTokenUsed(hToken)
+		return nil
+	}
+	hToken := wb.tp.attemptOnSecondaryTokens(mctx.GuildID, mctx.tokenSubset(), request)
+	if hToken == "" {
+		return ErrBackendUnavailable
+	}
+	mctx.markTokenUsed(hToken)
+	return nil
+}
+
+func (wb *workerBackend) ApplyMove(mctx *ModifyContext, request task.UserModify) error {
+	return wb.ApplyMuteDeaf(mctx, request)
+}
+
+func (wb *workerBackend) RateLimited(mctx *ModifyContext) bool {
+	if mctx.Limit < 1 {
+		return true
+	}
+	wb.tp.sessionLock.RLock()
+	defer wb.tp.sessionLock.RUnlock()
+	for hToken := range wb.tp.activeSessions {
+		if !wb.tp.isRateLimited(mctx.GuildID, hToken) {
+			return false
+		}
+	}
+	return true
+}
+
+// captureBackend has the capture client mute/deafen on the bot's behalf
+type captureBackend struct {
+	tp *TokenProvider
+}
+
+func (cb *captureBackend) Kind() BackendKind {
+	return CaptureBackend
+}
+
+func (cb *captureBackend) ApplyMuteDeaf(mctx *ModifyContext, request task.UserModify) error {
+	if !cb.tp.attemptOnCaptureBot(mctx.GuildID, mctx.ConnectCode, mctx.GID, request) {
+		return ErrBackendUnavailable
+	}
+	return nil
+}
+
+// ApplyMove is never supported; the capture client only knows how to mute/deafen
+func (cb *captureBackend) ApplyMove(_ *ModifyContext, _ task.UserModify) error {
+	return ErrBackendUnavailable
+}
+
+func (cb *captureBackend) RateLimited(mctx *ModifyContext) bool {
+	return mctx.ConnectCode == "" || cb.tp.isRateLimited(mctx.GuildID, mctx.ConnectCode)
+}
+
+// officialBackend uses the primary bot session. It's the last resort, so it reports errors instead of deferring
+type officialBackend struct {
+	tp *TokenProvider
+}
+
+func (ob *officialBackend) Kind() BackendKind {
+	return OfficialBackend
+}
+
+func (ob *officialBackend) ApplyMuteDeaf(mctx *ModifyContext, request task.UserModify) error {
+	if ob.tp.primarySession == nil {
+		return ErrBackendUnavailable
+	}
+	log.Printf("Applying mute=%v, deaf=%v, channel=%d using primary bot\n", request.Mute, request.Deaf, request.ChannelID)
+	return task.ApplyUserModify(ob.tp.primarySession, mctx.GuildID, request)
+}
+
+func (ob *officialBackend) ApplyMove(mctx *ModifyContext, request task.UserModify) error {
+	return ob.ApplyMuteDeaf(mctx, request)
+}
+
+// RateLimited is always false; discordgo queues requests on the primary session until the rate-limit resets
+func (ob *officialBackend) RateLimited(_ *ModifyContext) bool {
+	return false
+}
+
+// isRateLimited checks a guild/token combo's request counter without incrementing it
+func (tokenProvider *TokenProvider) isRateLimited(guildID, hashToken string) bool {
+	i, err := tokenProvider.client.Get(context.Background(), rediskey.GuildTokenLock(guildID, hashToken)).Int64()
+	if err != nil {
+		// a missing key means the token hasn't been used recently
+		return false
+	}
+	return i >= tokenProvider.maxRequests5Seconds
+}
@@ -0,0 +1,58 @@
+package tokenprovider
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"sync"
+	"time"
+)
+
+// FakeBackend is an in-memory VoiceBackend that records every request it's given, for testing the mute pipeline
+// without Discord
+type FakeBackend struct {
+	kind BackendKind
+
+	lock     sync.Mutex
+	requests []task.UserModify
+	// Err, if set, is returned for every request (use ErrBackendUnavailable to fall through to the next backend)
+	Err error
+	// Limited is reported as the backend's rate-limit state
+	Limited bool
+	// Delays is how long requests for a user take to apply
+	Delays map[uint64]time.Duration
+}
+
+func NewFakeBackend(kind BackendKind) *FakeBackend {
+	return &FakeBackend{kind: kind}
+}
+
+func (fb *FakeBackend) Kind() BackendKind {
+	return fb.kind
+}
+
+func (fb *FakeBackend) ApplyMuteDeaf(_ *ModifyContext, request task.UserModify) error {
+	time.Sleep(fb.Delays[request.UserID])
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	if fb.Err != nil {
+		return fb.Err
+	}
+	fb.requests = append(fb.requests, request)
+	return nil
+}
+
+func (fb *FakeBackend) ApplyMove(mctx *ModifyContext, request task.UserModify) error {
+	return fb.ApplyMuteDeaf(mctx, request)
+}
+
+func (fb *FakeBackend) RateLimited(_ *ModifyContext) bool {
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	return fb.Limited
+}
+
+// Requests returns the requests successfully applied so far, in the order they were received
+func (fb *FakeBackend) Requests() []task.UserModify {
+	fb.lock.Lock()
+	defer fb.lock.Unlock()
+	return append([]task.UserModify{}, fb.requests...)
+}
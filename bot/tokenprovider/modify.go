@@ -6,6 +6,7 @@ import (
 	"github.com/automuteus/automuteus/v8/internal/server"
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
 	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/bwmarrin/discordgo"
 	"github.com/go-redis/redis/v8"
 	"log"
 )
@@ -17,22 +18,11 @@ func RecordDiscordRequestsByCounts(client *redis.Client, counts task.MuteDeafenS
 	server.RecordDiscordRequests(client, server.InvalidRequest, counts.RateLimit)
 }
 
-func (tokenProvider *TokenProvider) attemptOnSecondaryTokens(guildID, userID string, tokenSubset map[string]struct{}, request task.UserModify) string {
+func (tokenProvider *TokenProvider) attemptOnSecondaryTokens(guildID string, tokenSubset map[string]struct{}, request task.UserModify) string {
 	if len(tokenProvider.activeSessions) > 0 {
 		sess, hToken := tokenProvider.getSession(guildID, tokenSubset)
 		if sess != nil {
-			err := task.ApplyMuteDeaf(sess, guildID, userID, request.Mute, request.Deaf)
-			if err != nil {
-				log.Println("Failed to apply mute to player with error:")
-				log.Println(err)
-
-				// don't attempt this token for this guild for another 5 minutes
-				err = tokenProvider.BlacklistTokenForDuration(guildID, hToken, UnresponsiveCaptureBlacklistDuration)
-				if err != nil {
-					log.Println(err)
-				}
-			} else {
-				log.Printf("Successfully applied mute=%v, deaf=%v to User %d using secondary bot: %s\n", request.Mute, request.Deaf, request.UserID, hToken)
+			if tokenProvider.applyOnSession(sess, guildID, hToken, request) {
 				return hToken
 			}
 		} else {
@@ -44,6 +34,39 @@ func (tokenProvider *TokenProvider) attemptOnSecondaryTokens(guildID, userID str
 	return ""
 }
 
+// attemptOnPlannedToken uses the secondary token a user was assigned to by PlanModify
+func (tokenProvider *TokenProvider) attemptOnPlannedToken(guildID, hToken string, request task.UserModify) bool {
+	tokenProvider.sessionLock.RLock()
+	sess, ok := tokenProvider.activeSessions[hToken]
+	tokenProvider.sessionLock.RUnlock()
+	if !ok {
+		return false
+	}
+	// still count the request, so other nodes (and the next plan) see the token's real usage
+	if !tokenProvider.IncrAndTestGuildTokenComboLock(guildID, hToken) {
+		log.Println("Planned secondary token is rate-limited after all. Trying other tokens")
+		return false
+	}
+	return tokenProvider.applyOnSession(sess, guildID, hToken, request)
+}
+
+func (tokenProvider *TokenProvider) applyOnSession(sess *discordgo.Session, guildID, hToken string, request task.UserModify) bool {
+	err := task.ApplyUserModify(sess, guildID, request)
+	if err != nil {
+		log.Println("Failed to apply mute to player with error:")
+		log.Println(err)
+
+		// don't attempt this token for this guild for another 5 minutes
+		err = tokenProvider.BlacklistTokenForDuration(guildID, hToken, UnresponsiveCaptureBlacklistDuration)
+		if err != nil {
+			log.Println(err)
+		}
+		return false
+	}
+	log.Printf("Successfully applied mute=%v, deaf=%v, channel=%d to User %d using secondary bot: %s\n", request.Mute, request.Deaf, request.ChannelID, request.UserID, hToken)
+	return true
+}
+
 func (tokenProvider *TokenProvider) attemptOnCaptureBot(guildID, connectCode string, gid uint64, request task.UserModify) bool {
 	// this is cheeky, but use the connect code as part of the lock; don't issue too many requests on the capture client w/ this code
 	if tokenProvider.IncrAndTestGuildTokenComboLock(guildID, connectCode) {
@@ -0,0 +1,124 @@
+package tokenprovider
+
+import (
+	"context"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/bwmarrin/discordgo"
+	"log"
+	"math"
+	"sort"
+)
+
+// ModifyPlan is the secondary token each user of a UserModifyRequest was assigned to, before any request is issued.
+// Users that don't fit in any token's budget are left unassigned, and go through the remaining backends as usual
+type ModifyPlan struct {
+	Assigned   map[uint64]string
+	Unassigned []task.UserModify
+}
+
+// TokenFor returns the hashed token assigned to a user, if any
+func (plan ModifyPlan) TokenFor(userID uint64) (string, bool) {
+	if plan.Assigned == nil {
+		return "", false
+	}
+	hToken, ok := plan.Assigned[userID]
+	return hToken, ok
+}
+
+// PlanModify assigns every user in the request to the secondary token with the most remaining budget in the guild.
+// Users are assigned in the order of the request, so priority users at the front (see handleTrackedMembers) get the
+// freshest tokens. At most limit distinct tokens are used, per the guild's premium tier
+func (tokenProvider *TokenProvider) PlanModify(guildID string, users []task.UserModify, limit int) ModifyPlan {
+	if limit < 1 {
+		return ModifyPlan{Unassigned: users}
+	}
+	return planAssignments(tokenProvider.tokenBudgets(guildID), limit, users)
+}
+
+// tokenBudgets estimates how many more member edits every secondary token can issue in the guild before it is
+// rate-limited, using both our own request counters and the session's Discord rate-limit bucket
+func (tokenProvider *TokenProvider) tokenBudgets(guildID string) map[string]int {
+	tokenProvider.sessionLock.RLock()
+	defer tokenProvider.sessionLock.RUnlock()
+
+	budgets := make(map[string]int, len(tokenProvider.activeSessions))
+	for hToken, sess := range tokenProvider.activeSessions {
+		budget := int(tokenProvider.maxRequests5Seconds)
+		used, err := tokenProvider.client.Get(context.Background(), rediskey.GuildTokenLock(guildID, hToken)).Int64()
+		if err == nil {
+			budget -= int(used)
+		}
+		if remaining, known := bucketRemaining(sess, guildID); known && remaining < budget {
+			budget = remaining
+		}
+		if budget < 0 {
+			budget = 0
+		}
+		log.Printf("Token %s has a budget of %d requests on guild %s", hToken, budget, guildID)
+		budgets[hToken] = budget
+	}
+	return budgets
+}
+
+// bucketRemaining reads how many requests Discord said are left in a session's member-edit bucket for a guild.
+// known is false when the bucket has reset (or was never used), because then the real limit is unknown to us
+func bucketRemaining(sess *discordgo.Session, guildID string) (remaining int, known bool) {
+	if sess == nil || sess.Ratelimiter == nil {
+		return 0, false
+	}
+	bucket := sess.Ratelimiter.GetBucket(discordgo.EndpointGuildMember(guildID, ""))
+	if !bucket.TryLock() {
+		// a request is in flight on this bucket right now; be conservative
+		return 1, true
+	}
+	defer bucket.Unlock()
+	// a wait time when asking for an impossible amount of requests means the bucket hasn't reset yet
+	if sess.Ratelimiter.GetWaitTime(bucket, math.MaxInt32) == 0 {
+		return 0, false
+	}
+	return bucket.Remaining, true
+}
+
+func planAssignments(budgets map[string]int, limit int, users []task.UserModify) ModifyPlan {
+	plan := ModifyPlan{
+		Assigned: make(map[uint64]string, len(users)),
+	}
+
+	tokens := make([]string, 0, len(budgets))
+	for hToken, budget := range budgets {
+		if budget > 0 {
+			tokens = append(tokens, hToken)
+		}
+	}
+	// the tokens with the most budget first, by name otherwise so plans are deterministic
+	sort.Slice(tokens, func(i, j int) bool {
+		if budgets[tokens[i]] != budgets[tokens[j]] {
+			return budgets[tokens[i]] > budgets[tokens[j]]
+		}
+		return tokens[i] < tokens[j]
+	})
+	if len(tokens) > limit {
+		tokens = tokens[:limit]
+	}
+
+	remaining := make(map[string]int, len(tokens))
+	for _, hToken := range tokens {
+		remaining[hToken] = budgets[hToken]
+	}
+	for _, user := range users {
+		best := ""
+		for _, hToken := range tokens {
+			if remaining[hToken] > 0 && (best == "" || remaining[hToken] > remaining[best]) {
+				best = hToken
+			}
+		}
+		if best == "" {
+			plan.Unassigned = append(plan.Unassigned, user)
+			continue
+		}
+		remaining[best]--
+		plan.Assigned[user.UserID] = best
+	}
+	return plan
+}
@@ -0,0 +1,82 @@
+package tokenprovider
+
+import (
+	"context"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/bwmarrin/discordgo"
+	"testing"
+)
+
+func testUsers(n int) []task.UserModify {
+	users := make([]task.UserModify, n)
+	for i := range users {
+		users[i] = task.UserModify{UserID: uint64(i + 1), Mute: true}
+	}
+	return users
+}
+
+func TestPlanAssignments(t *testing.T) {
+	budgets := map[string]int{"a": 4, "b": 2, "c": 0}
+	plan := planAssignments(budgets, 3, testUsers(10))
+
+	counts := map[string]int{}
+	for _, hToken := range plan.Assigned {
+		counts[hToken]++
+	}
+	if counts["a"] != 4 || counts["b"] != 2 || counts["c"] != 0 {
+		t.Errorf("expected tokens to be filled up to their budgets, got %v", counts)
+	}
+	if len(plan.Unassigned) != 4 {
+		t.Errorf("expected 4 users left over for the other backends, got %d", len(plan.Unassigned))
+	}
+	// the first user is the highest priority, so gets the token with the most budget
+	if hToken, _ := plan.TokenFor(1); hToken != "a" {
+		t.Errorf("expected the first user on token a, got %s", hToken)
+	}
+	// users are spread over the tokens, not piled onto the first one
+	if hToken, _ := plan.TokenFor(4); hToken != "b" {
+		t.Errorf("expected the fourth user on token b, got %s", hToken)
+	}
+}
+
+func TestPlanAssignmentsLimit(t *testing.T) {
+	budgets := map[string]int{"a": 5, "b": 5, "c": 5}
+	plan := planAssignments(budgets, 1, testUsers(6))
+
+	for _, hToken := range plan.Assigned {
+		if hToken != "a" {
+			t.Errorf("a tier limited to one token should only use one token, used %s", hToken)
+		}
+	}
+	if len(plan.Assigned) != 5 || len(plan.Unassigned) != 1 {
+		t.Errorf("expected 5 assigned and 1 unassigned, got %d and %d", len(plan.Assigned), len(plan.Unassigned))
+	}
+}
+
+func TestPlanModify(t *testing.T) {
+	tp, _ := newTestProvider(t)
+	for _, hToken := range []string{"a", "b"} {
+		sess, err := discordgo.New("Bot " + hToken)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tp.activeSessions[hToken] = sess
+	}
+	// token a was used a lot recently by another game in the guild
+	tp.client.Set(context.Background(), rediskey.GuildTokenLock(testGuildID, "a"), 6, 0)
+
+	plan := tp.PlanModify(testGuildID, testUsers(8), 3)
+	counts := map[string]int{}
+	for _, hToken := range plan.Assigned {
+		counts[hToken]++
+	}
+	if counts["a"] != 1 || counts["b"] != 7 {
+		t.Errorf("expected the plan to respect the recorded usage, got %v", counts)
+	}
+
+	plan = tp.PlanModify(testGuildID, testUsers(8), 0)
+	if len(plan.Assigned) != 0 || len(plan.Unassigned) != 8 {
+		t.Error("tiers without worker bots should never plan on secondary tokens")
+	}
+}
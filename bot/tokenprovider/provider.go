@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"github.com/automuteus/automuteus/v8/pkg/premium"
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
 	"github.com/automuteus/automuteus/v8/pkg/task"
@@ -36,17 +37,67 @@ type TokenProvider struct {
 	maxRequests5Seconds int64
 	sessionLock         sync.RWMutex
 	taskTimeoutMs       time.Duration
+
+	backendLock  sync.RWMutex
+	backends     map[BackendKind]VoiceBackend
+	tierOrders   map[premium.Tier][]BackendKind
+	defaultOrder []BackendKind
 }
 
 func NewTokenProvider(client *redis.Client, sess *discordgo.Session, taskTimeout time.Duration, maxReq int64) *TokenProvider {
-	return &TokenProvider{
+	tp := &TokenProvider{
 		client:              client,
 		primarySession:      sess,
 		activeSessions:      make(map[string]*discordgo.Session),
 		maxRequests5Seconds: maxReq,
 		sessionLock:         sync.RWMutex{},
 		taskTimeoutMs:       taskTimeout,
+		tierOrders:          make(map[premium.Tier][]BackendKind),
+		defaultOrder:        DefaultBackendOrder,
+	}
+	tp.backends = map[BackendKind]VoiceBackend{
+		WorkerBackend:   &workerBackend{tp: tp},
+		CaptureBackend:  &captureBackend{tp: tp},
+		OfficialBackend: &officialBackend{tp: tp},
+	}
+	return tp
+}
+
+// SetBackend adds a backend, or replaces the existing one of the same kind
+func (tokenProvider *TokenProvider) SetBackend(backend VoiceBackend) {
+	tokenProvider.backendLock.Lock()
+	defer tokenProvider.backendLock.Unlock()
+	tokenProvider.backends[backend.Kind()] = backend
+}
+
+// SetBackendOrder sets the order backends are tried in for a premium tier
+func (tokenProvider *TokenProvider) SetBackendOrder(tier premium.Tier, order ...BackendKind) {
+	tokenProvider.backendLock.Lock()
+	defer tokenProvider.backendLock.Unlock()
+	tokenProvider.tierOrders[tier] = order
+}
+
+// SetDefaultBackendOrder sets the order backends are tried in for tiers without an order of their own
+func (tokenProvider *TokenProvider) SetDefaultBackendOrder(order ...BackendKind) {
+	tokenProvider.backendLock.Lock()
+	defer tokenProvider.backendLock.Unlock()
+	tokenProvider.defaultOrder = order
+}
+
+func (tokenProvider *TokenProvider) backendsForTier(tier premium.Tier) []VoiceBackend {
+	tokenProvider.backendLock.RLock()
+	defer tokenProvider.backendLock.RUnlock()
+	order, ok := tokenProvider.tierOrders[tier]
+	if !ok {
+		order = tokenProvider.defaultOrder
+	}
+	backends := make([]VoiceBackend, 0, len(order))
+	for _, kind := range order {
+		if b, ok := tokenProvider.backends[kind]; ok {
+			backends = append(backends, b)
+		}
 	}
+	return backends
 }
 
 func (tp *TokenProvider) Init(client *redis.Client, sess *discordgo.Session) {
@@ -162,6 +213,16 @@ func (tokenProvider *TokenProvider) ModifyUsers(guildID, connectCode string, req
 		return gerr
 	}
 	limit := PremiumBotConstraints[request.Premium]
+	mctx := newModifyContext(guildID, gid, connectCode, limit)
+	backends := tokenProvider.backendsForTier(request.Premium)
+	for _, backend := range backends {
+		if backend.Kind() == WorkerBackend {
+			// spread the users over the worker tokens up front, instead of finding out about rate-limits as we go
+			mctx.Plan = tokenProvider.PlanModify(guildID, request.Users, limit)
+			log.Printf("Planned %d of %d mutes/deafens on secondary tokens for guild %s\n", len(mctx.Plan.Assigned), len(request.Users), guildID)
+			break
+		}
+	}
 
 	tasksChannel := make(chan task.UserModify, len(request.Users))
 	wg := sync.WaitGroup{}
@@ -172,78 +233,79 @@ func (tokenProvider *TokenProvider) ModifyUsers(guildID, connectCode string, req
 		Official:  0,
 		RateLimit: 0,
 	}
-	uniqueTokensUsed := make(map[string]struct{})
 	lock := sync.Mutex{}
-	tokenLock := sync.RWMutex{}
 
 	var latestErr error
 	// start a handful of workers to handle the tasks
 	for i := 0; i < DefaultMaxWorkers; i++ {
 		go func() {
 			for req := range tasksChannel {
-				userIDStr := strconv.FormatUint(req.UserID, 10)
-				hToken := ""
-				if limit > 0 {
-					tokenLock.RLock()
-					if len(uniqueTokensUsed) >= limit {
-						hToken = tokenProvider.attemptOnSecondaryTokens(guildID, userIDStr, uniqueTokensUsed, req)
-						tokenLock.RUnlock()
-					} else {
-						tokenLock.RUnlock()
-						hToken = tokenProvider.attemptOnSecondaryTokens(guildID, userIDStr, nil, req)
-					}
-				}
-				if hToken != "" {
-					lock.Lock()
+				kind, err := applyWithBackends(backends, mctx, req)
+				lock.Lock()
+				switch {
+				case err != nil:
+					latestErr = err
+				case kind == WorkerBackend:
 					mdsc.Worker++
-					lock.Unlock()
-
-					tokenLock.Lock()
-					uniqueTokensUsed[hToken] = struct{}{}
-					tokenLock.Unlock()
-				} else {
-					success := tokenProvider.attemptOnCaptureBot(guildID, connectCode, gid, req)
-					if success {
-						lock.Lock()
-						mdsc.Capture++
-						lock.Unlock()
-					} else {
-						log.Printf("Applying mute=%v, deaf=%v using primary bot\n", req.Mute, req.Deaf)
-						err := task.ApplyMuteDeaf(tokenProvider.primarySession, guildID, userIDStr, req.Mute, req.Deaf)
-						if err != nil {
-							lock.Lock()
-							latestErr = err
-							lock.Unlock()
-							log.Println("Error on primary bot:")
-							log.Println(err)
-						} else {
-							lock.Lock()
-							mdsc.Official++
-							lock.Unlock()
-						}
-					}
+				case kind == CaptureBackend:
+					mdsc.Capture++
+				case kind == OfficialBackend:
+					mdsc.Official++
 				}
+				lock.Unlock()
 				wg.Done()
 			}
 		}()
 	}
 
-	for _, modifyReq := range request.Users {
-		wg.Add(1)
-		tasksChannel <- modifyReq
+	// the priority users go first, and the rest wait for them, so they aren't racing for the same workers and tokens
+	priority := request.Priority
+	if priority < 0 || priority > len(request.Users) {
+		priority = 0
+	}
+	for _, batch := range [][]task.UserModify{request.Users[:priority], request.Users[priority:]} {
+		for _, modifyReq := range batch {
+			wg.Add(1)
+			tasksChannel <- modifyReq
+		}
+		wg.Wait()
 	}
-	wg.Wait()
 	close(tasksChannel)
 
 	RecordDiscordRequestsByCounts(tokenProvider.client, mdsc)
 
 	// note, this should probably be more systematic on startup, not when a mute/deafen task comes in. But this is a
 	// context in which we already have the guildID, successful tokens, AND the premium limit...
-	go tokenProvider.verifyBotMembership(guildID, limit, uniqueTokensUsed)
+	go tokenProvider.verifyBotMembership(guildID, limit, mctx.TokensUsed())
 
 	return latestErr
 }
 
+// applyWithBackends tries each backend in order until one of them applies the request.
+// Returns the kind of backend that succeeded, or the last error if none did
+func applyWithBackends(backends []VoiceBackend, mctx *ModifyContext, req task.UserModify) (BackendKind, error) {
+	err := ErrBackendUnavailable
+	for _, backend := range backends {
+		if backend.RateLimited(mctx) {
+			log.Printf("Voice backend %s is rate-limited on guild %s. Skipping\n", backend.Kind(), mctx.GuildID)
+			continue
+		}
+		if req.IsMove() {
+			err = backend.ApplyMove(mctx, req)
+		} else {
+			err = backend.ApplyMuteDeaf(mctx, req)
+		}
+		if err == nil {
+			return backend.Kind(), nil
+		}
+		if !errors.Is(err, ErrBackendUnavailable) {
+			log.Printf("Error applying mute=%v, deaf=%v, channel=%d with voice backend %s:\n", req.Mute, req.Deaf, req.ChannelID, backend.Kind())
+			log.Println(err)
+		}
+	}
+	return "", err
+}
+
 func (tokenProvider *TokenProvider) rateLimitEventCallback(sess *discordgo.Session, rl *discordgo.RateLimit) {
 	log.Println(rl.Message)
 }
@@ -0,0 +1,150 @@
+package tokenprovider
+
+import (
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/pkg/premium"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+const testGuildID = "754465589958803548"
+
+func newTestProvider(t *testing.T) (*TokenProvider, map[BackendKind]*FakeBackend) {
+	mr := miniredis.RunT(t)
+	tp := NewTokenProvider(redis.NewClient(&redis.Options{Addr: mr.Addr()}), nil, time.Millisecond, 7)
+	fakes := map[BackendKind]*FakeBackend{}
+	for _, kind := range DefaultBackendOrder {
+		fakes[kind] = NewFakeBackend(kind)
+		tp.SetBackend(fakes[kind])
+	}
+	return tp, fakes
+}
+
+func testRequest(tier premium.Tier, users ...task.UserModify) task.UserModifyRequest {
+	return task.UserModifyRequest{
+		Premium: tier,
+		Users:   users,
+	}
+}
+
+func TestModifyUsersFallsThrough(t *testing.T) {
+	tp, fakes := newTestProvider(t)
+	fakes[WorkerBackend].Err = ErrBackendUnavailable
+	fakes[CaptureBackend].Limited = true
+
+	err := tp.ModifyUsers(testGuildID, "ABCDEF", testRequest(premium.GoldTier,
+		task.UserModify{UserID: 1, Mute: true, Deaf: true},
+		task.UserModify{UserID: 2, Mute: true, Deaf: false},
+	), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fakes[WorkerBackend].Requests()) != 0 || len(fakes[CaptureBackend].Requests()) != 0 {
+		t.Error("unavailable and rate-limited backends should not have applied anything")
+	}
+	if len(fakes[OfficialBackend].Requests()) != 2 {
+		t.Errorf("expected both requests to fall through to the official backend, got %v", fakes[OfficialBackend].Requests())
+	}
+}
+
+func TestModifyUsersReportsErrors(t *testing.T) {
+	tp, fakes := newTestProvider(t)
+	fakes[WorkerBackend].Err = ErrBackendUnavailable
+	fakes[CaptureBackend].Err = ErrBackendUnavailable
+	broken := errors.New("missing permissions")
+	fakes[OfficialBackend].Err = broken
+
+	err := tp.ModifyUsers(testGuildID, "ABCDEF", testRequest(premium.FreeTier, task.UserModify{UserID: 1, Mute: true}), nil)
+	if !errors.Is(err, broken) {
+		t.Errorf("expected the last backend's error, got %v", err)
+	}
+}
+
+func TestModifyUsersTierOrder(t *testing.T) {
+	tp, fakes := newTestProvider(t)
+	tp.SetBackendOrder(premium.FreeTier, CaptureBackend)
+
+	_ = tp.ModifyUsers(testGuildID, "ABCDEF", testRequest(premium.FreeTier, task.UserModify{UserID: 1, Mute: true}), nil)
+	if len(fakes[CaptureBackend].Requests()) != 1 || len(fakes[WorkerBackend].Requests()) != 0 {
+		t.Error("free tier should only have used the capture backend")
+	}
+
+	_ = tp.ModifyUsers(testGuildID, "ABCDEF", testRequest(premium.GoldTier, task.UserModify{UserID: 1, Mute: true}), nil)
+	if len(fakes[WorkerBackend].Requests()) != 1 {
+		t.Error("gold tier should still use the default order, starting with worker bots")
+	}
+}
+
+func TestModifyUsersMoveSkipsCapture(t *testing.T) {
+	tp, fakes := newTestProvider(t)
+	// the real capture backend, which can't move users
+	tp.SetBackend(&captureBackend{tp: tp})
+	tp.SetBackendOrder(premium.FreeTier, CaptureBackend, OfficialBackend)
+
+	err := tp.ModifyUsers(testGuildID, "ABCDEF", testRequest(premium.FreeTier, task.UserModify{UserID: 1, ChannelID: 2}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reqs := fakes[OfficialBackend].Requests()
+	if len(reqs) != 1 || reqs[0].ChannelID != 2 {
+		t.Errorf("expected the move to be applied by the official backend, got %v", reqs)
+	}
+}
+
+func TestParseBackendOrder(t *testing.T) {
+	orders, def, err := ParseBackendOrder("free=capture,official; Gold=worker,official;default=official")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders[premium.FreeTier]) != 2 || orders[premium.FreeTier][0] != CaptureBackend {
+		t.Errorf("unexpected free tier order %v", orders[premium.FreeTier])
+	}
+	if len(orders[premium.GoldTier]) != 2 || orders[premium.GoldTier][0] != WorkerBackend {
+		t.Errorf("unexpected gold tier order %v", orders[premium.GoldTier])
+	}
+	if len(def) != 1 || def[0] != OfficialBackend {
+		t.Errorf("unexpected default order %v", def)
+	}
+
+	for _, invalid := range []string{"free", "free=carrier-pigeon", "diamond=official"} {
+		if _, _, err := ParseBackendOrder(invalid); err == nil {
+			t.Errorf("expected an error parsing \"%s\"", invalid)
+		}
+	}
+}
+
+func TestModifyUsersPriority(t *testing.T) {
+	tp, fakes := newTestProvider(t)
+	fakes[WorkerBackend].Err = ErrBackendUnavailable
+	fakes[CaptureBackend].Err = ErrBackendUnavailable
+
+	const priority = 3
+	// the priority users are slow to apply, so the rest would overtake them if they were started at the same time
+	fakes[OfficialBackend].Delays = map[uint64]time.Duration{}
+	var users []task.UserModify
+	for i := uint64(1); i <= 2*DefaultMaxWorkers; i++ {
+		users = append(users, task.UserModify{UserID: i, Mute: i > priority})
+		if i <= priority {
+			fakes[OfficialBackend].Delays[i] = 20 * time.Millisecond
+		}
+	}
+	req := testRequest(premium.FreeTier, users...)
+	req.Priority = priority
+	err := tp.ModifyUsers(testGuildID, "ABCDEF", req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := fakes[OfficialBackend].Requests()
+	if len(applied) != len(users) {
+		t.Fatalf("expected all %d requests to be applied, got %d", len(users), len(applied))
+	}
+	for i, req := range applied {
+		if (i < priority) != (req.UserID <= priority) {
+			t.Errorf("expected the %d priority users to be applied before the rest, got %v", priority, applied)
+			break
+		}
+	}
+}
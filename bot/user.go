@@ -18,6 +18,8 @@ type UserData struct {
 	ShouldBeMute bool   `json:"ShouldBeMute"`
 	ShouldBeDeaf bool   `json:"ShouldBeDeaf"`
 	InGameName   string `json:"PlayerName"`
+	// waiting for a slot, or sitting out; see GameState.trackedPlayer
+	Spectator bool `json:"Spectator,omitempty"`
 }
 
 func MakeUserDataFromDiscordUser(dUser *discordgo.User, nick string) UserData {
@@ -56,4 +58,6 @@ func (user *UserData) GetPlayerName() string {
 
 func (user *UserData) Link(player amongus.PlayerData) {
 	user.InGameName = player.Name
+	// they're in the lobby, so they're playing
+	user.Spectator = false
 }
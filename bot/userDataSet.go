@@ -3,7 +3,9 @@ package bot
 import (
 	"fmt"
 	"github.com/automuteus/automuteus/v8/pkg/amongus"
-	"strings"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"sort"
+	"strconv"
 )
 
 type UserDataSet map[string]UserData
@@ -19,18 +21,147 @@ func (dgs *GameState) GetCountLinked() int {
 	return LinkedPlayerCount
 }
 
+const (
+	// AutoLinkConfidence is how similar a Discord name has to be to an in-game name to be linked without asking
+	AutoLinkConfidence = 0.8
+	// SuggestLinkConfidence is how similar a Discord name has to be to an in-game name to suggest linking them
+	SuggestLinkConfidence = 0.5
+	// the best match has to beat the runner-up by this much to be linked without asking
+	autoLinkMargin = 0.15
+	// MaxLinkSuggestions fits in the last row of the game state message, after the roster buttons
+	MaxLinkSuggestions = 3
+)
+
+// LinkSuggestion is a likely, but not certain, match between an in-game player and a Discord user, that someone has
+// to confirm with a button on the game state message
+type LinkSuggestion struct {
+	PlayerName string  `json:"playerName"`
+	Color      int     `json:"color"`
+	UserID     string  `json:"userID"`
+	Confidence float64 `json:"confidence"`
+}
+
 func (dgs *GameState) AttemptPairingByMatchingNames(data amongus.PlayerData) string {
-	name := strings.ReplaceAll(strings.ToLower(data.Name), " ", "")
+	name := amongus.NormalizeName(data.Name)
+	if name == "" {
+		return ""
+	}
 	for userID, v := range dgs.UserData {
-		if strings.ReplaceAll(strings.ToLower(v.GetUserName()), " ", "") == name || strings.ReplaceAll(strings.ToLower(v.GetNickName()), " ", "") == name {
+		if amongus.NormalizeName(v.GetUserName()) == name || amongus.NormalizeName(v.GetNickName()) == name {
 			v.Link(data)
 			dgs.UserData[userID] = v
+			delete(dgs.LinkSuggestions, data.Name)
 			return userID
 		}
 	}
 	return ""
 }
 
+// nameCandidates are all the names a user might be recognized by: nickname, username and the cached display name
+func (dgs *GameState) nameCandidates(userID string, v UserData) []string {
+	names := []string{v.GetNickName(), v.GetUserName()}
+	if display, ok := dgs.DisplayNames[userID]; ok {
+		names = append(names, display)
+	}
+	return names
+}
+
+// bestNameMatch finds the unlinked user whose names are most similar to the player's, and how confident the match is
+// compared to the runner-up
+func (dgs *GameState) bestNameMatch(data amongus.PlayerData) (best string, confidence, runnerUp float64) {
+	for userID, v := range dgs.UserData {
+		if v.GetPlayerName() != amongus.UnlinkedPlayerName {
+			continue
+		}
+		score := 0.0
+		for _, name := range dgs.nameCandidates(userID, v) {
+			if s := amongus.NameSimilarity(name, data.Name); s > score {
+				score = s
+			}
+		}
+		// ties are broken by ID so the result doesn't depend on map ordering
+		if score > confidence || (score == confidence && score > 0 && userID < best) {
+			if best != "" {
+				runnerUp = confidence
+			}
+			best, confidence = userID, score
+		} else if score > runnerUp {
+			runnerUp = score
+		}
+	}
+	return best, confidence, runnerUp
+}
+
+// AttemptPairingByFuzzyNames links the player to the unlinked user with the most similar name if the match is clear
+// enough, and returns their ID. A less certain match is recorded as a LinkSuggestion instead; suggested reports if
+// the suggestions changed
+func (dgs *GameState) AttemptPairingByFuzzyNames(data amongus.PlayerData) (userID string, suggested bool) {
+	best, confidence, runnerUp := dgs.bestNameMatch(data)
+	if best == "" || confidence < SuggestLinkConfidence {
+		return "", false
+	}
+	if confidence >= AutoLinkConfidence && confidence-runnerUp >= autoLinkMargin {
+		v := dgs.UserData[best]
+		v.Link(data)
+		dgs.UserData[best] = v
+		delete(dgs.LinkSuggestions, data.Name)
+		return best, false
+	}
+
+	if old, ok := dgs.LinkSuggestions[data.Name]; ok && old.UserID == best && old.Color == data.Color {
+		return "", false
+	}
+	if _, ok := dgs.LinkSuggestions[data.Name]; !ok && len(dgs.LinkSuggestions) >= MaxLinkSuggestions {
+		return "", false
+	}
+	if dgs.LinkSuggestions == nil {
+		dgs.LinkSuggestions = map[string]LinkSuggestion{}
+	}
+	dgs.LinkSuggestions[data.Name] = LinkSuggestion{
+		PlayerName: data.Name,
+		Color:      data.Color,
+		UserID:     best,
+		Confidence: confidence,
+	}
+	return "", true
+}
+
+// PruneLinkSuggestions drops suggestions that no longer make sense: the player left, or either side got linked some
+// other way. Returns true if any were dropped
+func (dgs *GameState) PruneLinkSuggestions() bool {
+	pruned := false
+	for name, suggestion := range dgs.LinkSuggestions {
+		v, ok := dgs.UserData[suggestion.UserID]
+		_, inGame := dgs.GameData.GetByName(name)
+		if !ok || !inGame || v.GetPlayerName() != amongus.UnlinkedPlayerName || dgs.playerIsLinked(name) {
+			delete(dgs.LinkSuggestions, name)
+			pruned = true
+		}
+	}
+	return pruned
+}
+
+func (dgs *GameState) playerIsLinked(playerName string) bool {
+	for _, v := range dgs.UserData {
+		if v.GetPlayerName() == playerName {
+			return true
+		}
+	}
+	return false
+}
+
+// SortedLinkSuggestions returns the pending suggestions in color order, like the players in the game state message
+func (dgs *GameState) SortedLinkSuggestions() []LinkSuggestion {
+	suggestions := make([]LinkSuggestion, 0, len(dgs.LinkSuggestions))
+	for _, v := range dgs.LinkSuggestions {
+		suggestions = append(suggestions, v)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Color < suggestions[j].Color
+	})
+	return suggestions
+}
+
 func (dgs *GameState) UpdateUserData(userID string, data UserData) {
 	if dgs.UserData != nil {
 		dgs.UserData[userID] = data
@@ -44,6 +175,7 @@ func (dgs *GameState) AttemptPairingByUserIDs(data amongus.PlayerData, userIDs m
 			if v.GetPlayerName() == amongus.UnlinkedPlayerName {
 				v.Link(data)
 				dgs.UserData[userID] = v
+				delete(dgs.LinkSuggestions, data.Name)
 			}
 			return userID
 		}
@@ -51,6 +183,30 @@ func (dgs *GameState) AttemptPairingByUserIDs(data amongus.PlayerData, userIDs m
 	return ""
 }
 
+// AttemptPairingByAliases links the player to the first user in the game that was linked to the player's name
+// before. Aliases last linked with the player's current color are tried first, then the most confirmed ones
+func (dgs *GameState) AttemptPairingByAliases(data amongus.PlayerData, aliases []*storage.PostgresPlayerAlias) string {
+	sorted := make([]*storage.PostgresPlayerAlias, len(aliases))
+	copy(sorted, aliases)
+	sameColor := func(alias *storage.PostgresPlayerAlias) bool {
+		return alias.PlayerColor != nil && int(*alias.PlayerColor) == data.Color
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sameColor(sorted[i]) && !sameColor(sorted[j])
+	})
+	for _, alias := range sorted {
+		userID := strconv.FormatUint(alias.UserID, 10)
+		if v, ok := dgs.UserData[userID]; ok {
+			// users linked to someone else this game aren't candidates anymore
+			if name := v.GetPlayerName(); name != amongus.UnlinkedPlayerName && name != data.Name {
+				continue
+			}
+			return dgs.AttemptPairingByUserIDs(data, map[string]interface{}{userID: struct{}{}})
+		}
+	}
+	return ""
+}
+
 func (dgs *GameState) ClearPlayerData(userID string) bool {
 	if v, ok := dgs.UserData[userID]; ok {
 		v.InGameName = amongus.UnlinkedPlayerName
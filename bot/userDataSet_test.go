@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/amongus"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"testing"
+)
+
+func fuzzyTestState(users map[string]string) *GameState {
+	dgs := NewDiscordGameState("754465589958803548")
+	for userID, name := range users {
+		dgs.UserData[userID] = MakeUserDataFromDiscordUser(&discordgo.User{ID: userID, Username: name}, "")
+	}
+	return dgs
+}
+
+func fuzzyTestPlayer(dgs *GameState, name string, color int) amongus.PlayerData {
+	_, _, data := dgs.GameData.UpdatePlayer(game.Player{Name: name, Color: color, Action: game.JOINED})
+	return data
+}
+
+func TestAttemptPairingByMatchingNames(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "ｱﾘｽ"})
+	if userID := dgs.AttemptPairingByMatchingNames(fuzzyTestPlayer(dgs, "アリス", 0)); userID != "140000000000000001" {
+		t.Errorf("expected width and kana variants of a name to be linked, got \"%s\"", userID)
+	}
+}
+
+func TestAttemptPairingByAliases(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+		"140000000000000003": "Carol",
+	})
+	blue := int16(1)
+	// sorted by times confirmed, like GetAliasesByName
+	aliases := []*storage.PostgresPlayerAlias{
+		{UserID: 140000000000000009, PlayerName: "Sam", TimesConfirmed: 9},
+		{UserID: 140000000000000001, PlayerName: "Sam", TimesConfirmed: 5},
+		{UserID: 140000000000000002, PlayerName: "Sam", PlayerColor: &blue, TimesConfirmed: 1},
+	}
+	if userID := dgs.AttemptPairingByAliases(fuzzyTestPlayer(dgs, "Sam", 0), aliases); userID != "140000000000000001" {
+		t.Errorf("expected the most confirmed user in the game to be linked, got \"%s\"", userID)
+	}
+
+	dgs = fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+	})
+	if userID := dgs.AttemptPairingByAliases(fuzzyTestPlayer(dgs, "Sam", 1), aliases); userID != "140000000000000002" {
+		t.Errorf("expected the user last linked with the same color to be preferred, got \"%s\"", userID)
+	}
+	if userID := dgs.AttemptPairingByAliases(fuzzyTestPlayer(dgs, "Samuel", 2), []*storage.PostgresPlayerAlias{
+		{UserID: 140000000000000002, PlayerName: "Samuel"},
+	}); userID != "" {
+		t.Errorf("users already linked to another player shouldn't be linked again, got \"%s\"", userID)
+	}
+}
+
+func TestAttemptPairingByFuzzyNames(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Alice",
+		"140000000000000002": "Bob",
+	})
+	dgs.DisplayNames["140000000000000002"] = "ボブ太郎"
+
+	userID, suggested := dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "Alicee", 0))
+	if userID != "140000000000000001" || suggested {
+		t.Errorf("expected a clear match to be linked automatically, got \"%s\"", userID)
+	}
+
+	userID, suggested = dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "ボブ", 1))
+	if userID != "" || !suggested {
+		t.Fatal("expected an uncertain match to be suggested, not linked")
+	}
+	suggestion := dgs.LinkSuggestions["ボブ"]
+	if suggestion.UserID != "140000000000000002" || suggestion.Color != 1 {
+		t.Errorf("unexpected suggestion %v", suggestion)
+	}
+	if _, suggested = dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "ボブ", 1)); suggested {
+		t.Error("suggesting the same link twice should not report a change")
+	}
+
+	userID, suggested = dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "Zelda", 2))
+	if userID != "" || suggested {
+		t.Error("unrelated names should neither be linked nor suggested")
+	}
+
+	// linking the suggested player some other way makes the suggestion stale
+	dgs.AttemptPairingByUserIDs(fuzzyTestPlayer(dgs, "ボブ", 1), map[string]interface{}{"140000000000000002": struct{}{}})
+	if len(dgs.LinkSuggestions) != 0 {
+		t.Errorf("expected the suggestion to be cleared once linked, got %v", dgs.LinkSuggestions)
+	}
+}
+
+func TestAttemptPairingByFuzzyNamesAmbiguous(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{
+		"140000000000000001": "Sam1",
+		"140000000000000002": "Sam2",
+	})
+	userID, suggested := dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "Sam", 0))
+	if userID != "" || !suggested {
+		t.Error("two equally similar users should only produce a suggestion")
+	}
+	if dgs.LinkSuggestions["Sam"].UserID != "140000000000000001" {
+		t.Errorf("ties should be broken by user ID, got %v", dgs.LinkSuggestions["Sam"])
+	}
+}
+
+func TestPruneLinkSuggestions(t *testing.T) {
+	dgs := fuzzyTestState(map[string]string{"140000000000000001": "ボブ太郎"})
+	dgs.AttemptPairingByFuzzyNames(fuzzyTestPlayer(dgs, "ボブ", 1))
+	if dgs.PruneLinkSuggestions() {
+		t.Error("nothing should have been pruned yet")
+	}
+	dgs.GameData.ClearPlayerData("ボブ")
+	if !dgs.PruneLinkSuggestions() || len(dgs.LinkSuggestions) != 0 {
+		t.Error("suggestions for players that left should be pruned")
+	}
+}
@@ -1,7 +1,8 @@
 package bot
 
 import (
-	"context"
+	"github.com/automuteus/automuteus/v8/pkg/amongus"
+	"github.com/automuteus/automuteus/v8/pkg/game"
 	"github.com/automuteus/automuteus/v8/pkg/premium"
 	"github.com/automuteus/automuteus/v8/pkg/settings"
 	"github.com/automuteus/automuteus/v8/pkg/task"
@@ -41,11 +42,60 @@ func (bot *Bot) applyToSingle(dgs *GameState, userID string, mute, deaf bool) er
 	return bot.TokenProvider.ModifyUsers(dgs.GuildID, dgs.ConnectCode, req, nil)
 }
 
+// ghostChannelMove returns the voice channel a linked player should be moved to when the guild has a ghost channel:
+// dead players go to the ghost channel during tasks, and everyone comes back to the game's channel otherwise.
+// Returns 0 if the player is already where they belong
+func ghostChannelMove(ghostChannelID, gameChannelID, currentChannelID string, isAlive bool, phase game.Phase) uint64 {
+	if ghostChannelID == "" {
+		return 0
+	}
+	target := gameChannelID
+	if !isAlive && phase == game.TASKS {
+		target = ghostChannelID
+	}
+	if target == currentChannelID {
+		return 0
+	}
+	id, _ := strconv.ParseUint(target, 10, 64)
+	return id
+}
+
+// inGameVoiceChannel checks if a voice channel is the game's channel, or the guild's ghost channel
+func inGameVoiceChannel(sett *settings.GuildSettings, dgs *GameState, channelID string) bool {
+	if channelID == "" {
+		return false
+	}
+	return dgs.VoiceChannel == channelID || sett.GetGhostChannelID() == channelID
+}
+
+// voicePlayer is what the voice rules need to know about a player. Their team is only known if the capture reported
+// their role, since the base game doesn't tell anybody who the impostors are until the game is over
+func voicePlayer(mode game.GameMode, auData amongus.PlayerData) game.VoicePlayer {
+	player := game.VoicePlayer{
+		IsAlive: auData.IsAlive,
+		Exiled:  auData.Exiled,
+	}
+	if auData.Role != "" || auData.IsImpostor {
+		player.Team = mode.Role(auData.PlayerInfo()).Team
+	}
+	return player
+}
+
+// memberRoles returns the role IDs of a member, for looking up voice rule overrides
+func memberRoles(sess *discordgo.Session, guildID, userID string) []string {
+	member, err := sess.State.Member(guildID, userID)
+	if err != nil || member == nil {
+		return nil
+	}
+	return member.Roles
+}
+
 func (bot *Bot) applyToAll(dgs *GameState, mute, deaf bool) error {
 	g, err := bot.PrimarySession.State.Guild(dgs.GuildID)
 	if err != nil {
 		return err
 	}
+	sett := bot.StorageInterface.GetGuildSettings(dgs.GuildID)
 
 	var users []task.UserModify
 
@@ -60,18 +110,32 @@ func (bot *Bot) applyToAll(dgs *GameState, mute, deaf bool) error {
 			}
 		}
 
-		tracked := voiceState.ChannelID != "" && dgs.VoiceChannel == voiceState.ChannelID
+		tracked := inGameVoiceChannel(sett, dgs, voiceState.ChannelID)
 
-		_, linked := dgs.GameData.GetByName(userData.InGameName)
-		// only actually tracked if we're in a tracked channel AND linked to a player
-		tracked = tracked && linked
+		auData, linked := dgs.trackedPlayer(userData)
+		roles := memberRoles(bot.PrimarySession, dgs.GuildID, voiceState.UserID)
+		hasOverride := sett.HasVoiceOverride(voiceState.UserID, roles)
+		// only actually tracked if we're in a tracked channel AND linked to a player (or has an override of their own)
+		tracked = tracked && (linked || hasOverride)
 
 		if tracked {
 			uid, _ := strconv.ParseUint(userData.User.UserID, 10, 64)
+			var userMute, userDeaf bool
+			if linked {
+				userMute, userDeaf = sett.ApplyVoiceOverrides(voiceState.UserID, roles, auData.IsAlive, dgs.GameData.GetPhase(), mute, deaf)
+			} else {
+				userMute, userDeaf = sett.ApplyUnlinkedVoiceOverrides(voiceState.UserID, roles, dgs.GameData.GetPhase(), mute, deaf)
+			}
+			var channelID uint64
+			// nobody should be left behind in the ghost channel
+			if sett.GetGhostChannelID() != "" && voiceState.ChannelID == sett.GetGhostChannelID() {
+				channelID, _ = strconv.ParseUint(dgs.VoiceChannel, 10, 64)
+			}
 			users = append(users, task.UserModify{
-				UserID: uid,
-				Mute:   mute,
-				Deaf:   deaf,
+				UserID:    uid,
+				Mute:      userMute,
+				Deaf:      userDeaf,
+				ChannelID: channelID,
 			})
 			log.Println("Forcibly applying mute/deaf to " + userData.User.UserID)
 		}
@@ -93,7 +157,7 @@ func (bot *Bot) applyToAll(dgs *GameState, mute, deaf bool) error {
 }
 
 // handleTrackedMembers moves/mutes players according to the current game state
-func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.GuildSettings, delay int, handlePriority HandlePriority, gsr GameStateRequest) {
+func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.GuildSettings, delay time.Duration, handlePriority HandlePriority, gsr GameStateRequest) {
 
 	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(gsr)
 	for lock == nil {
@@ -121,15 +185,17 @@ func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.Gui
 			}
 		}
 
-		tracked := voiceState.ChannelID != "" && dgs.VoiceChannel == voiceState.ChannelID
+		tracked := inGameVoiceChannel(sett, dgs, voiceState.ChannelID)
 
-		auData, found := dgs.GameData.GetByName(userData.InGameName)
+		auData, found := dgs.trackedPlayer(userData)
+		roles := memberRoles(sess, dgs.GuildID, voiceState.UserID)
+		hasOverride := sett.HasVoiceOverride(voiceState.UserID, roles)
 		// only actually tracked if we're in a tracked channel AND linked to a player
 		var isAlive bool
 
-		// only actually tracked if we're in a tracked channel AND linked to a player
+		// only actually tracked if we're in a tracked channel AND linked to a player (or has an override of their own)
 		if !sett.GetMuteSpectator() {
-			tracked = tracked && found
+			tracked = tracked && (found || hasOverride)
 			isAlive = auData.IsAlive
 		} else {
 			if !found {
@@ -139,19 +205,34 @@ func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.Gui
 				isAlive = auData.IsAlive
 			}
 		}
-		shouldMute, shouldDeaf := sett.GetVoiceState(isAlive, tracked, dgs.GameData.GetPhase())
+		mode := dgs.getGameMode(sett)
+		player := voicePlayer(mode, auData)
+		player.IsAlive = isAlive
+		var shouldMute, shouldDeaf bool
+		if !found && !sett.GetMuteSpectator() {
+			// only tracked because of an override, so not a player at all
+			shouldMute, shouldDeaf = sett.GetUnlinkedVoiceStateFor(voiceState.UserID, roles, tracked, dgs.GameData.GetPhase(), mode.Name)
+		} else {
+			shouldMute, shouldDeaf = sett.GetVoiceStateFor(voiceState.UserID, roles, player, tracked, dgs.GameData.GetPhase(), mode.Name)
+		}
 
 		incorrectMuteDeafenState := shouldMute != userData.ShouldBeMute || shouldDeaf != userData.ShouldBeDeaf
 
+		var moveTo uint64
+		if tracked && found {
+			moveTo = ghostChannelMove(sett.GetGhostChannelID(), dgs.VoiceChannel, voiceState.ChannelID, isAlive, dgs.GameData.GetPhase())
+		}
+
 		// only issue a change if the User isn't in the right state already
 		// nicksmatch can only be false if the in-game data is != nil, so the reference to .audata below is safe
 		// check the userdata is linked here to not accidentally undeafen music bots, for example
-		if incorrectMuteDeafenState && (found || sett.GetMuteSpectator()) {
+		if (incorrectMuteDeafenState || moveTo != 0) && (found || sett.GetMuteSpectator() || hasOverride) {
 			uid, _ := strconv.ParseUint(userData.User.UserID, 10, 64)
 			userModify := task.UserModify{
-				UserID: uid,
-				Mute:   shouldMute,
-				Deaf:   shouldDeaf,
+				UserID:    uid,
+				Mute:      shouldMute,
+				Deaf:      shouldDeaf,
+				ChannelID: moveTo,
 			}
 
 			if handlePriority != NoPriority && ((handlePriority == AlivePriority && isAlive) || (handlePriority == DeadPriority && !isAlive)) {
@@ -168,11 +249,11 @@ func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.Gui
 	// we relinquish the lock while we wait
 	bot.RedisInterface.SetDiscordGameState(dgs, lock)
 
-	voiceLock := bot.RedisInterface.LockVoiceChanges(dgs.ConnectCode, time.Second*time.Duration(delay+1))
+	voiceLock := bot.RedisInterface.LockVoiceChanges(dgs.ConnectCode, delay+time.Second)
 
 	if delay > 0 {
-		log.Printf("Sleeping for %d seconds before applying changes to users\n", delay)
-		time.Sleep(time.Second * time.Duration(delay))
+		log.Printf("Sleeping for %s before applying changes to users\n", delay)
+		time.Sleep(delay)
 	}
 
 	if dgs.Running && len(users) > 0 {
@@ -183,41 +264,20 @@ func (bot *Bot) handleTrackedMembers(sess *discordgo.Session, sett *settings.Gui
 		}
 
 		if priorityRequests > 0 {
-			req := task.UserModifyRequest{
-				Premium: premTier,
-				Users:   users[:priorityRequests],
-			}
-			// no lock; we're not done yet
-			err := bot.issueMutesAndRecord(dgs.GuildID, dgs.ConnectCode, req, nil)
-			if err != nil {
-				log.Println(err)
-			} else {
-				log.Println("Successfully finished issuing high priority mutes")
-			}
-			rem := users[priorityRequests:]
-			if len(rem) > 0 {
-				req = task.UserModifyRequest{
-					Premium: premTier,
-					Users:   rem,
-				}
-				err := bot.issueMutesAndRecord(dgs.GuildID, dgs.ConnectCode, req, voiceLock)
-				if err != nil {
-					log.Println(err)
-				}
-			} else if voiceLock != nil {
-				voiceLock.Release(context.Background())
-			}
+			log.Printf("Issuing %d high priority mutes/deafens first\n", priorityRequests)
 		} else {
-			// no priority; issue all at once
 			log.Println("Issuing mutes/deafens with no particular priority")
-			req := task.UserModifyRequest{
-				Premium: premTier,
-				Users:   users,
-			}
-			err := bot.issueMutesAndRecord(dgs.GuildID, dgs.ConnectCode, req, voiceLock)
-			if err != nil {
-				log.Println(err)
-			}
+		}
+		// one request for everyone, so the whole transition is planned onto the tokens at once; the priority users at
+		// the front are planned onto the freshest tokens, and applied before the rest are started
+		req := task.UserModifyRequest{
+			Premium:  premTier,
+			Users:    users,
+			Priority: priorityRequests,
+		}
+		err := bot.issueMutesAndRecord(dgs.GuildID, dgs.ConnectCode, req, voiceLock)
+		if err != nil {
+			log.Println(err)
 		}
 	}
 }
@@ -0,0 +1,315 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The AmongUsCapture client talks Socket.IO over a websocket. The ingest server speaks just enough of it (Engine.IO
+// v3 and v4, the default namespace, text events) to take the capture's events and push them as jobs, like the broker
+// would, so a self-hosted bot can run without one
+
+const (
+	ingestPingInterval = 25 * time.Second
+	ingestPingTimeout  = 20 * time.Second
+)
+
+// events, as the capture names them
+const (
+	connectCodeEvent  = "connectCode"
+	lobbyEvent        = "lobby"
+	stateEvent        = "state"
+	playerEvent       = "player"
+	gameOverEvent     = "gameover"
+	modifyEvent       = "modify"
+	taskCompleteEvent = "taskComplete"
+	taskFailedEvent   = "taskFailed"
+)
+
+var captureJobTypes = map[string]task.JobType{
+	lobbyEvent:    task.LobbyJob,
+	stateEvent:    task.StateJob,
+	playerEvent:   task.PlayerJob,
+	gameOverEvent: task.GameOverJob,
+}
+
+var errCaptureClosed = errors.New("capture closed the connection")
+
+func StartIngestServer(client *redis.Client, port string) error {
+	r := mux.NewRouter()
+	r.Handle("/socket.io/", NewIngestHandler(client))
+	return http.ListenAndServe(":"+port, r)
+}
+
+type IngestHandler struct {
+	client   *redis.Client
+	upgrader websocket.Upgrader
+}
+
+func NewIngestHandler(client *redis.Client) *IngestHandler {
+	return &IngestHandler{
+		client: client,
+		upgrader: websocket.Upgrader{
+			// the capture is a desktop app, not a browser
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+func (h *IngestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("transport") != "websocket" {
+		http.Error(w, "only the websocket transport is supported", http.StatusBadRequest)
+		return
+	}
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	conn := &captureConn{
+		ws:     ws,
+		client: h.client,
+		// Engine.IO v3 clients ping the server; from v4 on, the server pings the clients
+		serverPings: r.URL.Query().Get("EIO") != "3",
+	}
+	conn.serve()
+}
+
+// captureConn is a single capture client. Everything it sends is pushed as a job for its connect code, and tasks the
+// bot publishes for the connect code are sent back to it
+type captureConn struct {
+	ws          *websocket.Conn
+	client      *redis.Client
+	serverPings bool
+	writeLock   sync.Mutex
+	sid         string
+
+	connectCode string
+	tasks       *redis.PubSub
+}
+
+func (c *captureConn) serve() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		c.setConnectCode(context.Background(), "")
+		c.ws.Close()
+	}()
+
+	c.sid = newSessionID()
+	open, _ := json.Marshal(map[string]interface{}{
+		"sid":          c.sid,
+		"upgrades":     []string{},
+		"pingInterval": ingestPingInterval.Milliseconds(),
+		"pingTimeout":  ingestPingTimeout.Milliseconds(),
+	})
+	if c.write("0"+string(open)) != nil {
+		return
+	}
+	if c.serverPings {
+		go c.ping(ctx)
+	} else if c.write("40") != nil {
+		// v3 clients are connected to the default namespace straight away
+		return
+	}
+
+	for {
+		c.ws.SetReadDeadline(time.Now().Add(ingestPingInterval + ingestPingTimeout))
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		err = c.handlePacket(ctx, string(msg))
+		if err != nil {
+			if !errors.Is(err, errCaptureClosed) {
+				log.Println(err)
+			}
+			return
+		}
+	}
+}
+
+func (c *captureConn) ping(ctx context.Context) {
+	t := time.NewTicker(ingestPingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if c.write("2") != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *captureConn) write(packet string) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	return c.ws.WriteMessage(websocket.TextMessage, []byte(packet))
+}
+
+func (c *captureConn) emit(event string, args ...interface{}) error {
+	data, err := json.Marshal(append([]interface{}{event}, args...))
+	if err != nil {
+		return err
+	}
+	return c.write("42" + string(data))
+}
+
+// handlePacket handles an Engine.IO packet
+func (c *captureConn) handlePacket(ctx context.Context, packet string) error {
+	if packet == "" {
+		return nil
+	}
+	switch packet[0] {
+	case '1':
+		return errCaptureClosed
+	case '2':
+		// answer with the same payload, so probes are answered too
+		return c.write("3" + packet[1:])
+	case '4':
+		return c.handleMessage(ctx, packet[1:])
+	}
+	return nil
+}
+
+// handleMessage handles a Socket.IO packet
+func (c *captureConn) handleMessage(ctx context.Context, msg string) error {
+	if msg == "" {
+		return nil
+	}
+	switch msg[0] {
+	case '0':
+		if c.serverPings {
+			return c.write(fmt.Sprintf(`40{"sid":"%s"}`, c.sid))
+		}
+	case '1':
+		return errCaptureClosed
+	case '2':
+		event, payload, ackID, err := parseCaptureEvent(msg[1:])
+		if err != nil {
+			return err
+		}
+		c.handleEvent(ctx, event, payload)
+		if ackID != "" {
+			return c.write("43" + ackID + "[]")
+		}
+	}
+	return nil
+}
+
+func (c *captureConn) handleEvent(ctx context.Context, event, payload string) {
+	switch event {
+	case connectCodeEvent:
+		c.setConnectCode(ctx, payload)
+	case taskCompleteEvent, taskFailedEvent:
+		err := c.client.Publish(ctx, rediskey.CompleteTask(payload), strconv.FormatBool(event == taskCompleteEvent)).Err()
+		if err != nil {
+			log.Println(err)
+		}
+	default:
+		jobType, ok := captureJobTypes[event]
+		if !ok {
+			return
+		}
+		if c.connectCode == "" {
+			log.Printf("Capture sent a %s event before its connect code; ignoring it\n", event)
+			return
+		}
+		err := task.PushJob(ctx, c.client, c.connectCode, jobType, payload)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// setConnectCode connects the capture to a game, disconnecting it from the one it was connected to before. An empty
+// code only disconnects it
+func (c *captureConn) setConnectCode(ctx context.Context, code string) {
+	if code == c.connectCode {
+		return
+	}
+	if c.connectCode != "" {
+		c.tasks.Close()
+		err := task.PushJob(ctx, c.client, c.connectCode, task.ConnectionJob, "false")
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	c.connectCode = code
+	if code == "" {
+		return
+	}
+	log.Printf("Capture connected with connect code %s\n", code)
+	c.tasks = c.client.Subscribe(ctx, rediskey.TasksList(code))
+	go c.relayTasks(c.tasks)
+	err := task.PushJob(ctx, c.client, code, task.ConnectionJob, "true")
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// relayTasks sends the mutes the bot asks the capture to do on its behalf
+func (c *captureConn) relayTasks(tasks *redis.PubSub) {
+	for msg := range tasks.Channel() {
+		if c.emit(modifyEvent, msg.Payload) != nil {
+			return
+		}
+	}
+}
+
+// parseCaptureEvent parses a Socket.IO event packet (without the packet type), like `12["state","1"]`. The capture
+// sends JSON payloads as strings; anything else is passed on as the raw JSON
+func parseCaptureEvent(msg string) (event, payload, ackID string, err error) {
+	if strings.HasPrefix(msg, "/") {
+		var nsp string
+		nsp, msg, _ = strings.Cut(msg, ",")
+		if nsp != "/" {
+			return "", "", "", fmt.Errorf("unsupported namespace %s", nsp)
+		}
+	}
+	i := 0
+	for i < len(msg) && msg[i] >= '0' && msg[i] <= '9' {
+		i++
+	}
+	ackID, msg = msg[:i], msg[i:]
+
+	var args []json.RawMessage
+	err = json.Unmarshal([]byte(msg), &args)
+	if err != nil {
+		return "", "", "", err
+	}
+	if len(args) == 0 || json.Unmarshal(args[0], &event) != nil {
+		return "", "", "", fmt.Errorf("event without a name: %s", msg)
+	}
+	if len(args) > 1 {
+		if json.Unmarshal(args[1], &payload) != nil {
+			payload = string(bytes.TrimSpace(args[1]))
+		}
+	}
+	return event, payload, ackID, nil
+}
+
+func newSessionID() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCaptureEvent(t *testing.T) {
+	tests := []struct {
+		msg, event, payload, ackID string
+		err                        bool
+	}{
+		{msg: `["connectCode","ABCDEFGH"]`, event: "connectCode", payload: "ABCDEFGH"},
+		{msg: `["state",1]`, event: "state", payload: "1"},
+		{msg: `["lobby","{\"LobbyCode\":\"ABCDEF\"}"]`, event: "lobby", payload: `{"LobbyCode":"ABCDEF"}`},
+		{msg: `["player",{"Name":"alice"}]`, event: "player", payload: `{"Name":"alice"}`},
+		{msg: `12["state","2"]`, event: "state", payload: "2", ackID: "12"},
+		{msg: `/,["state","0"]`, event: "state", payload: "0"},
+		{msg: `/admin,["state","0"]`, err: true},
+		{msg: `[]`, err: true},
+		{msg: `not json`, err: true},
+	}
+	for _, tt := range tests {
+		event, payload, ackID, err := parseCaptureEvent(tt.msg)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: expected error=%v, got %v", tt.msg, tt.err, err)
+			continue
+		}
+		if event != tt.event || payload != tt.payload || ackID != tt.ackID {
+			t.Errorf("%s: expected %q %q %q, got %q %q %q", tt.msg, tt.event, tt.payload, tt.ackID, event, payload, ackID)
+		}
+	}
+}
+
+func dialIngest(t *testing.T, client *redis.Client, eio string) *websocket.Conn {
+	srv := httptest.NewServer(NewIngestHandler(client))
+	t.Cleanup(srv.Close)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/socket.io/?EIO=" + eio + "&transport=websocket"
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func expectPacket(t *testing.T, ws *websocket.Conn, prefix string) string {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, msg, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(msg), prefix) {
+		t.Fatalf("expected a packet starting with %s, got %s", prefix, msg)
+	}
+	return string(msg)
+}
+
+func send(t *testing.T, ws *websocket.Conn, packet string) {
+	t.Helper()
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(packet)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectJob(t *testing.T, client *redis.Client, jobType task.JobType, payload string) {
+	t.Helper()
+	var job task.Job
+	var err error
+	for i := 0; i < 50; i++ {
+		job, err = task.PopJob(context.Background(), client, "ABCDEFGH", "test")
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.AckJob(context.Background(), client, "ABCDEFGH", job.ID)
+	if job.JobType != jobType || job.Payload != payload {
+		t.Errorf("expected job %d with %s, got %d with %v", jobType, payload, job.JobType, job.Payload)
+	}
+}
+
+func TestIngestHandler(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	if err := task.EnsureJobGroup(context.Background(), client, "ABCDEFGH"); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := dialIngest(t, client, "4")
+	expectPacket(t, ws, `0{"pingInterval":`)
+	send(t, ws, "40")
+	expectPacket(t, ws, `40{"sid":`)
+
+	// nothing to push to before the connect code
+	send(t, ws, `42["state","1"]`)
+	send(t, ws, `42["connectCode","ABCDEFGH"]`)
+	send(t, ws, `42["lobby","{\"LobbyCode\":\"ABCDEF\",\"Region\":0,\"Map\":0}"]`)
+	send(t, ws, `421["state","0"]`)
+	expectPacket(t, ws, "431[]")
+
+	expectJob(t, client, task.ConnectionJob, "true")
+	expectJob(t, client, task.LobbyJob, `{"LobbyCode":"ABCDEF","Region":0,"Map":0}`)
+	expectJob(t, client, task.StateJob, "0")
+
+	// mutes the bot asks the capture to do
+	for i := 0; i < 50 && mr.PubSubNumSub(rediskey.TasksList("ABCDEFGH"))[rediskey.TasksList("ABCDEFGH")] == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	client.Publish(context.Background(), rediskey.TasksList("ABCDEFGH"), `{"taskID":"abc"}`)
+	expectPacket(t, ws, `42["modify","{\"taskID\":\"abc\"}"]`)
+
+	acks := client.Subscribe(context.Background(), rediskey.CompleteTask("abc"))
+	defer acks.Close()
+	if _, err := acks.Receive(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	send(t, ws, `42["taskComplete","abc"]`)
+	select {
+	case msg := <-acks.Channel():
+		if msg.Payload != "true" {
+			t.Errorf("expected the task to be acked, got %s", msg.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the task to be acked")
+	}
+
+	send(t, ws, "41")
+	expectJob(t, client, task.ConnectionJob, "false")
+}
+
+func TestIngestHandlerV3(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	ws := dialIngest(t, client, "3")
+	expectPacket(t, ws, "0{")
+	// v3 clients are connected without asking, and ping the server themselves
+	expectPacket(t, ws, "40")
+	send(t, ws, "2probe")
+	expectPacket(t, ws, "3probe")
+}
+
+func TestIngestHandlerPolling(t *testing.T) {
+	srv := httptest.NewServer(NewIngestHandler(nil))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/socket.io/?EIO=4&transport=polling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected polling to be refused, got %d", resp.StatusCode)
+	}
+}
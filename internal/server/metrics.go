@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/automuteus/automuteus/v8/pkg/task"
 	"github.com/go-redis/redis/v8"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -35,14 +36,16 @@ var MetricTypeStrings = []string{
 }
 
 type Collector struct {
-	counterDesc *prometheus.Desc
-	client      *redis.Client
-	commit      string
-	nodeID      string
+	counterDesc      *prometheus.Desc
+	pendingEditsDesc *prometheus.Desc
+	client           *redis.Client
+	commit           string
+	nodeID           string
 }
 
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.counterDesc
+	ch <- c.pendingEditsDesc
 }
 
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
@@ -84,6 +87,13 @@ func (c *Collector) Collect(ch chan<- prometheus.Metric) {
 			)
 		}
 	}
+
+	pending, err := task.PendingEdits(context.Background(), c.client)
+	if err != nil {
+		log.Println(err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.pendingEditsDesc, prometheus.GaugeValue, float64(pending))
+	}
 }
 
 func RecordDiscordRequests(client *redis.Client, requestType EventType, num int64) {
@@ -95,9 +105,10 @@ func RecordDiscordRequests(client *redis.Client, requestType EventType, num int6
 
 func NewCollector(client *redis.Client, nodeID string) *Collector {
 	return &Collector{
-		counterDesc: prometheus.NewDesc("discord_requests_by_node_and_type", "Number of discord requests made, differentiated by node/type", []string{"nodeID", "type"}, nil),
-		client:      client,
-		nodeID:      nodeID,
+		counterDesc:      prometheus.NewDesc("discord_requests_by_node_and_type", "Number of discord requests made, differentiated by node/type", []string{"nodeID", "type"}, nil),
+		pendingEditsDesc: prometheus.NewDesc("pending_message_edits", "Number of game state message edits waiting to be sent, across all nodes", nil, nil),
+		client:           client,
+		nodeID:           nodeID,
 	}
 }
 
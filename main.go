@@ -1,7 +1,6 @@
 package main
 
 import (
-	_ "embed"
 	"errors"
 	"fmt"
 	"io"
@@ -14,6 +13,7 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // /schedule のタイムゾーンを、tzdata の無いコンテナでも解決できるように
 
 	"github.com/automuteus/automuteus/v8/bot"
 	"github.com/automuteus/automuteus/v8/bot/command"
@@ -32,9 +32,6 @@ var (
 	date    = "unknown"
 )
 
-//go:embed storage/postgres.sql
-var postgresFileContents string
-
 const (
 	DefaultURL                   = "http://localhost:8123"
 	DefaultMaxRequests5Sec int64 = 7
@@ -66,6 +63,7 @@ var EnabledSlashCommands = map[string]bool{
 	"premium":  false,
 	"debug":    false,
 	"download": false,
+	"schedule": true,
 }
 
 // マップに載っていないコマンド名は「デフォルトで true（有効）」扱いにします。
@@ -79,6 +77,23 @@ func isSlashCommandEnabled(name string) bool {
 func main() {
 	// seed the rand generator (used for making connection codes)
 	rand.Seed(time.Now().Unix())
+
+	// subcommands that run offline instead of starting the bot
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		err := replayMain(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migrateMain(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	err := discordMainWrapper()
 	if err != nil {
 		log.Println("Program exited with the following error:")
@@ -109,14 +124,24 @@ func discordMainWrapper() error {
 		log.SetOutput(mw)
 	}
 
-	// ===== ここから JST 固定処理 =====
-	// UTC+9 (JST) の固定タイムゾーンを作成
-	jst := time.FixedZone("Asia/Tokyo", 9*60*60)
-	// Go ランタイム全体の「ローカルタイム」を JST に変更
-	time.Local = jst
+	// ===== ここからタイムゾーン設定 =====
+	// AUTOMUTEUS_TIMEZONE で変更可能（デフォルトは JST）。
+	// /schedule はギルドごとに timezone を指定できるので、これはデフォルト値として使われます。
+	tzName := os.Getenv("AUTOMUTEUS_TIMEZONE")
+	if tzName == "" {
+		tzName = "Asia/Tokyo"
+	}
+	loc, err := time.LoadLocation(tzName)
+	if err != nil {
+		log.Printf("Init: unknown AUTOMUTEUS_TIMEZONE %s, falling back to Asia/Tokyo (UTC+9)\n", tzName)
+		// UTC+9 (JST) の固定タイムゾーン
+		loc = time.FixedZone("Asia/Tokyo", 9*60*60)
+	}
+	// Go ランタイム全体の「ローカルタイム」を変更
+	time.Local = loc
 	// 確認用ログ（起動時に一度だけ）
-	log.Println("Init: time.Local forced to Asia/Tokyo (UTC+9)")
-	// ===== JST 固定処理ここまで =====
+	log.Printf("Init: time.Local set to %s\n", loc)
+	// ===== タイムゾーン設定ここまで =====
 
 	emojiGuildID := os.Getenv("EMOJI_GUILD_ID")
 
@@ -202,16 +227,26 @@ func discordMainWrapper() error {
 		return err
 	}
 
+	// the official bot is migrated explicitly, with the migrate subcommand. Everyone else is migrated before any bot
+	// starts, because games are written to the new columns as soon as they're processed
 	if !isOfficial {
-		go func() {
-			err := psql.ExecFromString(postgresFileContents)
-			if err != nil {
-				log.Println("Exiting with fatal error when attempting to execute postgres.sql:")
-				log.Fatal(err)
-			}
-		}()
+		err = migrateToLatest(&psql)
+		if err != nil {
+			log.Println("Exiting with fatal error when attempting to migrate the Postgres schema:")
+			return err
+		}
 	}
 
+	go func() {
+		// player aliases used to be cached in redis; this is a no-op once they've all been moved over
+		migrated, err := bot.MigrateUsernameLinksToPostgres(&redisClient, &psql)
+		if err != nil {
+			log.Println("Failed to migrate cached player names to Postgres:", err)
+		} else if migrated > 0 {
+			log.Printf("Migrated %d cached player names from Redis to Postgres\n", migrated)
+		}
+	}()
+
 	log.Println("Bot is now running.  Press CTRL-C to exit.")
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
@@ -237,6 +272,20 @@ func discordMainWrapper() error {
 	}
 
 	tokenProvider := tokenprovider.NewTokenProvider(nil, nil, taskTimeoutms, maxReq)
+	backendOrderStr := os.Getenv("VOICE_BACKEND_ORDER")
+	if backendOrderStr != "" {
+		tierOrders, defaultOrder, err := tokenprovider.ParseBackendOrder(backendOrderStr)
+		if err != nil {
+			return err
+		}
+		for tier, order := range tierOrders {
+			tokenProvider.SetBackendOrder(tier, order...)
+		}
+		if defaultOrder != nil {
+			tokenProvider.SetDefaultBackendOrder(defaultOrder...)
+		}
+		log.Printf("Read from env; using VOICE_BACKEND_ORDER=%s\n", backendOrderStr)
+	}
 	var extraTokens []string
 	extraTokenStr := strings.ReplaceAll(os.Getenv("WORKER_BOT_TOKENS"), " ", "")
 	if extraTokenStr != "" {
@@ -264,6 +313,17 @@ func discordMainWrapper() error {
 
 	go bots[0].StartAPIServer("5000")
 
+	// self-hosted bots can take the capture's connection themselves, without a broker in front of them
+	if ingestPort := os.Getenv("INGEST_PORT"); ingestPort != "" {
+		log.Printf("Read from env; accepting capture connections on INGEST_PORT=%s\n", ingestPort)
+		go func() {
+			err := bots[0].StartIngestServer(ingestPort)
+			if err != nil {
+				log.Println("Ingest server exited with error:", err)
+			}
+		}()
+	}
+
 	// empty string entry = global
 	slashCommandGuildIds := []string{""}
 	slashCommandGuildIdStr := strings.ReplaceAll(os.Getenv("SLASH_COMMAND_GUILD_IDS"), " ", "")
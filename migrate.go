@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	storage2 "github.com/automuteus/automuteus/v8/pkg/storage"
+	"github.com/automuteus/automuteus/v8/storage"
+)
+
+// migrateMain shows or changes which schema migrations are applied to the Postgres database given by the POSTGRES_*
+// variables: "migrate status", "migrate up [-to version]" or "migrate down [-steps n]"
+func migrateMain(args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs one of: status, up, down")
+	}
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	to := fs.Int64("to", 0, "only migrate up to and including this version (default: latest)")
+	steps := fs.Int("steps", 1, "how many migrations to revert")
+	err := fs.Parse(args[1:])
+	if err != nil {
+		return err
+	}
+
+	migrations, err := storage2.LoadMigrations(storage.Migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	psql, err := psqlFromEnv()
+	if err != nil {
+		return err
+	}
+	defer psql.Close()
+
+	switch args[0] {
+	case "status":
+		states, err := psql.MigrationStatus(migrations)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			applied := "pending"
+			if state.AppliedAt != nil {
+				applied = "applied " + state.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%s\t%s\n", state.Migration, applied)
+		}
+		return nil
+	case "up":
+		done, err := psql.MigrateUp(migrations, *to)
+		fmt.Fprintf(os.Stderr, "applied %d migrations\n", len(done))
+		return err
+	case "down":
+		done, err := psql.MigrateDown(migrations, *steps)
+		fmt.Fprintf(os.Stderr, "reverted %d migrations\n", len(done))
+		return err
+	default:
+		return fmt.Errorf("unknown migrate command %s; expected status, up or down", args[0])
+	}
+}
+
+// migrateToLatest is run on startup by self-hosted bots, so upgrading the bot upgrades the schema too
+func migrateToLatest(psql *storage2.PsqlInterface) error {
+	migrations, err := storage2.LoadMigrations(storage.Migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	_, err = psql.MigrateUp(migrations, 0)
+	return err
+}
+
+func psqlFromEnv() (*storage2.PsqlInterface, error) {
+	pAddr := os.Getenv("POSTGRES_ADDR")
+	pUser := os.Getenv("POSTGRES_USER")
+	pPass := os.Getenv("POSTGRES_PASS")
+	if pAddr == "" || pUser == "" || pPass == "" {
+		return nil, errors.New("POSTGRES_ADDR, POSTGRES_USER and POSTGRES_PASS are needed to connect to Postgres")
+	}
+	psql := &storage2.PsqlInterface{}
+	err := psql.Init(storage2.ConstructPsqlConnectURL(pAddr, pUser, pPass))
+	if err != nil {
+		return nil, err
+	}
+	return psql, nil
+}
@@ -14,6 +14,8 @@ type GameData struct {
 	Room   string       `json:"room"`
 	Region string       `json:"region"`
 	Map    game.PlayMap `json:"map"`
+	// whether the current (or last) meeting ejected someone
+	Ejected bool `json:"ejected,omitempty"`
 }
 
 func NewGameData() GameData {
@@ -32,6 +34,7 @@ func (auData *GameData) Reset() {
 	auData.Room = ""
 	auData.Region = ""
 	auData.Map = game.EMPTYMAP
+	auData.Ejected = false
 }
 
 func (auData *GameData) SetRoomRegionMap(room, region string, playMap game.PlayMap) {
@@ -47,6 +50,7 @@ func (auData *GameData) GetRoomRegionMap() (string, string, game.PlayMap) {
 func (auData *GameData) setAllAlive() {
 	for i, v := range auData.PlayerData {
 		v.IsAlive = true
+		v.Exiled = false
 		auData.PlayerData[i] = v
 	}
 }
@@ -61,6 +65,9 @@ func (auData *GameData) UpdatePhase(phase game.Phase) (old game.Phase) {
 		} else if phase == game.MENU {
 			auData.Reset()
 		}
+		if phase == game.DISCUSS {
+			auData.Ejected = false
+		}
 	}
 	return old
 }
@@ -73,6 +80,7 @@ func (auData *GameData) UpdatePlayer(player game.Player) (updated, isAliveUpdate
 	}
 	if player.Action == game.EXILED {
 		player.IsDead = true
+		auData.Ejected = true
 	}
 
 	return auData.applyPlayerUpdate(player)
@@ -97,9 +105,12 @@ func (auData *GameData) ClearPlayerData(name string) {
 func (auData *GameData) applyPlayerUpdate(update game.Player) (bool, bool, PlayerData) {
 	if _, ok := auData.PlayerData[update.Name]; !ok {
 		auData.PlayerData[update.Name] = PlayerData{
-			Color:   update.Color,
-			Name:    update.Name,
-			IsAlive: !update.IsDead,
+			Color:      update.Color,
+			Name:       update.Name,
+			IsAlive:    !update.IsDead,
+			Exiled:     update.Action == game.EXILED,
+			Role:       update.Role,
+			IsImpostor: update.IsImpostor,
 		}
 		log.Printf("Added new player instance for %s\n", update.Name)
 		return true, false, auData.PlayerData[update.Name]
@@ -112,6 +123,13 @@ func (auData *GameData) applyPlayerUpdate(update game.Player) (bool, bool, Playe
 			Color:   update.Color,
 			Name:    update.Name,
 			IsAlive: !update.IsDead,
+			// exiled players stay exiled until they're alive again
+			Exiled:     update.IsDead && (update.Action == game.EXILED || playerData.Exiled),
+			Role:       playerData.Role,
+			IsImpostor: playerData.IsImpostor || update.IsImpostor,
+		}
+		if update.Role != "" {
+			p.Role = update.Role
 		}
 		auData.PlayerData[update.Name] = p
 	}
@@ -36,3 +36,42 @@ func TestGameData_UpdatePhase(t *testing.T) {
 		t.Error("GameData was not reset properly when transitioning from TASKS->MENU")
 	}
 }
+
+func TestGameData_Ejected(t *testing.T) {
+	gd := NewGameData()
+	gd.PlayerData["name"] = PlayerData{
+		Color:   game.Red,
+		Name:    "name",
+		IsAlive: true,
+	}
+	gd.UpdatePhase(game.LOBBY)
+	gd.UpdatePhase(game.TASKS)
+	gd.UpdatePhase(game.DISCUSS)
+	gd.UpdatePlayer(game.Player{Action: game.EXILED, Name: "name", Color: game.Red})
+	if !gd.Ejected {
+		t.Error("Expected the meeting to be marked as ending in an ejection")
+	}
+	if !gd.PlayerData["name"].Exiled || gd.PlayerData["name"].IsAlive {
+		t.Error("Expected the player to be dead from being exiled")
+	}
+	gd.UpdatePlayer(game.Player{Action: game.FORCEUPDATED, Name: "name", Color: game.Red, IsDead: true})
+	if !gd.PlayerData["name"].Exiled {
+		t.Error("Expected the player to stay exiled while they're dead")
+	}
+
+	gd.UpdatePhase(game.TASKS)
+	gd.UpdatePhase(game.DISCUSS)
+	if gd.Ejected {
+		t.Error("Expected a new meeting not to start out as an ejection")
+	}
+
+	gd.UpdatePhase(game.LOBBY)
+	if gd.PlayerData["name"].Exiled {
+		t.Error("Expected players to stop being exiled once they're alive again")
+	}
+	gd.UpdatePhase(game.TASKS)
+	gd.UpdatePlayer(game.Player{Action: game.DIED, Name: "name", Color: game.Red, IsDead: true})
+	if gd.PlayerData["name"].Exiled {
+		t.Error("Expected killed players not to count as exiled")
+	}
+}
@@ -0,0 +1,70 @@
+package amongus
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// NormalizeName folds a Discord or in-game name into a form that can be compared loosely: case, full-width/half-width
+// forms (ＡＢＣ/ｱｲｳ), katakana vs hiragana, and whitespace/punctuation are all ignored
+func NormalizeName(name string) string {
+	folded := width.Fold.String(strings.ToLower(name))
+	var b strings.Builder
+	for _, r := range folded {
+		switch {
+		case r >= 'ァ' && r <= 'ヶ':
+			// katakana -> hiragana; the blocks line up exactly
+			b.WriteRune(r - ('ァ' - 'ぁ'))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == 'ー':
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// NameSimilarity scores how alike two names are after normalization, from 0 (nothing in common) to 1 (identical),
+// based on their Levenshtein distance relative to the longer name
+func NameSimilarity(a, b string) float64 {
+	ra := []rune(NormalizeName(a))
+	rb := []rune(NormalizeName(b))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,38 @@
+package amongus
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name, expected string
+	}{
+		{"Alice", "alice"},
+		{"  a l i c e ", "alice"},
+		{"ＡＬＩＣＥ", "alice"},
+		{"ｱﾘｽ", "ありす"},
+		{"アリス", "ありす"},
+		{"ありす", "ありす"},
+		{"ミー・タン", "みーたん"},
+		{"xX_Bob_Xx", "xxbobxx"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeName(tt.name); got != tt.expected {
+			t.Errorf("NormalizeName(%q): expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	if s := NameSimilarity("アリス", "ｱﾘｽ"); s != 1 {
+		t.Errorf("width/kana variants of a name should be identical, got %f", s)
+	}
+	if s := NameSimilarity("Alice", "Alicee"); s < 0.8 {
+		t.Errorf("a one letter typo should still be very similar, got %f", s)
+	}
+	if s := NameSimilarity("Alice", "Bob"); s > 0.2 {
+		t.Errorf("unrelated names should not be similar, got %f", s)
+	}
+	if s := NameSimilarity("", ""); s != 0 {
+		t.Errorf("empty names should never match, got %f", s)
+	}
+}
@@ -8,6 +8,11 @@ type PlayerData struct {
 	Color   int    `json:"color"`
 	Name    string `json:"name"`
 	IsAlive bool   `json:"isAlive"`
+	// dead from being voted off, rather than killed
+	Exiled bool `json:"exiled,omitempty"`
+	// the role the capture reported, if it's modded
+	Role       string `json:"role,omitempty"`
+	IsImpostor bool   `json:"isImpostor,omitempty"`
 }
 
 const UnlinkedPlayerName = "UnlinkedPlayer"
@@ -19,5 +24,15 @@ var UnlinkedPlayer = PlayerData{
 }
 
 func (auData *PlayerData) isDifferent(player game.Player) bool {
-	return auData.IsAlive != !player.IsDead || auData.Color != player.Color || auData.Name != player.Name
+	return auData.IsAlive != !player.IsDead || auData.Color != player.Color || auData.Name != player.Name ||
+		(player.Action == game.EXILED && !auData.Exiled) || (player.Role != "" && auData.Role != player.Role)
+}
+
+// PlayerInfo is the player as the game mode sees them, to tell which team their role plays for
+func (auData *PlayerData) PlayerInfo() game.PlayerInfo {
+	return game.PlayerInfo{
+		Name:       auData.Name,
+		IsImpostor: auData.IsImpostor,
+		Role:       auData.Role,
+	}
 }
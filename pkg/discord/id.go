@@ -14,6 +14,10 @@ func MentionByChannelID(channelID string) string {
 	return fmt.Sprintf("<#%s>", channelID)
 }
 
+func MentionByRoleID(roleID string) string {
+	return fmt.Sprintf("<@&%s>", roleID)
+}
+
 func ExtractChannelIDFromText(mention string) (string, error) {
 	// channel is formatted <#123456>
 	if strings.HasPrefix(mention, "<#") && strings.HasSuffix(mention, ">") {
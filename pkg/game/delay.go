@@ -1,14 +1,42 @@
 package game
 
+import (
+	"strings"
+	"time"
+)
+
+// MeetingOutcome is how a meeting ended, which decides how long the animation before the next round of tasks is
+type MeetingOutcome string
+
+const (
+	Ejection MeetingOutcome = "ejection"
+	Skipped  MeetingOutcome = "skipped"
+)
+
+var MeetingOutcomes = []MeetingOutcome{Ejection, Skipped}
+
 // GameDelays struct
 type GameDelays struct {
-	// maps from origin->new phases, with the integer number of seconds for the delay
-	Delays map[PhaseNameString]map[PhaseNameString]int `json:"delays"`
+	// maps from origin->new phases, with the number of seconds for the delay (fractions allowed)
+	Delays map[PhaseNameString]map[PhaseNameString]float64 `json:"delays"`
+	// Discussion->Tasks, depending on how the meeting ended. Outcomes without a delay here use Delays
+	MeetingEnd map[MeetingOutcome]float64 `json:"meetingEnd,omitempty"`
+	// per-map profiles, by map name. Any delay a profile sets is used on that map instead of the delays above
+	Maps map[string]*GameDelays `json:"maps,omitempty"`
+}
+
+// Transition is everything the delay for a phase change depends on
+type Transition struct {
+	Origin PhaseNameString
+	Dest   PhaseNameString
+	Map    PlayMap
+	// only matters for Discussion->Tasks
+	Ejected bool
 }
 
 func MakeDefaultDelays() GameDelays {
 	return GameDelays{
-		Delays: map[PhaseNameString]map[PhaseNameString]int{
+		Delays: map[PhaseNameString]map[PhaseNameString]float64{
 			PhaseNames[LOBBY]: {
 				PhaseNames[LOBBY]:   0,
 				PhaseNames[TASKS]:   7,
@@ -28,6 +56,115 @@ func MakeDefaultDelays() GameDelays {
 	}
 }
 
-func (gd *GameDelays) GetDelay(origin, dest Phase) int {
+// MakeTransition describes a phase change. The first round of tasks is always timed like Lobby->Tasks (the role
+// reveal), even if the capture only connected once the game was already starting
+func MakeTransition(origin, dest Phase, playMap PlayMap, ejected bool) Transition {
+	if dest == TASKS && origin == MENU {
+		origin = LOBBY
+	}
+	return Transition{
+		Origin:  PhaseNames[origin],
+		Dest:    PhaseNames[dest],
+		Map:     playMap,
+		Ejected: ejected,
+	}
+}
+
+func (t Transition) meetingOutcome() (MeetingOutcome, bool) {
+	if t.Origin != PhaseNames[DISCUSS] || t.Dest != PhaseNames[TASKS] {
+		return "", false
+	}
+	if t.Ejected {
+		return Ejection, true
+	}
+	return Skipped, true
+}
+
+func (gd *GameDelays) GetDelay(origin, dest Phase) float64 {
 	return gd.Delays[PhaseNames[origin]][PhaseNames[dest]]
 }
+
+// Get is the delay this profile sets for a phase change, or for how a meeting ended if outcome isn't empty, without
+// falling back to anything
+func (gd *GameDelays) Get(origin, dest Phase, outcome MeetingOutcome) (float64, bool) {
+	if gd == nil {
+		return 0, false
+	}
+	if outcome != "" {
+		v, ok := gd.MeetingEnd[outcome]
+		return v, ok
+	}
+	v, ok := gd.Delays[PhaseNames[origin]][PhaseNames[dest]]
+	return v, ok
+}
+
+// lookup finds the delay this profile sets for the transition, ignoring map profiles
+func (gd *GameDelays) lookup(t Transition) (float64, bool) {
+	if outcome, ok := t.meetingOutcome(); ok {
+		if v, ok := gd.MeetingEnd[outcome]; ok {
+			return v, true
+		}
+	}
+	v, ok := gd.Delays[t.Origin][t.Dest]
+	return v, ok
+}
+
+// Resolve is how long to wait before muting/unmuting for the transition. The map's profile comes first, then the
+// guild's delays
+func (gd *GameDelays) Resolve(t Transition) time.Duration {
+	if profile, ok := gd.Maps[MapNames[t.Map]]; ok && profile != nil {
+		if v, ok := profile.lookup(t); ok {
+			return secondsToDuration(v)
+		}
+	}
+	v, _ := gd.lookup(t)
+	return secondsToDuration(v)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Profile returns the delays to edit for a map, creating its profile if needed. An empty map name is the guild's
+// delays
+func (gd *GameDelays) Profile(mapName string) *GameDelays {
+	if mapName == "" {
+		return gd
+	}
+	if gd.Maps == nil {
+		gd.Maps = map[string]*GameDelays{}
+	}
+	if gd.Maps[mapName] == nil {
+		gd.Maps[mapName] = &GameDelays{}
+	}
+	return gd.Maps[mapName]
+}
+
+// SetDelay sets the delay for a phase change, or for how a meeting ended if outcome isn't empty (Discussion->Tasks
+// only)
+func (gd *GameDelays) SetDelay(origin, dest Phase, outcome MeetingOutcome, v float64) {
+	if outcome != "" {
+		if gd.MeetingEnd == nil {
+			gd.MeetingEnd = map[MeetingOutcome]float64{}
+		}
+		gd.MeetingEnd[outcome] = v
+		return
+	}
+	if gd.Delays == nil {
+		gd.Delays = map[PhaseNameString]map[PhaseNameString]float64{}
+	}
+	if gd.Delays[PhaseNames[origin]] == nil {
+		gd.Delays[PhaseNames[origin]] = map[PhaseNameString]float64{}
+	}
+	gd.Delays[PhaseNames[origin]][PhaseNames[dest]] = v
+}
+
+// GetMeetingOutcome parses an outcome name, returning "" if it isn't one
+func GetMeetingOutcome(input string) MeetingOutcome {
+	for _, v := range MeetingOutcomes {
+		if strings.EqualFold(input, string(v)) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	delays := MakeDefaultDelays()
+	delays.SetDelay(DISCUSS, TASKS, Ejection, 9.5)
+	delays.Profile(MapNames[AIRSHIP]).SetDelay(LOBBY, TASKS, "", 12)
+
+	tests := []struct {
+		name       string
+		transition Transition
+		want       time.Duration
+	}{
+		{"first round", MakeTransition(LOBBY, TASKS, SKELD, false), 7 * time.Second},
+		{"capture connected mid-start", MakeTransition(MENU, TASKS, SKELD, false), 7 * time.Second},
+		{"ejection", MakeTransition(DISCUSS, TASKS, SKELD, true), 9500 * time.Millisecond},
+		{"skipped falls back to the phases", MakeTransition(DISCUSS, TASKS, SKELD, false), 7 * time.Second},
+		{"map profile", MakeTransition(LOBBY, TASKS, AIRSHIP, false), 12 * time.Second},
+		{"map profile falls back to the guild", MakeTransition(DISCUSS, TASKS, AIRSHIP, true), 9500 * time.Millisecond},
+		{"no delay", MakeTransition(TASKS, DISCUSS, AIRSHIP, false), 0},
+	}
+	for _, test := range tests {
+		if got := delays.Resolve(test.transition); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
@@ -4,4 +4,15 @@ type Lobby struct {
 	LobbyCode string  `json:"LobbyCode"`
 	Region    Region  `json:"Region"`
 	PlayMap   PlayMap `json:"Map"`
+	// only sent by captures that read the lobby's options; older ones leave it unset
+	GameMode LobbyMode `json:"GameMode,omitempty"`
 }
+
+// LobbyMode is the mode the lobby is set to in-game, numbered like the game's own GameModes
+type LobbyMode int
+
+const (
+	UnknownLobbyMode LobbyMode = iota
+	NormalLobbyMode
+	HideAndSeekLobbyMode
+)
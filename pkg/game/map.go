@@ -1,6 +1,9 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type PlayMap int
 
@@ -59,3 +62,13 @@ func FormMapUrl(baseUrl string, mapType PlayMap, detailed bool) string {
 	}
 	return fmt.Sprintf("%s%s.png", baseUrl, mapString)
 }
+
+// GetPlayMapFromString matches one of the MapNames, ignoring case
+func GetPlayMapFromString(input string) (PlayMap, bool) {
+	for playMap, name := range MapNames {
+		if strings.EqualFold(input, name) {
+			return playMap, true
+		}
+	}
+	return EMPTYMAP, false
+}
@@ -19,4 +19,7 @@ type Player struct {
 	Color        int          `json:"Color"`
 	IsDead       bool         `json:"IsDead"`
 	Disconnected bool         `json:"Disconnected"`
+	// only sent by modded captures
+	Role       string `json:"Role,omitempty"`
+	IsImpostor bool   `json:"IsImpostor,omitempty"`
 }
@@ -13,8 +13,54 @@ const (
 	ImpostorDisconnect
 	HumansDisconnect
 	Unknown
+	// hide-and-seek endings
+	HidersByTimer
+	SeekerByKill
 )
 
+// ImpostorWin is whether the impostors (or the seeker) won. Unknown results count as a crewmate win
+func (r GameResult) ImpostorWin() bool {
+	return r == ImpostorByKill || r == ImpostorByVote || r == ImpostorBySabotage || r == ImpostorDisconnect || r == SeekerByKill
+}
+
+// GameResultFromCapture maps the GameOverReason the capture sends, which is the game's own enum, onto a GameResult.
+// The two only match up to HumansDisconnect; the game has no Unknown, and sends its hide-and-seek endings as 7 and 8
+func GameResultFromCapture(reason int16) GameResult {
+	switch reason {
+	case 0:
+		return HumansByVote
+	case 1:
+		return HumansByTask
+	case 2:
+		return ImpostorByVote
+	case 3:
+		return ImpostorByKill
+	case 4:
+		return ImpostorBySabotage
+	case 5:
+		return ImpostorDisconnect
+	case 6:
+		return HumansDisconnect
+	case 7:
+		return HidersByTimer
+	case 8:
+		return SeekerByKill
+	default:
+		return Unknown
+	}
+}
+
+// ParseGameover decodes the capture's gameover payload, with its GameOverReason mapped onto a GameResult
+func ParseGameover(payload []byte) (Gameover, error) {
+	var gameOver Gameover
+	err := json.Unmarshal(payload, &gameOver)
+	if err != nil {
+		return gameOver, err
+	}
+	gameOver.GameOverReason = GameResultFromCapture(int16(gameOver.GameOverReason))
+	return gameOver, nil
+}
+
 func (r *Gameover) Marshal() ([]byte, error) {
 	return json.Marshal(r)
 }
@@ -22,11 +68,16 @@ func (r *Gameover) Marshal() ([]byte, error) {
 type Gameover struct {
 	GameOverReason GameResult   `json:"GameOverReason"`
 	PlayerInfos    []PlayerInfo `json:"PlayerInfos"`
+	// set by modded captures when a role or modifier won on its own, like a Jester getting ejected
+	WinningRole string `json:"WinningRole,omitempty"`
 }
 
 type PlayerInfo struct {
 	Name       string `json:"Name"`
 	IsImpostor bool   `json:"IsImpostor"`
+	// only sent by modded captures
+	Role     string `json:"Role,omitempty"`
+	Modifier string `json:"Modifier,omitempty"`
 }
 
 type GameRole int16
@@ -34,4 +85,5 @@ type GameRole int16
 const (
 	CrewmateRole GameRole = iota
 	ImposterRole
+	NeutralRole
 )
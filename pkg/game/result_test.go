@@ -0,0 +1,41 @@
+package game
+
+import "testing"
+
+func TestParseGameover(t *testing.T) {
+	tests := []struct {
+		payload string
+		result  GameResult
+	}{
+		{`{"GameOverReason":0}`, HumansByVote},
+		{`{"GameOverReason":3}`, ImpostorByKill},
+		{`{"GameOverReason":6}`, HumansDisconnect},
+		// the game's hide-and-seek endings come right after HumansDisconnect, where GameResult has Unknown
+		{`{"GameOverReason":7}`, HidersByTimer},
+		{`{"GameOverReason":8}`, SeekerByKill},
+		{`{"GameOverReason":42}`, Unknown},
+	}
+	for _, tt := range tests {
+		gameOver, err := ParseGameover([]byte(tt.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gameOver.GameOverReason != tt.result {
+			t.Errorf("%s: expected %d, got %d", tt.payload, tt.result, gameOver.GameOverReason)
+		}
+	}
+	if !mustParseGameover(t, `{"GameOverReason":8}`).GameOverReason.ImpostorWin() {
+		t.Error("expected the seeker finding everyone to be an impostor win")
+	}
+	if mustParseGameover(t, `{"GameOverReason":7}`).GameOverReason.ImpostorWin() {
+		t.Error("expected the hiders outlasting the timer not to be an impostor win")
+	}
+}
+
+func mustParseGameover(t *testing.T, payload string) Gameover {
+	gameOver, err := ParseGameover([]byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gameOver
+}
@@ -0,0 +1,249 @@
+package game
+
+import "strings"
+
+// Team is the side a role plays for, which decides whether it won with the game's GameOverReason
+type Team string
+
+const (
+	CrewmateTeam Team = "crewmate"
+	ImpostorTeam Team = "impostor"
+	// NeutralTeam roles play for themselves, and only win when the capture reports them in Gameover.WinningRole
+	NeutralTeam Team = "neutral"
+)
+
+// GameRole is how a Team is stored in users_games.player_role
+func (t Team) GameRole() GameRole {
+	switch t {
+	case ImpostorTeam:
+		return ImposterRole
+	case NeutralTeam:
+		return NeutralRole
+	default:
+		return CrewmateRole
+	}
+}
+
+// Role is a role from the base game or a mod, as named by the capture
+type Role struct {
+	Name string `json:"name"`
+	Team Team   `json:"team"`
+}
+
+var (
+	CrewmateDefault = Role{Name: "Crewmate", Team: CrewmateTeam}
+	ImpostorDefault = Role{Name: "Impostor", Team: ImpostorTeam}
+)
+
+const (
+	ClassicMode     = "classic"
+	TownOfUsMode    = "town-of-us"
+	HideAndSeekMode = "hide-and-seek"
+)
+
+// GameMode is a preset of the roles a guild plays with, so roles reported by the capture count towards the right team
+type GameMode struct {
+	Name  string
+	Roles []Role
+	// the roles of players the capture didn't report a role for; CrewmateDefault and ImpostorDefault if empty
+	Crewmate Role
+	Impostor Role
+	// there are no meetings, so the discussion rules never apply
+	NoMeetings bool
+	// rules that come with the mode. A guild's own rules for the mode take precedence
+	VoiceRules VoiceOverride
+}
+
+var vanillaRoles = []Role{
+	CrewmateDefault,
+	{Name: "Engineer", Team: CrewmateTeam},
+	{Name: "Scientist", Team: CrewmateTeam},
+	{Name: "Tracker", Team: CrewmateTeam},
+	{Name: "Noisemaker", Team: CrewmateTeam},
+	{Name: "GuardianAngel", Team: CrewmateTeam},
+	ImpostorDefault,
+	{Name: "Shapeshifter", Team: ImpostorTeam},
+	{Name: "Phantom", Team: ImpostorTeam},
+}
+
+var GameModes = map[string]GameMode{
+	ClassicMode: {
+		Name:  ClassicMode,
+		Roles: vanillaRoles,
+	},
+	TownOfUsMode: {
+		Name: TownOfUsMode,
+		Roles: append([]Role{
+			{Name: "Sheriff", Team: CrewmateTeam},
+			{Name: "Mayor", Team: CrewmateTeam},
+			{Name: "Medic", Team: CrewmateTeam},
+			{Name: "Seer", Team: CrewmateTeam},
+			{Name: "Snitch", Team: CrewmateTeam},
+			{Name: "Swapper", Team: CrewmateTeam},
+			{Name: "Investigator", Team: CrewmateTeam},
+			{Name: "Spy", Team: CrewmateTeam},
+			{Name: "Vigilante", Team: CrewmateTeam},
+			{Name: "Veteran", Team: CrewmateTeam},
+			{Name: "Altruist", Team: CrewmateTeam},
+			{Name: "Medium", Team: CrewmateTeam},
+			{Name: "Transporter", Team: CrewmateTeam},
+			{Name: "Janitor", Team: ImpostorTeam},
+			{Name: "Morphling", Team: ImpostorTeam},
+			{Name: "Swooper", Team: ImpostorTeam},
+			{Name: "Miner", Team: ImpostorTeam},
+			{Name: "Undertaker", Team: ImpostorTeam},
+			{Name: "Grenadier", Team: ImpostorTeam},
+			{Name: "Blackmailer", Team: ImpostorTeam},
+			{Name: "Traitor", Team: ImpostorTeam},
+			{Name: "Bomber", Team: ImpostorTeam},
+			{Name: "Jester", Team: NeutralTeam},
+			{Name: "Executioner", Team: NeutralTeam},
+			{Name: "Arsonist", Team: NeutralTeam},
+			{Name: "Glitch", Team: NeutralTeam},
+			{Name: "Amnesiac", Team: NeutralTeam},
+			{Name: "Survivor", Team: NeutralTeam},
+			{Name: "Werewolf", Team: NeutralTeam},
+			{Name: "Juggernaut", Team: NeutralTeam},
+			{Name: "Plaguebearer", Team: NeutralTeam},
+			{Name: "Vampire", Team: NeutralTeam},
+		}, vanillaRoles...),
+	},
+	HideAndSeekMode: {
+		Name: HideAndSeekMode,
+		Roles: []Role{
+			hider,
+			seeker,
+		},
+		Crewmate:   hider,
+		Impostor:   seeker,
+		NoMeetings: true,
+		// the seeker can't give the hiders away
+		VoiceRules: VoiceOverride{
+			MuteRules: map[PhaseNameString]map[string]bool{
+				PhaseNames[TASKS]: {
+					VoiceStateKey(ImpostorTeam, AliveState): true,
+				},
+			},
+		},
+	},
+}
+
+var (
+	hider  = Role{Name: "Hider", Team: CrewmateTeam}
+	seeker = Role{Name: "Seeker", Team: ImpostorTeam}
+)
+
+// short names players know the modes by
+var gameModeAliases = map[string]string{
+	"hns": HideAndSeekMode,
+}
+
+// GetGameMode looks up a preset by name, defaulting to classic
+func GetGameMode(name string) GameMode {
+	name = strings.ToLower(name)
+	if alias, ok := gameModeAliases[name]; ok {
+		name = alias
+	}
+	if mode, ok := GameModes[name]; ok {
+		return mode
+	}
+	return GameModes[ClassicMode]
+}
+
+// IsHideAndSeek is whether the mode is played without meetings, by a seeker hunting down the hiders
+func (mode GameMode) IsHideAndSeek() bool {
+	return mode.Name == HideAndSeekMode
+}
+
+// DefaultRole is the role of a player on the team that the capture didn't report a role for
+func (mode GameMode) DefaultRole(team Team) Role {
+	switch team {
+	case ImpostorTeam:
+		if mode.Impostor.Name != "" {
+			return mode.Impostor
+		}
+		return ImpostorDefault
+	case CrewmateTeam:
+		if mode.Crewmate.Name != "" {
+			return mode.Crewmate
+		}
+		return CrewmateDefault
+	default:
+		return Role{Team: team}
+	}
+}
+
+// VoicePhase is the phase whose voice rules apply during the phase the capture reported
+func (mode GameMode) VoicePhase(phase Phase) Phase {
+	if mode.NoMeetings && phase == DISCUSS {
+		return TASKS
+	}
+	return phase
+}
+
+// MaxRoleNameLength is the most characters of a role's name that are kept; users_games.player_role_name is a VARCHAR(32)
+const MaxRoleNameLength = 32
+
+// Role resolves the role the capture reported for a player. Roles the mode doesn't know about are crewmates or
+// impostors depending on IsImpostor, under the name the capture used (cut to MaxRoleNameLength)
+func (mode GameMode) Role(info PlayerInfo) Role {
+	if info.Role == "" {
+		if info.IsImpostor {
+			return mode.DefaultRole(ImpostorTeam)
+		}
+		return mode.DefaultRole(CrewmateTeam)
+	}
+	for _, role := range mode.Roles {
+		if strings.EqualFold(role.Name, info.Role) {
+			return role
+		}
+	}
+	name := info.Role
+	if runes := []rune(name); len(runes) > MaxRoleNameLength {
+		name = string(runes[:MaxRoleNameLength])
+	}
+	if info.IsImpostor {
+		return Role{Name: name, Team: ImpostorTeam}
+	}
+	return Role{Name: name, Team: CrewmateTeam}
+}
+
+// PlayerResult is how a game ended for one player
+type PlayerResult struct {
+	Name string
+	Role Role
+	Won  bool
+}
+
+// Results decides who won the game. A role or modifier (like Lovers) named by WinningRole wins on its own; otherwise
+// the team the GameOverReason favors wins, and neutral roles lose
+func (mode GameMode) Results(gameOver Gameover) []PlayerResult {
+	winningTeam := CrewmateTeam
+	if gameOver.GameOverReason.ImpostorWin() {
+		winningTeam = ImpostorTeam
+	}
+	results := make([]PlayerResult, 0, len(gameOver.PlayerInfos))
+	for _, info := range gameOver.PlayerInfos {
+		role := mode.Role(info)
+		won := role.Team == winningTeam
+		if gameOver.WinningRole != "" {
+			won = strings.EqualFold(gameOver.WinningRole, role.Name) || strings.EqualFold(gameOver.WinningRole, info.Modifier)
+		}
+		results = append(results, PlayerResult{
+			Name: info.Name,
+			Role: role,
+			Won:  won,
+		})
+	}
+	return results
+}
+
+// Result finds how the game ended for the player with the in-game name
+func (mode GameMode) Result(gameOver Gameover, name string) (PlayerResult, bool) {
+	for _, v := range mode.Results(gameOver) {
+		if strings.EqualFold(v.Name, name) {
+			return v, true
+		}
+	}
+	return PlayerResult{}, false
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGameMode_Role(t *testing.T) {
+	classic := GetGameMode("")
+	if role := classic.Role(PlayerInfo{Name: "a", IsImpostor: true}); role != ImpostorDefault {
+		t.Errorf("expected impostors without a role name to be plain impostors, got %v", role)
+	}
+	if role := classic.Role(PlayerInfo{Name: "a", Role: "shapeshifter", IsImpostor: true}); role.Name != "Shapeshifter" || role.Team != ImpostorTeam {
+		t.Errorf("expected role names to match regardless of case, got %v", role)
+	}
+	// classic doesn't know about Jesters, so they count as crewmates like the base game would report them
+	if role := classic.Role(PlayerInfo{Name: "a", Role: "Jester"}); role.Name != "Jester" || role.Team != CrewmateTeam {
+		t.Errorf("expected unknown roles to keep their name and play for the crewmates, got %v", role)
+	}
+	// has to fit users_games.player_role_name, in characters rather than bytes
+	long := strings.Repeat("ロール", 20)
+	if role := classic.Role(PlayerInfo{Name: "a", Role: long}); role.Name != string([]rune(long)[:MaxRoleNameLength]) {
+		t.Errorf("expected long role names to be cut to %d characters, got %s", MaxRoleNameLength, role.Name)
+	}
+	if role := GetGameMode(TownOfUsMode).Role(PlayerInfo{Name: "a", Role: "Jester"}); role.Team != NeutralTeam {
+		t.Errorf("expected Town of Us Jesters to be neutral, got %v", role)
+	}
+	hns := GetGameMode("hns")
+	if !hns.IsHideAndSeek() {
+		t.Fatalf("expected hns to be hide-and-seek, got %s", hns.Name)
+	}
+	if role := hns.Role(PlayerInfo{Name: "a", IsImpostor: true}); role.Name != "Seeker" || role.Team != ImpostorTeam {
+		t.Errorf("expected the impostor to be the seeker in hide-and-seek, got %v", role)
+	}
+	if role := hns.Role(PlayerInfo{Name: "a"}); role.Name != "Hider" || role.Team != CrewmateTeam {
+		t.Errorf("expected crewmates to be hiders in hide-and-seek, got %v", role)
+	}
+}
+
+func TestGameMode_Results(t *testing.T) {
+	players := []PlayerInfo{
+		{Name: "crew", Role: "Sheriff"},
+		{Name: "imp", Role: "Janitor", IsImpostor: true},
+		{Name: "jester", Role: "Jester"},
+		{Name: "lover", Role: "Medic", Modifier: "Lovers"},
+	}
+	mode := GetGameMode(TownOfUsMode)
+
+	tests := []struct {
+		name     string
+		gameOver Gameover
+		winners  map[string]bool
+	}{
+		{"crewmates by task", Gameover{GameOverReason: HumansByTask, PlayerInfos: players}, map[string]bool{"crew": true, "lover": true}},
+		{"impostors by kill", Gameover{GameOverReason: ImpostorByKill, PlayerInfos: players}, map[string]bool{"imp": true}},
+		{"jester ejected", Gameover{GameOverReason: Unknown, PlayerInfos: players, WinningRole: "Jester"}, map[string]bool{"jester": true}},
+		{"lovers", Gameover{GameOverReason: Unknown, PlayerInfos: players, WinningRole: "lovers"}, map[string]bool{"lover": true}},
+	}
+	for _, test := range tests {
+		results := mode.Results(test.gameOver)
+		if len(results) != len(players) {
+			t.Fatalf("%s: expected a result for every player, got %v", test.name, results)
+		}
+		for _, v := range results {
+			if v.Won != test.winners[v.Name] {
+				t.Errorf("%s: expected %s won to be %t", test.name, v.Name, test.winners[v.Name])
+			}
+		}
+	}
+
+	if result, ok := mode.Result(Gameover{GameOverReason: ImpostorByVote, PlayerInfos: players}, "IMP"); !ok || !result.Won || result.Role.Team.GameRole() != ImposterRole {
+		t.Errorf("expected to find the impostor's result by name, got %v", result)
+	}
+}
@@ -3,19 +3,99 @@ package game
 type VoiceRules struct {
 	MuteRules map[PhaseNameString]map[string]bool
 	DeafRules map[PhaseNameString]map[string]bool
+
+	// rules for a game mode (like hide-and-seek), keyed by the mode's name and layered on top of the rules above
+	ModeRules map[string]VoiceOverride `json:",omitempty"`
+
+	// overrides keyed by Discord user or role ID, layered on top of the rules above
+	UserOverrides map[string]VoiceOverride `json:",omitempty"`
+	RoleOverrides map[string]VoiceOverride `json:",omitempty"`
+}
+
+// the states a rule can be keyed by. A rule for exiled or killed players is more specific than one for dead players,
+// and a rule for a team (like "impostor-alive") is more specific than one for everybody
+const (
+	AliveState  = "alive"
+	DeadState   = "dead"
+	ExiledState = "exiled"
+	KilledState = "killed"
+)
+
+var VoiceStates = []string{AliveState, DeadState, ExiledState, KilledState}
+
+// VoicePlayer is everything about a player that the voice rules can depend on
+type VoicePlayer struct {
+	IsAlive bool
+	// dead from being voted off, rather than killed
+	Exiled bool
+	// the team the player's role plays for, if the capture reported it
+	Team Team
+}
+
+// VoiceStateKey is the key for a rule about players in the state, on the team if it isn't empty
+func VoiceStateKey(team Team, state string) string {
+	if team == "" {
+		return state
+	}
+	return string(team) + "-" + state
+}
+
+// StateKeys are the keys of the rules that could apply to the player, most specific first
+func (player VoicePlayer) StateKeys() []string {
+	states := []string{AliveState}
+	if !player.IsAlive {
+		states = []string{KilledState, DeadState}
+		if player.Exiled {
+			states[0] = ExiledState
+		}
+	}
+	keys := make([]string, 0, 2*len(states))
+	if player.Team != "" {
+		for _, state := range states {
+			keys = append(keys, VoiceStateKey(player.Team, state))
+		}
+	}
+	return append(keys, states...)
 }
 
 func (rules *VoiceRules) GetVoiceState(isAlive, isTracked bool, phase Phase) (bool, bool) {
+	return rules.GetPlayerVoiceState(VoicePlayer{IsAlive: isAlive}, isTracked, phase, "")
+}
+
+// GetPlayerVoiceState decides whether the player should be muted and deafened. The most specific rule for the player
+// applies, with the game mode's rules (if any) taking precedence over the guild's, and the guild's rules for the mode
+// over the rules the mode comes with
+func (rules *VoiceRules) GetPlayerVoiceState(player VoicePlayer, isTracked bool, phase Phase, mode string) (bool, bool) {
 	if !isTracked {
 		return false, false
 	}
-	aliveStr := "dead"
-	if isAlive {
-		aliveStr = "alive"
-	}
-	phaseStr := PhaseNames[phase]
+	phase = GetGameMode(mode).VoicePhase(phase)
+	return rules.lookup(true, player, phase, mode), rules.lookup(false, player, phase, mode)
+}
 
-	return rules.MuteRules[phaseStr][aliveStr], rules.DeafRules[phaseStr][aliveStr]
+func (rules *VoiceRules) lookup(isMute bool, player VoicePlayer, phase Phase, mode string) bool {
+	keys := player.StateKeys()
+	modeRules := []VoiceOverride{rules.ModeRules[mode]}
+	if builtin, ok := GameModes[mode]; ok {
+		modeRules = append(modeRules, builtin.VoiceRules)
+	}
+	for _, override := range modeRules {
+		for _, key := range keys {
+			if v, ok := override.Get(isMute, phase, key); ok {
+				return v
+			}
+		}
+	}
+	base := rules.DeafRules
+	if isMute {
+		base = rules.MuteRules
+	}
+	for _, key := range keys {
+		if v, ok := base[PhaseNames[phase]][key]; ok {
+			return v
+		}
+	}
+	return false
 }
 
 func MakeMuteAndDeafenRules() VoiceRules {
@@ -51,3 +131,196 @@ func MakeMuteAndDeafenRules() VoiceRules {
 	}
 	return rules
 }
+
+// VoiceOverride forces the mute/deafen state of a single user or role. It only holds the phase and alive/dead
+// combinations it changes; everything else falls through to the guild-wide rules
+type VoiceOverride struct {
+	MuteRules map[PhaseNameString]map[string]bool `json:",omitempty"`
+	DeafRules map[PhaseNameString]map[string]bool `json:",omitempty"`
+}
+
+func (override *VoiceOverride) rules(isMute bool) map[PhaseNameString]map[string]bool {
+	if isMute {
+		return override.MuteRules
+	}
+	return override.DeafRules
+}
+
+// Get returns the overridden value, and whether there is an override at all
+func (override *VoiceOverride) Get(isMute bool, phase Phase, alive string) (bool, bool) {
+	v, ok := override.rules(isMute)[PhaseNames[phase]][alive]
+	return v, ok
+}
+
+func (override *VoiceOverride) Set(isMute bool, phase Phase, alive string, val bool) {
+	if isMute && override.MuteRules == nil {
+		override.MuteRules = map[PhaseNameString]map[string]bool{}
+	} else if !isMute && override.DeafRules == nil {
+		override.DeafRules = map[PhaseNameString]map[string]bool{}
+	}
+	rules := override.rules(isMute)
+	if rules[PhaseNames[phase]] == nil {
+		rules[PhaseNames[phase]] = map[string]bool{}
+	}
+	rules[PhaseNames[phase]][alive] = val
+}
+
+// Clear removes the override so the guild rule applies again
+func (override *VoiceOverride) Clear(isMute bool, phase Phase, alive string) {
+	rules := override.rules(isMute)
+	delete(rules[PhaseNames[phase]], alive)
+	if len(rules[PhaseNames[phase]]) == 0 {
+		delete(rules, PhaseNames[phase])
+	}
+}
+
+func (override *VoiceOverride) IsEmpty() bool {
+	return len(override.MuteRules) == 0 && len(override.DeafRules) == 0
+}
+
+// GetVoiceStateFor is GetPlayerVoiceState for a specific member, with their user and role overrides applied
+func (rules *VoiceRules) GetVoiceStateFor(userID string, roleIDs []string, player VoicePlayer, isTracked bool, phase Phase, mode string) (bool, bool) {
+	if !isTracked {
+		return false, false
+	}
+	mute, deaf := rules.GetPlayerVoiceState(player, isTracked, phase, mode)
+	return rules.ApplyOverrides(userID, roleIDs, player.IsAlive, GetGameMode(mode).VoicePhase(phase), mute, deaf)
+}
+
+// GetUnlinkedVoiceStateFor is GetVoiceStateFor a member who isn't linked to a player, but is tracked because of their
+// overrides. The guild rules are for players, so only the overrides apply
+func (rules *VoiceRules) GetUnlinkedVoiceStateFor(userID string, roleIDs []string, isTracked bool, phase Phase, mode string) (bool, bool) {
+	if !isTracked {
+		return false, false
+	}
+	return rules.ApplyUnlinkedOverrides(userID, roleIDs, GetGameMode(mode).VoicePhase(phase), false, false)
+}
+
+func (rules *VoiceRules) modeRules(mode string) VoiceOverride {
+	if rules.ModeRules == nil {
+		rules.ModeRules = map[string]VoiceOverride{}
+	}
+	return rules.ModeRules[mode]
+}
+
+// GetRule returns the rule for players in the state (see VoiceStateKey) during the phase, and whether there is one.
+// An empty mode is the guild's rules
+func (rules *VoiceRules) GetRule(isMute bool, phase Phase, key, mode string) (bool, bool) {
+	if mode != "" {
+		modeRules := rules.ModeRules[mode]
+		return modeRules.Get(isMute, phase, key)
+	}
+	base := VoiceOverride{MuteRules: rules.MuteRules, DeafRules: rules.DeafRules}
+	return base.Get(isMute, phase, key)
+}
+
+func (rules *VoiceRules) SetRule(isMute bool, phase Phase, key, mode string, val bool) {
+	if mode != "" {
+		modeRules := rules.modeRules(mode)
+		modeRules.Set(isMute, phase, key, val)
+		rules.ModeRules[mode] = modeRules
+		return
+	}
+	base := VoiceOverride{MuteRules: rules.MuteRules, DeafRules: rules.DeafRules}
+	base.Set(isMute, phase, key, val)
+	rules.MuteRules, rules.DeafRules = base.MuteRules, base.DeafRules
+}
+
+// ClearRule removes a rule, so a less specific one applies again. The alive and dead rules of the guild can't be
+// removed, since there would be nothing to fall back to
+func (rules *VoiceRules) ClearRule(isMute bool, phase Phase, key, mode string) bool {
+	if mode != "" {
+		modeRules, ok := rules.ModeRules[mode]
+		if _, set := modeRules.Get(isMute, phase, key); !ok || !set {
+			return false
+		}
+		modeRules.Clear(isMute, phase, key)
+		if modeRules.IsEmpty() {
+			delete(rules.ModeRules, mode)
+		} else {
+			rules.ModeRules[mode] = modeRules
+		}
+		return true
+	}
+	if key == AliveState || key == DeadState {
+		return false
+	}
+	base := VoiceOverride{MuteRules: rules.MuteRules, DeafRules: rules.DeafRules}
+	if _, set := base.Get(isMute, phase, key); !set {
+		return false
+	}
+	base.Clear(isMute, phase, key)
+	return true
+}
+
+// ApplyOverrides layers a member's overrides on top of an already decided mute/deafen state.
+// User overrides win over role overrides, whatever the member's roles override. When several of the member's roles
+// override the same state, muting/deafening wins, so an override can never leak voice to somebody who should be muted
+func (rules *VoiceRules) ApplyOverrides(userID string, roleIDs []string, isAlive bool, phase Phase, mute, deaf bool) (bool, bool) {
+	aliveStr := "dead"
+	if isAlive {
+		aliveStr = "alive"
+	}
+	if v, ok := rules.override(true, userID, roleIDs, aliveStr, phase); ok {
+		mute = v
+	}
+	if v, ok := rules.override(false, userID, roleIDs, aliveStr, phase); ok {
+		deaf = v
+	}
+	return mute, deaf
+}
+
+// ApplyUnlinkedOverrides is ApplyOverrides for a member who isn't linked to a player, so is neither alive nor dead.
+// Whatever the override says for the phase applies; if it says different things for alive and dead players,
+// muting/deafening wins
+func (rules *VoiceRules) ApplyUnlinkedOverrides(userID string, roleIDs []string, phase Phase, mute, deaf bool) (bool, bool) {
+	return rules.unlinkedOverride(true, userID, roleIDs, phase, mute), rules.unlinkedOverride(false, userID, roleIDs, phase, deaf)
+}
+
+func (rules *VoiceRules) unlinkedOverride(isMute bool, userID string, roleIDs []string, phase Phase, current bool) bool {
+	overridden := false
+	result := false
+	for _, alive := range []string{"alive", "dead"} {
+		if v, ok := rules.override(isMute, userID, roleIDs, alive, phase); ok {
+			overridden = true
+			result = result || v
+		}
+	}
+	if overridden {
+		return result
+	}
+	return current
+}
+
+// override is the member's override for the state, and whether they have one
+func (rules *VoiceRules) override(isMute bool, userID string, roleIDs []string, alive string, phase Phase) (bool, bool) {
+	if override, ok := rules.UserOverrides[userID]; ok {
+		if v, ok := override.Get(isMute, phase, alive); ok {
+			return v, true
+		}
+	}
+	overridden := false
+	result := false
+	for _, roleID := range roleIDs {
+		if override, ok := rules.RoleOverrides[roleID]; ok {
+			if v, ok := override.Get(isMute, phase, alive); ok {
+				overridden = true
+				result = result || v
+			}
+		}
+	}
+	return result, overridden
+}
+
+// HasOverride reports whether the member has any user or role override at all
+func (rules *VoiceRules) HasOverride(userID string, roleIDs []string) bool {
+	if override, ok := rules.UserOverrides[userID]; ok && !override.IsEmpty() {
+		return true
+	}
+	for _, roleID := range roleIDs {
+		if override, ok := rules.RoleOverrides[roleID]; ok && !override.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package game
+
+import "testing"
+
+func TestGetPlayerVoiceState(t *testing.T) {
+	rules := MakeMuteAndDeafenRules()
+	// impostors can talk to each other during tasks
+	rules.SetRule(true, TASKS, VoiceStateKey(ImpostorTeam, AliveState), "", false)
+	rules.SetRule(false, TASKS, VoiceStateKey(ImpostorTeam, AliveState), "", false)
+	// exiled players get to explain themselves once the meeting is over, killed players don't
+	rules.SetRule(true, DISCUSS, ExiledState, "", false)
+	// nobody talks while tasks are going in hide-and-seek, alive or dead
+	rules.SetRule(true, TASKS, DeadState, "hide-and-seek", true)
+	rules.SetRule(true, TASKS, VoiceStateKey(ImpostorTeam, AliveState), "hide-and-seek", true)
+
+	tests := []struct {
+		name       string
+		player     VoicePlayer
+		tracked    bool
+		phase      Phase
+		mode       string
+		mute, deaf bool
+	}{
+		{"untracked", VoicePlayer{IsAlive: true}, false, TASKS, "", false, false},
+		{"alive crewmate in tasks", VoicePlayer{IsAlive: true, Team: CrewmateTeam}, true, TASKS, "", true, true},
+		{"alive without a known team", VoicePlayer{IsAlive: true}, true, TASKS, "", true, true},
+		{"alive impostor in tasks", VoicePlayer{IsAlive: true, Team: ImpostorTeam}, true, TASKS, "", false, false},
+		{"dead impostor falls back to dead", VoicePlayer{Team: ImpostorTeam}, true, TASKS, "", false, false},
+		{"killed in discussion", VoicePlayer{}, true, DISCUSS, "", true, false},
+		{"exiled in discussion", VoicePlayer{Exiled: true}, true, DISCUSS, "", false, false},
+		{"exiled impostor in discussion", VoicePlayer{Exiled: true, Team: ImpostorTeam}, true, DISCUSS, "", false, false},
+		{"dead in hide-and-seek", VoicePlayer{}, true, TASKS, "hide-and-seek", true, false},
+		{"mode beats the team rule", VoicePlayer{IsAlive: true, Team: ImpostorTeam}, true, TASKS, "hide-and-seek", true, false},
+		{"mode without a rule falls back", VoicePlayer{IsAlive: true}, true, LOBBY, "hide-and-seek", false, false},
+		{"no discussion in hide-and-seek", VoicePlayer{IsAlive: true}, true, DISCUSS, "hide-and-seek", true, true},
+		{"unknown mode", VoicePlayer{Exiled: true}, true, DISCUSS, "classic", false, false},
+	}
+	for _, tt := range tests {
+		mute, deaf := rules.GetPlayerVoiceState(tt.player, tt.tracked, tt.phase, tt.mode)
+		if mute != tt.mute || deaf != tt.deaf {
+			t.Errorf("%s: expected mute=%v deaf=%v, got mute=%v deaf=%v", tt.name, tt.mute, tt.deaf, mute, deaf)
+		}
+	}
+}
+
+func TestHideAndSeekVoiceRules(t *testing.T) {
+	rules := MakeMuteAndDeafenRules()
+	// everybody alive can talk during tasks, like in proximity chat lobbies
+	rules.SetRule(true, TASKS, AliveState, "", false)
+	rules.SetRule(false, TASKS, AliveState, "", false)
+	seeker := VoicePlayer{IsAlive: true, Team: ImpostorTeam}
+
+	if mute, deaf := rules.GetPlayerVoiceState(seeker, true, TASKS, HideAndSeekMode); !mute || deaf {
+		t.Errorf("expected the seeker to be muted but not deafened, got mute=%v deaf=%v", mute, deaf)
+	}
+	if mute, _ := rules.GetPlayerVoiceState(seeker, true, TASKS, ClassicMode); mute {
+		t.Error("expected impostors to follow the guild's rules outside of hide-and-seek")
+	}
+	if mute, _ := rules.GetPlayerVoiceState(VoicePlayer{IsAlive: true, Team: CrewmateTeam}, true, TASKS, HideAndSeekMode); mute {
+		t.Error("expected hiders to follow the guild's rules")
+	}
+	// the guild can still let the seeker talk
+	rules.SetRule(true, TASKS, VoiceStateKey(ImpostorTeam, AliveState), HideAndSeekMode, false)
+	if mute, _ := rules.GetPlayerVoiceState(seeker, true, TASKS, HideAndSeekMode); mute {
+		t.Error("expected the guild's rules for the mode to win over the mode's own")
+	}
+}
+
+func TestVoicePlayer_StateKeys(t *testing.T) {
+	keys := VoicePlayer{Exiled: true, Team: NeutralTeam}.StateKeys()
+	expected := []string{"neutral-exiled", "neutral-dead", "exiled", "dead"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+	for i := range keys {
+		if keys[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, keys)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	rules := MakeMuteAndDeafenRules()
+	rules.UserOverrides = map[string]VoiceOverride{}
+	rules.RoleOverrides = map[string]VoiceOverride{}
+
+	user := VoiceOverride{}
+	user.Set(true, TASKS, "alive", false)
+	rules.UserOverrides["user"] = user
+	mod, streamer := VoiceOverride{}, VoiceOverride{}
+	mod.Set(true, TASKS, "alive", true)
+	mod.Set(false, TASKS, "alive", false)
+	streamer.Set(false, TASKS, "alive", true)
+	streamer.Set(true, LOBBY, "alive", true)
+	streamer.Set(true, LOBBY, "dead", false)
+	rules.RoleOverrides["mod"], rules.RoleOverrides["streamer"] = mod, streamer
+
+	tests := []struct {
+		name       string
+		userID     string
+		roles      []string
+		phase      Phase
+		mute, deaf bool
+	}{
+		// the role would mute them, but their own override says otherwise, whichever order the roles come in
+		{"user override wins over roles", "user", []string{"mod", "streamer"}, TASKS, false, true},
+		{"user override wins over roles in any order", "user", []string{"streamer", "mod"}, TASKS, false, true},
+		{"roles that disagree mute", "other", []string{"mod", "streamer"}, TASKS, true, true},
+		{"no override keeps the rule", "other", nil, TASKS, true, true},
+	}
+	for _, tt := range tests {
+		mute, deaf := rules.ApplyOverrides(tt.userID, tt.roles, true, tt.phase, true, true)
+		if mute != tt.mute || deaf != tt.deaf {
+			t.Errorf("%s: expected mute=%v deaf=%v, got mute=%v deaf=%v", tt.name, tt.mute, tt.deaf, mute, deaf)
+		}
+	}
+
+	// a member who isn't a player only gets what their overrides say, not the rules for dead players
+	if mute, deaf := rules.GetUnlinkedVoiceStateFor("user", nil, true, TASKS, ""); mute || deaf {
+		t.Errorf("expected an unlinked member to follow their override, got mute=%v deaf=%v", mute, deaf)
+	}
+	if mute, deaf := rules.GetUnlinkedVoiceStateFor("other", []string{"streamer"}, true, TASKS, ""); mute || !deaf {
+		t.Errorf("expected an unlinked member to be deafened by their role, got mute=%v deaf=%v", mute, deaf)
+	}
+	// the override differs for alive and dead, so muting wins
+	if mute, _ := rules.GetUnlinkedVoiceStateFor("other", []string{"streamer"}, true, LOBBY, ""); !mute {
+		t.Error("expected an unlinked member to be muted when their override disagrees between alive and dead")
+	}
+	if mute, deaf := rules.GetUnlinkedVoiceStateFor("user", nil, false, TASKS, ""); mute || deaf {
+		t.Error("expected an untracked member to be left alone")
+	}
+}
@@ -0,0 +1,56 @@
+package rating
+
+import "math"
+
+const (
+	// PriorGames is how many games at the guild's average every record is padded with, so a player that won their
+	// only game isn't taken for a sure win
+	PriorGames = 5.0
+	// OutlierMargin is how far from the expected win rate a record has to be to stand out
+	OutlierMargin = 0.15
+	// LopsidedMargin is how far from the baseline a lobby's ImpostorWinChance has to be for it to be lopsided
+	LopsidedMargin = 0.1
+)
+
+// Record is how a player (or a pair of them) did in one role
+type Record struct {
+	Wins  int64
+	Games int64
+}
+
+// WinRate is the share of games won, pulled towards prior the fewer games there are
+func (r Record) WinRate(prior float64) float64 {
+	return (float64(r.Wins) + prior*PriorGames) / (float64(r.Games) + PriorGames)
+}
+
+// IsOutlier is whether the record is at least minGames long, and its win rate is OutlierMargin or more away from
+// expected
+func (r Record) IsOutlier(expected float64, minGames int64) bool {
+	if r.Games == 0 || r.Games < minGames {
+		return false
+	}
+	return math.Abs(float64(r.Wins)/float64(r.Games)-expected) >= OutlierMargin
+}
+
+type LobbyPlayer struct {
+	ID       string
+	Crewmate Record
+	Impostor Record
+}
+
+// ImpostorWinChance is how likely the impostors are to win a game between the players, whoever of them ends up
+// impostor. baseline is how often impostors win on the guild. It weighs how well the average player does as impostor
+// against how well the average player does as crewmate, so a lobby of average players is right at the baseline
+func ImpostorWinChance(players []LobbyPlayer, baseline float64) float64 {
+	if len(players) == 0 {
+		return baseline
+	}
+	impostor, crewmate := 0.0, 0.0
+	for _, p := range players {
+		impostor += p.Impostor.WinRate(baseline)
+		crewmate += p.Crewmate.WinRate(1 - baseline)
+	}
+	impostor /= float64(len(players))
+	crewmate /= float64(len(players))
+	return (impostor + 1 - crewmate) / 2
+}
@@ -0,0 +1,54 @@
+package rating
+
+import "testing"
+
+func TestImpostorWinChance(t *testing.T) {
+	if !closeTo(ImpostorWinChance(nil, 0.4), 0.4) {
+		t.Error("expected an empty lobby to be at the baseline")
+	}
+	// 4/10 as impostor and 6/10 as crewmate is what everyone does at a 40% baseline
+	average := LobbyPlayer{Crewmate: Record{Wins: 6, Games: 10}, Impostor: Record{Wins: 4, Games: 10}}
+	if chance := ImpostorWinChance([]LobbyPlayer{average, average}, 0.4); !closeTo(chance, 0.4) {
+		t.Errorf("expected average players to be at the baseline, got %f", chance)
+	}
+	newcomer := LobbyPlayer{}
+	if chance := ImpostorWinChance([]LobbyPlayer{newcomer}, 0.4); !closeTo(chance, 0.4) {
+		t.Errorf("expected players without games to be at the baseline, got %f", chance)
+	}
+	killer := LobbyPlayer{Crewmate: Record{Wins: 6, Games: 10}, Impostor: Record{Wins: 9, Games: 10}}
+	if chance := ImpostorWinChance([]LobbyPlayer{average, killer}, 0.4); chance <= 0.4 {
+		t.Errorf("expected a strong impostor to tip the lobby, got %f", chance)
+	}
+	weakCrew := LobbyPlayer{Crewmate: Record{Wins: 1, Games: 10}, Impostor: Record{Wins: 4, Games: 10}}
+	if chance := ImpostorWinChance([]LobbyPlayer{average, weakCrew}, 0.4); chance <= 0.4 {
+		t.Errorf("expected a weak crewmate to tip the lobby, got %f", chance)
+	}
+}
+
+func TestRecordWinRate(t *testing.T) {
+	if !closeTo(Record{Wins: 1, Games: 1}.WinRate(0.5), 7.0/12) {
+		t.Errorf("expected a single win to barely move the win rate, got %f", Record{Wins: 1, Games: 1}.WinRate(0.5))
+	}
+	if !closeTo(Record{}.WinRate(0.3), 0.3) {
+		t.Error("expected no games to be the prior")
+	}
+}
+
+func TestRecordIsOutlier(t *testing.T) {
+	tests := []struct {
+		record   Record
+		minGames int64
+		outlier  bool
+	}{
+		{Record{Wins: 8, Games: 10}, 3, true},
+		{Record{Wins: 1, Games: 10}, 3, true},
+		{Record{Wins: 5, Games: 10}, 3, false},
+		{Record{Wins: 2, Games: 2}, 3, false},
+		{Record{}, 0, false},
+	}
+	for _, tt := range tests {
+		if tt.record.IsOutlier(0.5, tt.minGames) != tt.outlier {
+			t.Errorf("expected %v to be an outlier: %v", tt.record, tt.outlier)
+		}
+	}
+}
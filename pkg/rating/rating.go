@@ -0,0 +1,61 @@
+package rating
+
+import "math"
+
+// Ratings are Elo ratings for teams: a team is rated as the average of its players, and after a game every player on
+// it moves by how surprising the result was for the team
+
+const (
+	// Default is what every player starts at, for every role on every guild
+	Default = 1500.0
+	// K is the most an established rating can move in one game
+	K = 24.0
+	// ProvisionalGames is how many games a rating moves twice as fast for, so new players find their level sooner
+	ProvisionalGames = 10
+	// a team rated Scale points above another is expected to win 10 times as often
+	Scale = 400.0
+)
+
+type Player struct {
+	Rating float64
+	Games  int
+}
+
+// TeamRating is the average rating of the team. Teams without anyone rated on them (say, the impostors weren't
+// linked) are rated like new players
+func TeamRating(team []Player) float64 {
+	if len(team) == 0 {
+		return Default
+	}
+	sum := 0.0
+	for _, p := range team {
+		sum += p.Rating
+	}
+	return sum / float64(len(team))
+}
+
+// Expected is the chance that a team rated a beats a team rated b
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/Scale))
+}
+
+// Update is the new ratings of the players after the winners beat the losers, in the order they were passed in
+func Update(winners, losers []Player) ([]float64, []float64) {
+	expected := Expected(TeamRating(winners), TeamRating(losers))
+	return adjust(winners, 1-expected), adjust(losers, -(1 - expected))
+}
+
+func adjust(team []Player, surprise float64) []float64 {
+	ratings := make([]float64, len(team))
+	for i, p := range team {
+		ratings[i] = p.Rating + kFactor(p.Games)*surprise
+	}
+	return ratings
+}
+
+func kFactor(games int) float64 {
+	if games < ProvisionalGames {
+		return 2 * K
+	}
+	return K
+}
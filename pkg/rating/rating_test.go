@@ -0,0 +1,50 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestExpected(t *testing.T) {
+	if !closeTo(Expected(1500, 1500), 0.5) {
+		t.Errorf("expected even teams to be even, got %f", Expected(1500, 1500))
+	}
+	if !closeTo(Expected(1900, 1500), 10.0/11) {
+		t.Errorf("expected a team %.0f points ahead to win 10 times as often, got %f", Scale, Expected(1900, 1500))
+	}
+	if !closeTo(Expected(1600, 1500)+Expected(1500, 1600), 1) {
+		t.Error("expected both sides to add up to 1")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	established := []Player{{Rating: 1500, Games: 20}, {Rating: 1500, Games: 20}}
+	impostors := []Player{{Rating: 1500, Games: 20}}
+	crew, imps := Update(established, impostors)
+	if !closeTo(crew[0], 1500+K/2) || !closeTo(crew[1], 1500+K/2) || !closeTo(imps[0], 1500-K/2) {
+		t.Errorf("expected an even game to move everyone by half of K, got %v %v", crew, imps)
+	}
+
+	// beating a stronger team is worth more than beating a weaker one
+	upset, _ := Update([]Player{{Rating: 1400, Games: 20}}, []Player{{Rating: 1600, Games: 20}})
+	expected, _ := Update([]Player{{Rating: 1600, Games: 20}}, []Player{{Rating: 1400, Games: 20}})
+	if upset[0]-1400 <= expected[0]-1600 {
+		t.Errorf("expected the upset to be worth more, got +%f and +%f", upset[0]-1400, expected[0]-1600)
+	}
+
+	newcomer, _ := Update([]Player{{Rating: 1500, Games: 0}}, impostors)
+	if !closeTo(newcomer[0], 1500+K) {
+		t.Errorf("expected new players to move twice as fast, got %f", newcomer[0])
+	}
+}
+
+func TestUpdateWithoutOpponents(t *testing.T) {
+	winners, losers := Update([]Player{{Rating: 1500, Games: 20}}, nil)
+	if len(losers) != 0 || !closeTo(winners[0], 1500+K/2) {
+		t.Errorf("expected the winners to be rated against a new team, got %v %v", winners, losers)
+	}
+}
@@ -1,5 +1,7 @@
 package rediskey
 
+import "strings"
+
 const TotalGuildsSet = "automuteus:count:guilds"
 const ActiveGamesZSet = "automuteus:games"
 const EventsNamespace = "automuteus:capture:events"
@@ -28,10 +30,18 @@ func ConnectCodeData(guildID, connCode string) string {
 	return "automuteus:discord:" + guildID + ":" + connCode
 }
 
+// GuildCacheHash held the in-game name <-> user ID links before they moved to Postgres (player_aliases)
 func GuildCacheHash(guildID string) string {
 	return "automuteus:discord:" + guildID + ":cache"
 }
 
+// GuildCacheHashGuildID is the inverse of GuildCacheHash, for keys found by matching GuildCacheHashPattern
+func GuildCacheHashGuildID(key string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(key, "automuteus:discord:"), ":cache")
+}
+
+const GuildCacheHashPattern = "automuteus:discord:*:cache"
+
 func SnowflakeLockID(snowflake string) string {
 	return "automuteus:snowflake:" + snowflake + ":lock"
 }
@@ -83,3 +93,37 @@ func UserSoftban(userID string) string {
 func UserSoftbanCount(userID string) string {
 	return "automuteus:ratelimit:softban:count:user:" + userID
 }
+
+func JobStream(connectCode string) string {
+	return JobNamespace + "stream:" + connectCode
+}
+
+func JobDeadLetterList(connectCode string) string {
+	return JobNamespace + "deadletter:" + connectCode
+}
+
+// GameOwner holds the node ID of the worker that owns (processes the capture events for) a game
+func GameOwner(connectCode string) string {
+	return "automuteus:game:" + connectCode + ":owner"
+}
+
+// GameLeasesZSet indexes every game lease by its expiry, so expired ones can be found and adopted
+const GameLeasesZSet = "automuteus:game:leases"
+
+func GameControl(connectCode string) string {
+	return "automuteus:game:" + connectCode + ":control"
+}
+
+// PendingEditsZSet holds the message IDs with a pending game state message edit, scored by when the edit is due
+const PendingEditsZSet = "automuteus:edits:pending"
+
+// PendingEditNamespace prefixes the latest embed waiting to be sent for a message
+const PendingEditNamespace = "automuteus:edits:message:"
+
+func PendingEdit(messageID string) string {
+	return PendingEditNamespace + messageID
+}
+
+func ChannelEditRateLimit(channelID string) string {
+	return "automuteus:edits:ratelimit:" + channelID
+}
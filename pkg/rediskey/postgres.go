@@ -17,7 +17,7 @@ func queryTotalUsers(ctx context.Context, pool *pgxpool.Pool) int64 {
 
 func queryTotalGames(ctx context.Context, pool *pgxpool.Pool) int64 {
 	var r []int64
-	err := pgxscan.Select(ctx, pool, &r, "SELECT COUNT (*) FROM games WHERE start_time != -1 AND end_time != -1")
+	err := pgxscan.Select(ctx, pool, &r, "SELECT COUNT (*) FROM games WHERE end_time IS NOT NULL")
 	if err != nil || len(r) < 1 {
 		return NotFound
 	}
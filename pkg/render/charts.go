@@ -0,0 +1,201 @@
+package render
+
+import (
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"image"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth  = 600
+	chartHeight = 300
+	textScale   = 2
+	// how tall a line of text is at textScale
+	lineHeight = glyphHeight * textScale
+)
+
+// WinRatePoint is the games played on a day
+type WinRatePoint struct {
+	Day   time.Time
+	Wins  int
+	Games int
+}
+
+// WinRateChart draws the win rate over time, counting every game up to each day, so one bad night doesn't look like a
+// collapse. Days are placed by date, and points should be in order
+func WinRateChart(points []WinRatePoint) *image.RGBA {
+	img := newImage(chartWidth, chartHeight)
+	left, right, top, bottom := 64, chartWidth-24, 16, chartHeight-40
+	yFor := func(rate float64) int {
+		return bottom - int(math.Round(rate*float64(bottom-top)))
+	}
+
+	for _, pct := range []int{0, 25, 50, 75, 100} {
+		y := yFor(float64(pct) / 100)
+		line := gridLine
+		if pct == 50 {
+			line = mutedText
+		}
+		fillRect(img, image.Rect(left, y, right+1, y+1), line)
+		label := fmt.Sprintf("%d%%", pct)
+		drawText(img, left-10-textWidth(label, textScale), y-lineHeight/2, label, textScale, mutedText)
+	}
+	if len(points) == 0 {
+		return img
+	}
+
+	first, last := points[0].Day, points[len(points)-1].Day
+	xFor := func(day time.Time) int {
+		if !last.After(first) {
+			return (left + right) / 2
+		}
+		return left + int(math.Round(float64(right-left)*float64(day.Sub(first))/float64(last.Sub(first))))
+	}
+
+	wins, games := 0, 0
+	var plotted []image.Point
+	for _, p := range points {
+		wins += p.Wins
+		games += p.Games
+		if games == 0 {
+			continue
+		}
+		pt := image.Pt(xFor(p.Day), yFor(float64(wins)/float64(games)))
+		if len(plotted) > 0 {
+			prev := plotted[len(plotted)-1]
+			drawLine(img, prev.X, prev.Y, pt.X, pt.Y, 3, accent)
+		}
+		plotted = append(plotted, pt)
+	}
+	for _, pt := range plotted {
+		fillRect(img, image.Rect(pt.X-3, pt.Y-3, pt.X+4, pt.Y+4), textColor)
+	}
+
+	labels := []time.Time{first}
+	if len(points) > 2 {
+		labels = append(labels, first.Add(last.Sub(first)/2))
+	}
+	if last.After(first) {
+		labels = append(labels, last)
+	}
+	for _, day := range labels {
+		label := day.Format("Jan 2")
+		x := xFor(day) - textWidth(label, textScale)/2
+		x = clamp(x, 0, chartWidth-textWidth(label, textScale))
+		drawText(img, x, bottom+14, label, textScale, mutedText)
+	}
+	return img
+}
+
+// ColorCount is how many games were played as a color
+type ColorCount struct {
+	Color int
+	Count int64
+}
+
+// pieLegendRows is how many colors get their own row in the legend; the rest are lumped together
+const pieLegendRows = 6
+
+// ColorPieChart draws the share of games played as each color, with a legend of the most played colors
+func ColorPieChart(counts []ColorCount) *image.RGBA {
+	img := newImage(chartWidth, chartHeight)
+
+	sorted := make([]ColorCount, 0, len(counts))
+	var total int64
+	for _, c := range counts {
+		if c.Count > 0 {
+			sorted = append(sorted, c)
+			total += c.Count
+		}
+	}
+	if total == 0 {
+		return img
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Count > sorted[j].Count
+	})
+
+	type slice struct {
+		color   int
+		label   string
+		count   int64
+		end     float64
+		colored bool
+	}
+	var slices []slice
+	var other int64
+	for i, c := range sorted {
+		if len(sorted) > pieLegendRows && i >= pieLegendRows-1 {
+			other += c.Count
+			continue
+		}
+		slices = append(slices, slice{color: c.Color, label: colorName(c.Color), count: c.Count, colored: true})
+	}
+	if other > 0 {
+		slices = append(slices, slice{color: -1, label: "Other", count: other})
+	}
+	var sum int64
+	for i := range slices {
+		sum += slices[i].count
+		slices[i].end = float64(sum) / float64(total)
+	}
+
+	// clockwise from the top, like a clock
+	cx, cy, radius := 150, chartHeight/2, 120
+	for y := cy - radius; y <= cy+radius; y++ {
+		for x := cx - radius; x <= cx+radius; x++ {
+			dx, dy := float64(x-cx)+0.5, float64(y-cy)+0.5
+			if dx*dx+dy*dy > float64(radius*radius) {
+				continue
+			}
+			angle := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if angle < 0 {
+				angle++
+			}
+			for _, s := range slices {
+				if angle < s.end || s.end == 1 {
+					img.SetRGBA(x, y, CrewmateColor(s.color))
+					break
+				}
+			}
+		}
+	}
+
+	rowHeight := iconHeight + 4
+	x, y := 310, cy-len(slices)*rowHeight/2
+	for _, s := range slices {
+		if s.colored {
+			drawIcon(img, x, y, s.color)
+		} else {
+			fillRect(img, image.Rect(x+iconWidth/4, y+iconHeight/3, x+iconWidth*3/4, y+iconHeight*2/3), CrewmateColor(s.color))
+		}
+		textY := y + (iconHeight-lineHeight)/2
+		drawText(img, x+iconWidth+12, textY, s.label, textScale, textColor)
+		pct := fmt.Sprintf("%d%%", int(math.Round(float64(s.count)*100/float64(total))))
+		drawText(img, chartWidth-24-textWidth(pct, textScale), textY, pct, textScale, mutedText)
+		y += rowHeight
+	}
+	return img
+}
+
+func colorName(colorInt int) string {
+	name := game.GetColorStringForInt(colorInt)
+	if name == "" {
+		return "?"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func clamp(x, min, max int) int {
+	if x < min {
+		return min
+	}
+	if x > max {
+		return max
+	}
+	return x
+}
@@ -0,0 +1,142 @@
+package render
+
+import (
+	"image"
+	"image/color"
+)
+
+// The renderer only has the standard library, so text is drawn with a small bitmap font: 5x7 glyphs for printable
+// ASCII, scaled up by whole pixels. Anything else is drawn as a '?', so the images only carry numbers and color
+// names, and players are named in the embeds
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// the gap between glyphs, in font pixels
+	glyphSpacing = 1
+)
+
+var glyphs = map[rune][glyphHeight]string{
+	' ':  {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+	'!':  {"..#..", "..#..", "..#..", "..#..", "..#..", ".....", "..#.."},
+	'"':  {".#.#.", ".#.#.", ".....", ".....", ".....", ".....", "....."},
+	'#':  {".#.#.", ".#.#.", "#####", ".#.#.", "#####", ".#.#.", ".#.#."},
+	'$':  {"..#..", ".####", "#.#..", ".###.", "..#.#", "####.", "..#.."},
+	'%':  {"##...", "##..#", "...#.", "..#..", ".#...", "#..##", "...##"},
+	'&':  {".##..", "#..#.", "#.#..", ".#...", "#.#.#", "#..#.", ".##.#"},
+	'\'': {"..#..", "..#..", ".....", ".....", ".....", ".....", "....."},
+	'(':  {"...#.", "..#..", ".#...", ".#...", ".#...", "..#..", "...#."},
+	')':  {".#...", "..#..", "...#.", "...#.", "...#.", "..#..", ".#..."},
+	'*':  {".....", "..#..", "#.#.#", ".###.", "#.#.#", "..#..", "....."},
+	'+':  {".....", "..#..", "..#..", "#####", "..#..", "..#..", "....."},
+	',':  {".....", ".....", ".....", ".....", "..##.", "..#..", ".#..."},
+	'-':  {".....", ".....", ".....", "#####", ".....", ".....", "....."},
+	'.':  {".....", ".....", ".....", ".....", ".....", ".##..", ".##.."},
+	'/':  {".....", "....#", "...#.", "..#..", ".#...", "#....", "....."},
+	'0':  {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1':  {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2':  {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3':  {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4':  {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5':  {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6':  {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7':  {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8':  {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9':  {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	':':  {".....", ".##..", ".##..", ".....", ".##..", ".##..", "....."},
+	';':  {".....", ".##..", ".##..", ".....", ".##..", "..#..", ".#..."},
+	'<':  {"...#.", "..#..", ".#...", "#....", ".#...", "..#..", "...#."},
+	'=':  {".....", ".....", "#####", ".....", "#####", ".....", "....."},
+	'>':  {".#...", "..#..", "...#.", "....#", "...#.", "..#..", ".#..."},
+	'?':  {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+	'@':  {".###.", "#...#", "....#", ".##.#", "#.#.#", "#.#.#", ".###."},
+	'A':  {".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'B':  {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	'C':  {".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."},
+	'D':  {"###..", "#..#.", "#...#", "#...#", "#...#", "#..#.", "###.."},
+	'E':  {"#####", "#....", "#....", "####.", "#....", "#....", "#####"},
+	'F':  {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	'G':  {".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"},
+	'H':  {"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"},
+	'I':  {".###.", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'J':  {"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."},
+	'K':  {"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"},
+	'L':  {"#....", "#....", "#....", "#....", "#....", "#....", "#####"},
+	'M':  {"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"},
+	'N':  {"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"},
+	'O':  {".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'P':  {"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."},
+	'Q':  {".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"},
+	'R':  {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	'S':  {".####", "#....", "#....", ".###.", "....#", "....#", "####."},
+	'T':  {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'U':  {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	'V':  {"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'W':  {"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."},
+	'X':  {"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"},
+	'Y':  {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	'Z':  {"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"},
+	'[':  {".###.", ".#...", ".#...", ".#...", ".#...", ".#...", ".###."},
+	'\\': {".....", "#....", ".#...", "..#..", "...#.", "....#", "....."},
+	']':  {".###.", "...#.", "...#.", "...#.", "...#.", "...#.", ".###."},
+	'^':  {"..#..", ".#.#.", "#...#", ".....", ".....", ".....", "....."},
+	'_':  {".....", ".....", ".....", ".....", ".....", ".....", "#####"},
+	'`':  {".#...", "..#..", ".....", ".....", ".....", ".....", "....."},
+	'a':  {".....", ".....", ".###.", "....#", ".####", "#...#", ".####"},
+	'b':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "####."},
+	'c':  {".....", ".....", ".###.", "#....", "#....", "#...#", ".###."},
+	'd':  {"....#", "....#", ".##.#", "#..##", "#...#", "#...#", ".####"},
+	'e':  {".....", ".....", ".###.", "#...#", "#####", "#....", ".###."},
+	'f':  {"..##.", ".#..#", ".#...", "###..", ".#...", ".#...", ".#..."},
+	'g':  {".....", ".####", "#...#", "#...#", ".####", "....#", ".###."},
+	'h':  {"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "#...#"},
+	'i':  {"..#..", ".....", ".##..", "..#..", "..#..", "..#..", ".###."},
+	'j':  {"...#.", ".....", "..##.", "...#.", "...#.", "#..#.", ".##.."},
+	'k':  {"#....", "#....", "#..#.", "#.#..", "##...", "#.#..", "#..#."},
+	'l':  {".##..", "..#..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'm':  {".....", ".....", "##.#.", "#.#.#", "#.#.#", "#...#", "#...#"},
+	'n':  {".....", ".....", "#.##.", "##..#", "#...#", "#...#", "#...#"},
+	'o':  {".....", ".....", ".###.", "#...#", "#...#", "#...#", ".###."},
+	'p':  {".....", ".....", "####.", "#...#", "####.", "#....", "#...."},
+	'q':  {".....", ".....", ".##.#", "#..##", ".####", "....#", "....#"},
+	'r':  {".....", ".....", "#.##.", "##..#", "#....", "#....", "#...."},
+	's':  {".....", ".....", ".###.", "#....", ".###.", "....#", "####."},
+	't':  {".#...", ".#...", "###..", ".#...", ".#...", ".#..#", "..##."},
+	'u':  {".....", ".....", "#...#", "#...#", "#...#", "#..##", ".##.#"},
+	'v':  {".....", ".....", "#...#", "#...#", "#...#", ".#.#.", "..#.."},
+	'w':  {".....", ".....", "#...#", "#...#", "#.#.#", "#.#.#", ".#.#."},
+	'x':  {".....", ".....", "#...#", ".#.#.", "..#..", ".#.#.", "#...#"},
+	'y':  {".....", ".....", "#...#", "#...#", ".####", "....#", ".###."},
+	'z':  {".....", ".....", "#####", "...#.", "..#..", ".#...", "#####"},
+	'{':  {"...#.", "..#..", "..#..", ".#...", "..#..", "..#..", "...#."},
+	'|':  {"..#..", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	'}':  {".#...", "..#..", "..#..", "...#.", "..#..", "..#..", ".#..."},
+	'~':  {".....", ".....", ".#...", "#.#.#", "...#.", ".....", "....."},
+}
+
+// textWidth is how wide text is drawn at the scale, in pixels
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+glyphSpacing) - glyphSpacing) * scale
+}
+
+// drawText draws text with its top left corner at x, y
+func drawText(img *image.RGBA, x, y int, text string, scale int, c color.RGBA) {
+	for _, r := range text {
+		glyph, ok := glyphs[r]
+		if !ok {
+			glyph = glyphs['?']
+		}
+		for row, line := range glyph {
+			for col, px := range line {
+				if px == '#' {
+					fillRect(img, image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale), c)
+				}
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
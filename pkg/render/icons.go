@@ -0,0 +1,88 @@
+package render
+
+import (
+	"github.com/automuteus/automuteus/v8/assets"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"sync"
+)
+
+// the emojis are 535x686, so icons keep about the same shape
+const (
+	iconWidth  = 28
+	iconHeight = 36
+)
+
+var (
+	iconLock sync.Mutex
+	icons    = map[int]*image.RGBA{}
+)
+
+// crewmateIcon is the crewmate emoji for the color, shrunk to an icon. Colors without an emoji get a plain swatch
+func crewmateIcon(colorInt int) *image.RGBA {
+	iconLock.Lock()
+	defer iconLock.Unlock()
+	if icon, ok := icons[colorInt]; ok {
+		return icon
+	}
+	icon := loadIcon(colorInt)
+	icons[colorInt] = icon
+	return icon
+}
+
+func loadIcon(colorInt int) *image.RGBA {
+	icon := image.NewRGBA(image.Rect(0, 0, iconWidth, iconHeight))
+	name := game.GetColorStringForInt(colorInt)
+	if name == "" {
+		fillRect(icon, image.Rect(iconWidth/4, iconHeight/4, iconWidth*3/4, iconHeight*3/4), CrewmateColor(colorInt))
+		return icon
+	}
+	f, err := assets.Emojis.Open("emojis/au" + name + ".png")
+	if err != nil {
+		log.Println(err)
+		return icon
+	}
+	defer f.Close()
+	src, err := png.Decode(f)
+	if err != nil {
+		log.Println(err)
+		return icon
+	}
+	shrink(icon, src)
+	return icon
+}
+
+// shrink scales src down into dst, averaging the source pixels that make up each destination pixel
+func shrink(dst *image.RGBA, src image.Image) {
+	sb, db := src.Bounds(), dst.Bounds()
+	for y := 0; y < db.Dy(); y++ {
+		y0, y1 := sb.Min.Y+y*sb.Dy()/db.Dy(), sb.Min.Y+(y+1)*sb.Dy()/db.Dy()
+		for x := 0; x < db.Dx(); x++ {
+			x0, x1 := sb.Min.X+x*sb.Dx()/db.Dx(), sb.Min.X+(x+1)*sb.Dx()/db.Dx()
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					// premultiplied, so transparent pixels don't darken the edges
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+pr, g+pg, b+pb, a+pa, n+1
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			dst.SetRGBA(db.Min.X+x, db.Min.Y+y, color.RGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+}
+
+// drawIcon draws the color's crewmate with its top left corner at x, y
+func drawIcon(img *image.RGBA, x, y, colorInt int) {
+	icon := crewmateIcon(colorInt)
+	draw.Draw(img, icon.Bounds().Add(image.Pt(x, y)), icon, image.Point{}, draw.Over)
+}
@@ -0,0 +1,107 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+// Package render draws the stats images attached to /stats, with nothing but the standard library so the bot doesn't
+// need fonts or cgo. Images have no titles; the embeds they're attached to say what they are, in the guild's language
+
+var (
+	background = color.RGBA{R: 0x2F, G: 0x31, B: 0x36, A: 0xFF}
+	panel      = color.RGBA{R: 0x36, G: 0x39, B: 0x3F, A: 0xFF}
+	gridLine   = color.RGBA{R: 0x4F, G: 0x54, B: 0x5C, A: 0xFF}
+	textColor  = color.RGBA{R: 0xDC, G: 0xDD, B: 0xDE, A: 0xFF}
+	mutedText  = color.RGBA{R: 0x96, G: 0x98, B: 0x9D, A: 0xFF}
+	accent     = color.RGBA{R: 0x58, G: 0x65, B: 0xF2, A: 0xFF}
+)
+
+// crewmateColors are the in-game colors, indexed like the colors in pkg/game
+var crewmateColors = []color.RGBA{
+	{R: 0xC5, G: 0x11, B: 0x11, A: 0xFF}, // red
+	{R: 0x13, G: 0x2E, B: 0xD1, A: 0xFF}, // blue
+	{R: 0x11, G: 0x7F, B: 0x2D, A: 0xFF}, // green
+	{R: 0xED, G: 0x54, B: 0xBA, A: 0xFF}, // pink
+	{R: 0xEF, G: 0x7D, B: 0x0D, A: 0xFF}, // orange
+	{R: 0xF5, G: 0xF5, B: 0x57, A: 0xFF}, // yellow
+	{R: 0x3F, G: 0x47, B: 0x4E, A: 0xFF}, // black
+	{R: 0xD6, G: 0xE0, B: 0xF0, A: 0xFF}, // white
+	{R: 0x6B, G: 0x2F, B: 0xBB, A: 0xFF}, // purple
+	{R: 0x71, G: 0x49, B: 0x1E, A: 0xFF}, // brown
+	{R: 0x38, G: 0xFE, B: 0xDC, A: 0xFF}, // cyan
+	{R: 0x50, G: 0xEF, B: 0x39, A: 0xFF}, // lime
+	{R: 0x6B, G: 0x2B, B: 0x3C, A: 0xFF}, // maroon
+	{R: 0xEC, G: 0xC0, B: 0xD3, A: 0xFF}, // rose
+	{R: 0xFF, G: 0xFE, B: 0xBE, A: 0xFF}, // banana
+	{R: 0x70, G: 0x84, B: 0x96, A: 0xFF}, // gray
+	{R: 0x92, G: 0x87, B: 0x76, A: 0xFF}, // tan
+	{R: 0xEC, G: 0x75, B: 0x78, A: 0xFF}, // coral
+}
+
+// CrewmateColor is the in-game color for a color int, or gray for one the renderer doesn't know
+func CrewmateColor(colorInt int) color.RGBA {
+	if colorInt < 0 || colorInt >= len(crewmateColors) {
+		return mutedText
+	}
+	return crewmateColors[colorInt]
+}
+
+// EncodePNG encodes a rendered image to attach to a message
+func EncodePNG(img image.Image) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{})
+	err := png.Encode(buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), background)
+	return img
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+// drawLine draws a line thickness pixels wide from x0, y0 to x1, y1
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	half := thickness / 2
+	e := dx + dy
+	for {
+		fillRect(img, image.Rect(x0-half, y0-half, x0-half+thickness, y0-half+thickness), c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,85 @@
+package render
+
+import (
+	"flag"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+// checkGolden compares img to testdata/<name>.png pixel by pixel, so a different PNG encoder doesn't fail the test
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+	if *update {
+		b, err := EncodePNG(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	defer f.Close()
+	golden, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("expected a %v image, got %v", golden.Bounds(), img.Bounds())
+	}
+	diff := 0
+	for y := img.Bounds().Min.Y; y < img.Bounds().Max.Y; y++ {
+		for x := img.Bounds().Min.X; x < img.Bounds().Max.X; x++ {
+			r1, g1, b1, a1 := golden.At(x, y).RGBA()
+			r2, g2, b2, a2 := img.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%d pixels differ from %s", diff, path)
+	}
+}
+
+func day(d int) time.Time {
+	return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestWinRateChart(t *testing.T) {
+	checkGolden(t, "winrate", WinRateChart([]WinRatePoint{
+		{Day: day(1), Wins: 3, Games: 4},
+		{Day: day(2), Wins: 0, Games: 3},
+		{Day: day(5), Wins: 2, Games: 5},
+		{Day: day(9), Wins: 6, Games: 7},
+		{Day: day(14), Wins: 1, Games: 6},
+	}))
+	checkGolden(t, "winrate_single", WinRateChart([]WinRatePoint{{Day: day(1), Wins: 1, Games: 2}}))
+}
+
+func TestColorPieChart(t *testing.T) {
+	checkGolden(t, "colors", ColorPieChart([]ColorCount{
+		{Color: 0, Count: 40},
+		{Color: 10, Count: 25},
+		{Color: 3, Count: 20},
+		{Color: 14, Count: 10},
+		{Color: 6, Count: 5},
+	}))
+	// more colors than the legend has rows for
+	counts := []ColorCount{}
+	for c := 0; c < 18; c++ {
+		counts = append(counts, ColorCount{Color: c, Count: int64(18 - c)})
+	}
+	checkGolden(t, "colors_other", ColorPieChart(counts))
+}
@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+)
+
+type Recurrence string
+
+const (
+	Once   Recurrence = "once"
+	Daily  Recurrence = "daily"
+	Weekly Recurrence = "weekly"
+)
+
+var Recurrences = []Recurrence{Once, Daily, Weekly}
+
+const (
+	DateFormat = "2006-01-02"
+	TimeFormat = "15:04"
+)
+
+var ErrInPast = errors.New("the start time is in the past")
+
+var ErrEndBeforeStart = errors.New("the last day is before the first day")
+
+// LoadLocation loads an IANA timezone name like "Asia/Tokyo". An empty name is the bot's default timezone (time.Local)
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// ParseStart reads a date (2006-01-02) and a time of day (15:04) as the wall clock time in loc, and makes sure it's
+// after now
+func ParseStart(date, clock string, loc *time.Location, now time.Time) (time.Time, error) {
+	start, err := time.ParseInLocation(DateFormat+" "+TimeFormat, date+" "+clock, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !start.After(now) {
+		return start, ErrInPast
+	}
+	return start, nil
+}
+
+// ParseDateRange reads the first and last day of a range (2006-01-02, both included) as the start of the first day and
+// the start of the day after the last one, in loc. Either can be empty, for a range that's open on that end
+func ParseDateRange(first, last string, loc *time.Location) (start, end *time.Time, err error) {
+	if first != "" {
+		day, err := time.ParseInLocation(DateFormat, first, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = &day
+	}
+	if last != "" {
+		day, err := time.ParseInLocation(DateFormat, last, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+		end = &day
+	}
+	if start != nil && end != nil && !end.After(*start) {
+		return nil, nil, ErrEndBeforeStart
+	}
+	return start, end, nil
+}
+
+// ParseRecurrence returns the recurrence by name; anything unknown is Once
+func ParseRecurrence(name string) Recurrence {
+	for _, r := range Recurrences {
+		if string(r) == name {
+			return r
+		}
+	}
+	return Once
+}
+
+// Next returns the first occurrence of a session starting at start and repeating every recurrence, that's after after.
+// Occurrences keep the same wall clock time in loc, so a weekly session doesn't drift by an hour when DST changes.
+// Returns false if the session doesn't repeat
+func Next(start time.Time, recurrence Recurrence, loc *time.Location, after time.Time) (time.Time, bool) {
+	var days int
+	switch recurrence {
+	case Daily:
+		days = 1
+	case Weekly:
+		days = 7
+	default:
+		return time.Time{}, false
+	}
+	local := start.In(loc)
+	// skip straight to around after, for sessions whose occurrences were missed while the bot was down
+	n := days
+	if missed := int(after.Sub(start).Hours()/24) / days * days; missed > n {
+		n = missed
+	}
+	for ; ; n += days {
+		next := time.Date(local.Year(), local.Month(), local.Day()+n, local.Hour(), local.Minute(), 0, 0, loc)
+		if next.After(after) {
+			return next, true
+		}
+	}
+}
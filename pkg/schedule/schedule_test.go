@@ -0,0 +1,107 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestParseStart(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	start, err := ParseStart("2024-03-08", "21:00", tokyo, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 21:00 JST to be 12:00 UTC, got %s", start.UTC())
+	}
+
+	if _, err = ParseStart("2024-02-28", "21:00", tokyo, now); !errors.Is(err, ErrInPast) {
+		t.Errorf("expected a start time in the past to be rejected, got %v", err)
+	}
+	if _, err = ParseStart("2024/03/08", "9pm", tokyo, now); err == nil {
+		t.Error("expected a malformed date to be rejected")
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the friday before DST starts in New York (March 10th, 2024)
+	start := time.Date(2024, 3, 8, 20, 0, 0, 0, newYork)
+
+	next, ok := Next(start, Weekly, newYork, start)
+	if !ok {
+		t.Fatal("expected a weekly session to repeat")
+	}
+	if want := time.Date(2024, 3, 15, 20, 0, 0, 0, newYork); !next.Equal(want) {
+		t.Errorf("expected the session to stay at 20:00 local time across DST, got %s", next)
+	}
+	if next.Sub(start) != 7*24*time.Hour-time.Hour {
+		t.Errorf("expected the week with the DST change to be an hour shorter, got %s", next.Sub(start))
+	}
+
+	// the bot was down for a few weeks
+	next, _ = Next(start, Weekly, newYork, start.Add(20*24*time.Hour))
+	if want := time.Date(2024, 3, 29, 20, 0, 0, 0, newYork); !next.Equal(want) {
+		t.Errorf("expected missed occurrences to be skipped, got %s", next)
+	}
+
+	next, _ = Next(start, Daily, newYork, start.Add(time.Minute))
+	if want := time.Date(2024, 3, 9, 20, 0, 0, 0, newYork); !next.Equal(want) {
+		t.Errorf("expected the next day, got %s", next)
+	}
+
+	if _, ok = Next(start, Once, newYork, start); ok {
+		t.Error("sessions that happen once should not repeat")
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	if r := ParseRecurrence("weekly"); r != Weekly {
+		t.Errorf("expected weekly, got %s", r)
+	}
+	if r := ParseRecurrence("fortnightly"); r != Once {
+		t.Errorf("expected unknown recurrences to be once, got %s", r)
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end, err := ParseDateRange("2024-04-01", "2024-06-30", tokyo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, 3, 31, 15, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 6, 30, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the whole of both days in JST, got %s to %s", start.UTC(), end.UTC())
+	}
+
+	// a single day
+	start, end, err = ParseDateRange("2024-04-01", "2024-04-01", tokyo)
+	if err != nil || end.Sub(*start) != 24*time.Hour {
+		t.Errorf("expected a single day to be 24 hours long, got %v %v", start, end)
+	}
+
+	start, end, err = ParseDateRange("", "2024-04-01", tokyo)
+	if err != nil || start != nil || end == nil {
+		t.Errorf("expected a range open at the start, got %v %v %v", start, end, err)
+	}
+
+	if _, _, err = ParseDateRange("2024-06-30", "2024-04-01", tokyo); !errors.Is(err, ErrEndBeforeStart) {
+		t.Errorf("expected a range that ends before it starts to be rejected, got %v", err)
+	}
+	if _, _, err = ParseDateRange("April 1st", "", tokyo); err == nil {
+		t.Error("expected a malformed date to be rejected")
+	}
+}
@@ -27,6 +27,8 @@ type GuildSettings struct {
 	LeaderboardMin           int    `json:"leaderboardMin"`
 	MuteSpectator            bool   `json:"muteSpectator"`
 	DisplayRoomCode          string `json:"displayRoomCode"`
+	GhostChannelID           string `json:"ghostChannelID"`
+	GameMode                 string `json:"gameMode,omitempty"`
 }
 
 func MakeGuildSettings() *GuildSettings {
@@ -46,6 +48,7 @@ func MakeGuildSettings() *GuildSettings {
 		LeaderboardMin:           DefaultLeaderboardMin,
 		MuteSpectator:            false,
 		DisplayRoomCode:          "always",
+		GhostChannelID:           "",
 		lock:                     sync.RWMutex{},
 	}
 }
@@ -115,6 +118,14 @@ func (gs *GuildSettings) GetMatchSummaryChannelID() string {
 	return gs.MatchSummaryChannelID
 }
 
+func (gs *GuildSettings) SetGhostChannelID(id string) {
+	gs.GhostChannelID = id
+}
+
+func (gs *GuildSettings) GetGhostChannelID() string {
+	return gs.GhostChannelID
+}
+
 func (gs *GuildSettings) GetAutoRefresh() bool {
 	return gs.AutoRefresh
 }
@@ -185,33 +196,101 @@ func (gs *GuildSettings) SetLanguage(l string) {
 	gs.Language = l
 }
 
-func (gs *GuildSettings) GetDelay(oldPhase, newPhase game.Phase) int {
+func (gs *GuildSettings) GetDelay(oldPhase, newPhase game.Phase) float64 {
 	return gs.Delays.GetDelay(oldPhase, newPhase)
 }
 
-func (gs *GuildSettings) SetDelay(oldPhase, newPhase game.Phase, v int) {
-	gs.Delays.Delays[oldPhase.ToString()][newPhase.ToString()] = v
+func (gs *GuildSettings) SetDelay(oldPhase, newPhase game.Phase, v float64) {
+	gs.Delays.SetDelay(oldPhase, newPhase, "", v)
 }
 
 func (gs *GuildSettings) GetVoiceRule(isMute bool, phase game.Phase, alive string) bool {
-	if isMute {
-		return gs.VoiceRules.MuteRules[phase.ToString()][alive]
-	}
-	return gs.VoiceRules.DeafRules[phase.ToString()][alive]
+	v, _ := gs.VoiceRules.GetRule(isMute, phase, alive, "")
+	return v
 }
 
 func (gs *GuildSettings) SetVoiceRule(isMute bool, phase game.Phase, alive string, val bool) {
-	if isMute {
-		gs.VoiceRules.MuteRules[phase.ToString()][alive] = val
-	} else {
-		gs.VoiceRules.DeafRules[phase.ToString()][alive] = val
-	}
+	gs.VoiceRules.SetRule(isMute, phase, alive, "", val)
+}
+
+// GetModeVoiceRule is the rule for players in the state (see game.VoiceStateKey), and whether there is one. An empty
+// mode is the guild's rules
+func (gs *GuildSettings) GetModeVoiceRule(isMute bool, phase game.Phase, key, mode string) (bool, bool) {
+	return gs.VoiceRules.GetRule(isMute, phase, key, mode)
+}
+
+func (gs *GuildSettings) SetModeVoiceRule(isMute bool, phase game.Phase, key, mode string, val bool) {
+	gs.VoiceRules.SetRule(isMute, phase, key, mode, val)
+}
+
+func (gs *GuildSettings) ClearModeVoiceRule(isMute bool, phase game.Phase, key, mode string) bool {
+	return gs.VoiceRules.ClearRule(isMute, phase, key, mode)
 }
 
 func (gs *GuildSettings) GetVoiceState(alive bool, tracked bool, phase game.Phase) (bool, bool) {
 	return gs.VoiceRules.GetVoiceState(alive, tracked, phase)
 }
 
+func (gs *GuildSettings) GetVoiceStateFor(userID string, roleIDs []string, player game.VoicePlayer, tracked bool, phase game.Phase, mode string) (bool, bool) {
+	return gs.VoiceRules.GetVoiceStateFor(userID, roleIDs, player, tracked, phase, mode)
+}
+
+func (gs *GuildSettings) GetUnlinkedVoiceStateFor(userID string, roleIDs []string, tracked bool, phase game.Phase, mode string) (bool, bool) {
+	return gs.VoiceRules.GetUnlinkedVoiceStateFor(userID, roleIDs, tracked, phase, mode)
+}
+
+func (gs *GuildSettings) ApplyUnlinkedVoiceOverrides(userID string, roleIDs []string, phase game.Phase, mute, deaf bool) (bool, bool) {
+	return gs.VoiceRules.ApplyUnlinkedOverrides(userID, roleIDs, phase, mute, deaf)
+}
+
+func (gs *GuildSettings) ApplyVoiceOverrides(userID string, roleIDs []string, alive bool, phase game.Phase, mute, deaf bool) (bool, bool) {
+	return gs.VoiceRules.ApplyOverrides(userID, roleIDs, alive, phase, mute, deaf)
+}
+
+func (gs *GuildSettings) HasVoiceOverride(userID string, roleIDs []string) bool {
+	return gs.VoiceRules.HasOverride(userID, roleIDs)
+}
+
+func (gs *GuildSettings) voiceOverrides(isRole bool) map[string]game.VoiceOverride {
+	if isRole {
+		if gs.VoiceRules.RoleOverrides == nil {
+			gs.VoiceRules.RoleOverrides = map[string]game.VoiceOverride{}
+		}
+		return gs.VoiceRules.RoleOverrides
+	}
+	if gs.VoiceRules.UserOverrides == nil {
+		gs.VoiceRules.UserOverrides = map[string]game.VoiceOverride{}
+	}
+	return gs.VoiceRules.UserOverrides
+}
+
+// GetVoiceOverride returns the override for a user (or role), and whether one is set
+func (gs *GuildSettings) GetVoiceOverride(isRole bool, id string, isMute bool, phase game.Phase, alive string) (bool, bool) {
+	override := gs.voiceOverrides(isRole)[id]
+	return override.Get(isMute, phase, alive)
+}
+
+func (gs *GuildSettings) SetVoiceOverride(isRole bool, id string, isMute bool, phase game.Phase, alive string, val bool) {
+	overrides := gs.voiceOverrides(isRole)
+	override := overrides[id]
+	override.Set(isMute, phase, alive, val)
+	overrides[id] = override
+}
+
+func (gs *GuildSettings) ClearVoiceOverride(isRole bool, id string, isMute bool, phase game.Phase, alive string) {
+	overrides := gs.voiceOverrides(isRole)
+	override, ok := overrides[id]
+	if !ok {
+		return
+	}
+	override.Clear(isMute, phase, alive)
+	if override.IsEmpty() {
+		delete(overrides, id)
+	} else {
+		overrides[id] = override
+	}
+}
+
 func (gs *GuildSettings) GetDisplayRoomCode() string {
 	if gs.DisplayRoomCode == "" {
 		return "always"
@@ -222,3 +301,12 @@ func (gs *GuildSettings) GetDisplayRoomCode() string {
 func (gs *GuildSettings) SetDisplayRoomCode(r string) {
 	gs.DisplayRoomCode = r
 }
+
+// GetGameMode is the preset that decides which team the roles reported by the capture play for
+func (gs *GuildSettings) GetGameMode() game.GameMode {
+	return game.GetGameMode(gs.GameMode)
+}
+
+func (gs *GuildSettings) SetGameMode(mode string) {
+	gs.GameMode = mode
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"github.com/georgysavva/scany/pgxscan"
+	"strconv"
+	"time"
+)
+
+func (psqlInterface *PsqlInterface) AddPlayerAlias(guildID, userID, playerName string, color int) error {
+	alias, err := makeAlias(guildID, userID, playerName)
+	if err != nil {
+		return err
+	}
+	c := int16(color)
+	alias.PlayerColor = &c
+
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	return addPlayerAlias(conn.Conn(), alias)
+}
+
+func makeAlias(guildID, userID, playerName string) (*PostgresPlayerAlias, error) {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &PostgresPlayerAlias{
+		GuildID:        gid,
+		UserID:         uid,
+		PlayerName:     playerName,
+		TimesConfirmed: 1,
+		LastSeen:       time.Now(),
+	}, nil
+}
+
+// addPlayerAlias records that a user was linked to an in-game name, or confirms an alias that was already known
+func addPlayerAlias(conn PgxIface, alias *PostgresPlayerAlias) error {
+	// never remember names for users that opted out of data collection
+	user, err := getUser(conn, alias.UserID)
+	if err == nil && !user.Opt {
+		return nil
+	}
+	_, err = conn.Exec(context.Background(), "INSERT INTO player_aliases VALUES ($1, $2, $3, $4, $5, $6) "+
+		"ON CONFLICT (guild_id, user_id, player_name) DO UPDATE SET "+
+		"player_color = COALESCE(EXCLUDED.player_color, player_aliases.player_color), "+
+		"times_confirmed = player_aliases.times_confirmed + EXCLUDED.times_confirmed, "+
+		"last_seen = GREATEST(EXCLUDED.last_seen, player_aliases.last_seen);",
+		alias.GuildID, alias.UserID, alias.PlayerName, alias.PlayerColor, alias.TimesConfirmed, alias.LastSeen)
+	return err
+}
+
+// ImportPlayerAliases adds aliases known from elsewhere (see MigrateUsernameLinksToPostgres), without counting them
+// as confirmations of aliases that already exist
+func (psqlInterface *PsqlInterface) ImportPlayerAliases(aliases []*PostgresPlayerAlias) error {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	return importPlayerAliases(conn.Conn(), aliases)
+}
+
+func importPlayerAliases(conn PgxIface, aliases []*PostgresPlayerAlias) error {
+	optedOut := map[uint64]bool{}
+	for _, alias := range aliases {
+		// same as addPlayerAlias; never remember names for users that opted out of data collection
+		out, ok := optedOut[alias.UserID]
+		if !ok {
+			user, err := getUser(conn, alias.UserID)
+			out = err == nil && !user.Opt
+			optedOut[alias.UserID] = out
+		}
+		if out {
+			continue
+		}
+		_, err := conn.Exec(context.Background(), "INSERT INTO player_aliases VALUES ($1, $2, $3, $4, $5, $6) "+
+			"ON CONFLICT DO NOTHING;",
+			alias.GuildID, alias.UserID, alias.PlayerName, alias.PlayerColor, alias.TimesConfirmed, alias.LastSeen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAliasesByName returns every user that was ever linked to an in-game name on the guild, most confirmed first
+func (psqlInterface *PsqlInterface) GetAliasesByName(guildID, playerName string) ([]*PostgresPlayerAlias, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return getAliasesByName(conn.Conn(), guildID, playerName)
+}
+
+func getAliasesByName(conn PgxIface, guildID, playerName string) ([]*PostgresPlayerAlias, error) {
+	var aliases []*PostgresPlayerAlias
+	err := pgxscan.Select(context.Background(), conn, &aliases, "SELECT * FROM player_aliases "+
+		"WHERE guild_id = $1 AND player_name = $2 "+
+		"ORDER BY times_confirmed DESC, last_seen DESC;", guildID, playerName)
+	if err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// GetAliasesForUser returns every in-game name a user was linked to on the guild, most recent first
+func (psqlInterface *PsqlInterface) GetAliasesForUser(guildID, userID string) ([]*PostgresPlayerAlias, error) {
+	var aliases []*PostgresPlayerAlias
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &aliases, "SELECT * FROM player_aliases "+
+		"WHERE guild_id = $1 AND user_id = $2 "+
+		"ORDER BY last_seen DESC, times_confirmed DESC;", guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// DeleteAliasesForUser forgets one of a user's in-game names on the guild, or all of them if playerName is empty.
+// Returns how many aliases were deleted
+func (psqlInterface *PsqlInterface) DeleteAliasesForUser(guildID, userID, playerName string) (int64, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	return deleteAliasesForUser(conn.Conn(), guildID, userID, playerName)
+}
+
+func deleteAliasesForUser(conn PgxIface, guildID, userID, playerName string) (int64, error) {
+	if playerName == "" {
+		tag, err := conn.Exec(context.Background(), "DELETE FROM player_aliases WHERE guild_id = $1 AND user_id = $2;", guildID, userID)
+		return tag.RowsAffected(), err
+	}
+	tag, err := conn.Exec(context.Background(), "DELETE FROM player_aliases WHERE guild_id = $1 AND user_id = $2 AND player_name = $3;", guildID, userID, playerName)
+	return tag.RowsAffected(), err
+}
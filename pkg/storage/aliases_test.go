@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"time"
+)
+
+func TestAddPlayerAlias(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	alias, err := makeAlias(GuildID, UserID, "Soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery("^SELECT (.+) FROM users WHERE user_id = (.+)$").
+		WithArgs(UserIDInt).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "opt", "vote_time_unix"}).
+				AddRow(UserIDInt, true, nil))
+	mock.ExpectExec("^INSERT INTO player_aliases VALUES (.+) ON CONFLICT (.+) DO UPDATE SET (.+)$").
+		WithArgs(GuildIDInt, UserIDInt, "Soup", alias.PlayerColor, int32(1), alias.LastSeen).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+
+	err = addPlayerAlias(mock, alias)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// nothing should be written for a user that opted out
+	mock.ExpectQuery("^SELECT (.+) FROM users WHERE user_id = (.+)$").
+		WithArgs(UserIDInt).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "opt", "vote_time_unix"}).
+				AddRow(UserIDInt, false, nil))
+
+	err = addPlayerAlias(mock, alias)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportPlayerAliases(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	const optedOutID = uint64(345345345345345345)
+	now := time.Now()
+	aliases := []*PostgresPlayerAlias{
+		{GuildID: GuildIDInt, UserID: UserIDInt, PlayerName: "Soup", TimesConfirmed: 1, LastSeen: now},
+		{GuildID: GuildIDInt, UserID: optedOutID, PlayerName: "Salad", TimesConfirmed: 1, LastSeen: now},
+		{GuildID: GuildIDInt, UserID: UserIDInt, PlayerName: "Stew", TimesConfirmed: 1, LastSeen: now},
+		{GuildID: GuildIDInt, UserID: optedOutID, PlayerName: "Slaw", TimesConfirmed: 1, LastSeen: now},
+	}
+
+	// each user is only looked up once, and nothing is imported for the one that opted out
+	mock.ExpectQuery("^SELECT (.+) FROM users WHERE user_id = (.+)$").
+		WithArgs(UserIDInt).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "opt", "vote_time_unix"}).
+				AddRow(UserIDInt, true, nil))
+	mock.ExpectExec("^INSERT INTO player_aliases VALUES (.+) ON CONFLICT DO NOTHING;$").
+		WithArgs(GuildIDInt, UserIDInt, "Soup", aliases[0].PlayerColor, int32(1), now).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectQuery("^SELECT (.+) FROM users WHERE user_id = (.+)$").
+		WithArgs(optedOutID).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "opt", "vote_time_unix"}).
+				AddRow(optedOutID, false, nil))
+	mock.ExpectExec("^INSERT INTO player_aliases VALUES (.+) ON CONFLICT DO NOTHING;$").
+		WithArgs(GuildIDInt, UserIDInt, "Stew", aliases[2].PlayerColor, int32(1), now).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+
+	err = importPlayerAliases(mock, aliases)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetAliasesByName(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	color := int16(3)
+	mock.ExpectQuery("^SELECT (.+) FROM player_aliases WHERE guild_id = (.+) AND player_name = (.+) ORDER BY times_confirmed DESC, last_seen DESC;$").
+		WithArgs(GuildID, "Soup").
+		WillReturnRows(
+			pgxmock.NewRows([]string{"guild_id", "user_id", "player_name", "player_color", "times_confirmed", "last_seen"}).
+				AddRow(GuildIDInt, UserIDInt, "Soup", &color, int32(4), time.Unix(1600000000, 0)).
+				AddRow(GuildIDInt, uint64(345345345345345345), "Soup", nil, int32(1), time.Unix(1600000000, 0)))
+
+	aliases, err := getAliasesByName(mock, GuildID, "Soup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aliases) != 2 || aliases[0].UserID != UserIDInt || *aliases[0].PlayerColor != 3 || aliases[1].PlayerColor != nil {
+		t.Errorf("unexpected aliases %v", aliases)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteAliasesForUser(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectExec("^DELETE FROM player_aliases WHERE guild_id = (.+) AND user_id = (.+) AND player_name = (.+);$").
+		WithArgs(GuildID, UserID, "Soup").
+		WillReturnResult(pgconn.CommandTag("DELETE 1"))
+	mock.ExpectExec("^DELETE FROM player_aliases WHERE guild_id = (.+) AND user_id = (.+);$").
+		WithArgs(GuildID, UserID).
+		WillReturnResult(pgconn.CommandTag("DELETE 3"))
+
+	deleted, err := deleteAliasesForUser(mock, GuildID, UserID, "Soup")
+	if err != nil || deleted != 1 {
+		t.Errorf("expected one alias to be deleted, got %d (%v)", deleted, err)
+	}
+	deleted, err = deleteAliasesForUser(mock, GuildID, UserID, "")
+	if err != nil || deleted != 3 {
+		t.Errorf("expected all aliases to be deleted, got %d (%v)", deleted, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// arbitrary, but has to be the same for every shard so only one of them migrates at a time
+const migrationLockID int64 = 0x616d75 // "amu"
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationState is a migration, and when it was applied to the database (nil if it wasn't)
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads every NNNN_name.up.sql/NNNN_name.down.sql pair in the directory, sorted by version
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up migration", m)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func (psqlInterface *PsqlInterface) MigrationStatus(migrations []Migration) ([]MigrationState, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+
+	err = ensureMigrationsTable(conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(conn.Conn())
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i].Migration = m
+		if appliedAt, ok := applied[m.Version]; ok {
+			states[i].AppliedAt = &appliedAt
+		}
+	}
+	return states, nil
+}
+
+// MigrateUp applies every migration that wasn't applied yet, up to and including the target version (or all of them
+// if target is 0). Returns the migrations that were applied
+func (psqlInterface *PsqlInterface) MigrateUp(migrations []Migration, target int64) ([]Migration, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return migrateUp(conn.Conn(), migrations, target)
+}
+
+// MigrateDown reverts the last steps applied migrations, newest first. Returns the migrations that were reverted
+func (psqlInterface *PsqlInterface) MigrateDown(migrations []Migration, steps int) ([]Migration, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return migrateDown(conn.Conn(), migrations, steps)
+}
+
+func migrateUp(conn PgxIface, migrations []Migration, target int64) (done []Migration, err error) {
+	unlock, err := lockMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		log.Printf("Applying migration %s\n", m)
+		err = runMigration(conn, m.Up,
+			"INSERT INTO schema_migrations VALUES ($1, $2, $3);", m.Version, m.Name, time.Now())
+		if err != nil {
+			return done, fmt.Errorf("migration %s failed: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+func migrateDown(conn PgxIface, migrations []Migration, steps int) (done []Migration, err error) {
+	unlock, err := lockMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := appliedMigrations(conn)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migration %s can't be reverted; it has no down migration", m)
+		}
+		log.Printf("Reverting migration %s\n", m)
+		err = runMigration(conn, m.Down, "DELETE FROM schema_migrations WHERE version = $1;", m.Version)
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %s failed: %w", m, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// lockMigrations blocks until no other shard is migrating. The lock is held by the connection, so the same
+// connection has to be used for the migrations themselves
+func lockMigrations(conn PgxIface) (unlock func(), err error) {
+	_, err = conn.Exec(context.Background(), "SELECT pg_advisory_lock($1);", migrationLockID)
+	if err != nil {
+		return nil, err
+	}
+	unlock = func() {
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	// only check the table once we hold the lock, so two shards don't both try to create it
+	err = ensureMigrationsTable(conn)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+func ensureMigrationsTable(conn PgxIface) error {
+	_, err := conn.Exec(context.Background(), "CREATE TABLE IF NOT EXISTS schema_migrations "+
+		"(version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamptz NOT NULL);")
+	return err
+}
+
+func appliedMigrations(conn PgxIface) (map[int64]time.Time, error) {
+	rows, err := conn.Query(context.Background(), "SELECT version, applied_at FROM schema_migrations;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// runMigration runs the migration's SQL and records it in schema_migrations, all in one transaction
+func runMigration(conn PgxIface, sql, record string, args ...interface{}) error {
+	if sql == "" {
+		return errors.New("empty migration")
+	}
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), sql)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(), record, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(context.Background())
+}
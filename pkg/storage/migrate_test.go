@@ -0,0 +1,135 @@
+package storage
+
+import (
+	schema "github.com/automuteus/automuteus/v8/storage"
+	"github.com/jackc/pgconn"
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+	"migrations/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+	"migrations/0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+	"migrations/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+	"migrations/README.md":            {Data: []byte("not a migration")},
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(testMigrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].Version != 1 || migrations[1].Name != "second" {
+		t.Fatalf("expected two migrations in version order, got %v", migrations)
+	}
+	if migrations[0].Up != "CREATE TABLE a ();" || migrations[0].Down != "DROP TABLE a;" {
+		t.Errorf("unexpected contents for %s", migrations[0])
+	}
+
+	_, err = LoadMigrations(fstest.MapFS{
+		"migrations/0001_first.down.sql": {Data: []byte("DROP TABLE a;")},
+	}, "migrations")
+	if err == nil {
+		t.Error("expected a migration without an up migration to be rejected")
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(schema.Migrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("expected migrations to be numbered without gaps, %s is number %d", m, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %s has no down migration", m)
+		}
+	}
+}
+
+func expectMigrationLock(mock pgxmock.PgxConnIface, applied ...int64) {
+	mock.ExpectExec("^SELECT pg_advisory_lock(.+)$").
+		WithArgs(migrationLockID).
+		WillReturnResult(pgconn.CommandTag("SELECT 1"))
+	mock.ExpectExec("^CREATE TABLE IF NOT EXISTS schema_migrations (.+)$").
+		WillReturnResult(pgconn.CommandTag("CREATE TABLE"))
+	rows := pgxmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range applied {
+		rows.AddRow(v, time.Unix(1600000000, 0))
+	}
+	mock.ExpectQuery("^SELECT version, applied_at FROM schema_migrations;$").WillReturnRows(rows)
+}
+
+func TestMigrateUp(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	migrations, err := LoadMigrations(testMigrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first migration is already applied, so only the second one runs
+	expectMigrationLock(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec("^CREATE TABLE b (.+)$").WillReturnResult(pgconn.CommandTag("CREATE TABLE"))
+	mock.ExpectExec("^INSERT INTO schema_migrations VALUES (.+)$").
+		WithArgs(int64(2), "second", pgxmock.AnyArg()).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectCommit()
+	mock.ExpectExec("^SELECT pg_advisory_unlock(.+)$").
+		WithArgs(migrationLockID).
+		WillReturnResult(pgconn.CommandTag("SELECT 1"))
+
+	done, err := migrateUp(mock, migrations, 0)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(done) != 1 || done[0].Version != 2 {
+		t.Errorf("expected only the second migration to be applied, got %v", done)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMigrateDown(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	migrations, err := LoadMigrations(testMigrations, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectMigrationLock(mock, 1, 2)
+	mock.ExpectBegin()
+	mock.ExpectExec("^DROP TABLE b;$").WillReturnResult(pgconn.CommandTag("DROP TABLE"))
+	mock.ExpectExec("^DELETE FROM schema_migrations WHERE version = (.+)$").
+		WithArgs(int64(2)).
+		WillReturnResult(pgconn.CommandTag("DELETE 1"))
+	mock.ExpectCommit()
+	mock.ExpectExec("^SELECT pg_advisory_unlock(.+)$").
+		WithArgs(migrationLockID).
+		WillReturnResult(pgconn.CommandTag("SELECT 1"))
+
+	done, err := migrateDown(mock, migrations, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(done) != 1 || done[0].Version != 2 {
+		t.Errorf("expected only the newest migration to be reverted, got %v", done)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
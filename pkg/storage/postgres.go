@@ -50,15 +50,6 @@ func (psqlInterface *PsqlInterface) Init(addr string) error {
 	return nil
 }
 
-func (psqlInterface *PsqlInterface) ExecFromString(postgresFileContents string) error {
-	tag, err := psqlInterface.Pool.Exec(context.Background(), postgresFileContents)
-	if err != nil {
-		return err
-	}
-	log.Println(tag.String())
-	return nil
-}
-
 func insertGuild(conn PgxIface, guildID uint64, guildName string) error {
 	_, err := conn.Exec(context.Background(), "INSERT INTO guilds VALUES ($1, $2, 0);", guildID, guildName)
 	return err
@@ -136,6 +127,11 @@ func optUser(conn PgxIface, uid uint64, opt bool) error {
 		if err != nil {
 			return err
 		}
+
+		_, err = conn.Exec(context.Background(), "DELETE FROM player_aliases WHERE user_id = $1;", uid)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -208,8 +204,18 @@ func (psqlInterface *PsqlInterface) GetGameEvents(matchID string) ([]*PostgresGa
 	return events, nil
 }
 
+// GetGamePlayers returns the players of a game, as they were recorded when it ended
+func (psqlInterface *PsqlInterface) GetGamePlayers(matchID string) ([]*PostgresUserGame, error) {
+	var players []*PostgresUserGame
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &players, "SELECT * FROM users_games WHERE game_id = $1 ORDER BY player_color;", matchID)
+	if err != nil {
+		return nil, err
+	}
+	return players, nil
+}
+
 func insertGame(conn PgxIface, game *PostgresGame) (uint64, error) {
-	t, err := conn.Query(context.Background(), "INSERT INTO games VALUES (DEFAULT, $1, $2, $3, $4, $5) RETURNING game_id;", game.GuildID, game.ConnectCode, game.StartTime, game.WinType, game.EndTime)
+	t, err := conn.Query(context.Background(), "INSERT INTO games VALUES (DEFAULT, $1, $2, $3, $4, $5, $6) RETURNING game_id;", game.GuildID, game.ConnectCode, game.StartTime, game.WinType, game.EndTime, game.GameMode)
 	if t != nil {
 		for t.Next() {
 			g := uint64(0)
@@ -227,13 +233,13 @@ func insertGame(conn PgxIface, game *PostgresGame) (uint64, error) {
 	return 0, err
 }
 
-func updateGame(conn PgxIface, gameID int64, winType int16, endTime int64) error {
+func updateGame(conn PgxIface, gameID int64, winType int16, endTime time.Time) error {
 	_, err := conn.Exec(context.Background(), "UPDATE games SET (win_type, end_time) = ($1, $2) WHERE game_id = $3;", winType, endTime, gameID)
 	return err
 }
 
 func insertPlayer(conn PgxIface, player *PostgresUserGame) error {
-	_, err := conn.Exec(context.Background(), "INSERT INTO users_games VALUES ($1, $2, $3, $4, $5, $6, $7);", player.UserID, player.GuildID, player.GameID, player.PlayerName, player.PlayerColor, player.PlayerRole, player.PlayerWon)
+	_, err := conn.Exec(context.Background(), "INSERT INTO users_games VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);", player.UserID, player.GuildID, player.GameID, player.PlayerName, player.PlayerColor, player.PlayerRole, player.PlayerWon, player.PlayerRoleName, player.GameMode)
 	return err
 }
 
@@ -467,7 +473,7 @@ func (psqlInterface *PsqlInterface) GetUsersGamesForGuild(guildID uint64) ([]*Po
 
 func getUsersGamesForGuild(conn PgxIface, guildID uint64) ([]*PostgresUserGame, error) {
 	var r []*PostgresUserGame
-	err := pgxscan.Select(context.Background(), conn, &r, "SELECT DISTINCT users_games.user_id,guild_id,game_id,player_name,player_color,player_role,player_won "+
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT DISTINCT users_games.user_id,guild_id,game_id,player_name,player_color,player_role,player_won,player_role_name,users_games.game_mode "+
 		"FROM users_games "+
 		"INNER JOIN users u ON u.user_id = users_games.user_id "+
 		"WHERE guild_id = $1 AND u.opt = true", guildID)
@@ -497,7 +503,7 @@ func (psqlInterface *PsqlInterface) AddEvent(event *PostgresGameEvent) error {
 }
 
 // make sure to call the relevant "ensure" methods before this one...
-func (psqlInterface *PsqlInterface) UpdateGameAndPlayers(gameID int64, winType int16, endTime int64, players []*PostgresUserGame) error {
+func (psqlInterface *PsqlInterface) UpdateGameAndPlayers(gameID int64, winType int16, endTime time.Time, players []*PostgresUserGame) error {
 	conn, err := psqlInterface.Pool.Acquire(context.Background())
 	if err != nil {
 		return err
@@ -4,6 +4,7 @@ import (
 	"github.com/automuteus/automuteus/v8/pkg/premium"
 	"github.com/jackc/pgconn"
 	"github.com/pashagolub/pgxmock"
+	"strings"
 	"testing"
 	"time"
 )
@@ -211,6 +212,11 @@ func TestOptUser(t *testing.T) {
 		WithArgs(UserIDInt).
 		WillReturnResult(pgconn.CommandTag{})
 
+	// expect all the user's in-game name aliases to be forgotten
+	mock.ExpectExec("^DELETE FROM player_aliases WHERE user_id = (.+)$").
+		WithArgs(UserIDInt).
+		WillReturnResult(pgconn.CommandTag{})
+
 	err = optUser(mock, UserIDInt, false)
 	if err != nil {
 		t.Error(err)
@@ -221,3 +227,27 @@ func TestOptUser(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetUsersGamesForGuild(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectQuery("^SELECT DISTINCT users_games.user_id,(.+),users_games.game_mode FROM users_games (.+)$").
+		WithArgs(GuildIDInt).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "guild_id", "game_id", "player_name", "player_color", "player_role", "player_won", "player_role_name", "game_mode"}).
+				AddRow(UserIDInt, GuildIDInt, int64(2), "tom", int16(3), int16(1), true, "Seeker", "hide-and-seek"))
+
+	usersGames, err := getUsersGamesForGuild(mock, GuildIDInt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := strings.Split(UsersGamesToCSV(usersGames), "\n")[1]; row != UserID+","+GuildID+",2,tom,3,1,true,Seeker,hide-and-seek," {
+		t.Errorf("expected the game mode in the download, got %s", row)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
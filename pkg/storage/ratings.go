@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/rating"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+)
+
+// ratedPlayer is a linked player of a game, with the rating they went into it with
+type ratedPlayer struct {
+	UserID     uint64  `db:"user_id"`
+	GuildID    uint64  `db:"guild_id"`
+	PlayerRole int16   `db:"player_role"`
+	PlayerWon  bool    `db:"player_won"`
+	Rating     float64 `db:"rating"`
+	Games      int32   `db:"games"`
+}
+
+// UpdateRatings rates the crewmates and impostors linked in a game against each other. Call it after
+// UpdateGameAndPlayers; a game is only ever rated once
+func (psqlInterface *PsqlInterface) UpdateRatings(gameID int64) error {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	return updateRatings(conn.Conn(), gameID)
+}
+
+func updateRatings(conn PgxIface, gameID int64) error {
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return err
+	}
+	// no-op once committed
+	defer tx.Rollback(context.Background())
+
+	// neutral roles don't play for either team, and hide-and-seek isn't the same game
+	players := "users_games.game_id = $1 AND users_games.player_role IN ($2, $3) AND users_games." + classicGames
+
+	// new players get a rating to lock first; the rollback drops it again if the game was already rated
+	_, err = tx.Exec(context.Background(), "INSERT INTO player_ratings "+
+		"SELECT users_games.guild_id, users_games.user_id, users_games.player_role, $4, 0 "+
+		"FROM users_games WHERE "+players+" "+
+		"ON CONFLICT DO NOTHING;", gameID, int16(game.CrewmateRole), int16(game.ImposterRole), rating.Default)
+	if err != nil {
+		return err
+	}
+
+	var rated []*ratedPlayer
+	// locked until the game is rated, so a player in two games that end at once isn't rated from the same rating twice
+	err = pgxscan.Select(context.Background(), tx, &rated, "SELECT users_games.user_id, users_games.guild_id, "+
+		"users_games.player_role, users_games.player_won, player_ratings.rating, player_ratings.games "+
+		"FROM users_games "+
+		"INNER JOIN player_ratings ON player_ratings.guild_id = users_games.guild_id "+
+		"AND player_ratings.user_id = users_games.user_id AND player_ratings.player_role = users_games.player_role "+
+		"WHERE "+players+" "+
+		"AND NOT EXISTS (SELECT 1 FROM rating_history WHERE rating_history.game_id = users_games.game_id) "+
+		"ORDER BY users_games.user_id "+
+		"FOR UPDATE OF player_ratings;", gameID, int16(game.CrewmateRole), int16(game.ImposterRole))
+	if err != nil {
+		return err
+	}
+	if len(rated) == 0 {
+		return nil
+	}
+
+	var winners, losers []*ratedPlayer
+	var winnerRatings, loserRatings []rating.Player
+	for _, p := range rated {
+		if p.PlayerWon {
+			winners = append(winners, p)
+			winnerRatings = append(winnerRatings, rating.Player{Rating: p.Rating, Games: int(p.Games)})
+		} else {
+			losers = append(losers, p)
+			loserRatings = append(loserRatings, rating.Player{Rating: p.Rating, Games: int(p.Games)})
+		}
+	}
+	newWinnerRatings, newLoserRatings := rating.Update(winnerRatings, loserRatings)
+	for i, p := range winners {
+		err = saveRating(tx, gameID, p, newWinnerRatings[i])
+		if err != nil {
+			return err
+		}
+	}
+	for i, p := range losers {
+		err = saveRating(tx, gameID, p, newLoserRatings[i])
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(context.Background())
+}
+
+func saveRating(tx pgx.Tx, gameID int64, p *ratedPlayer, newRating float64) error {
+	_, err := tx.Exec(context.Background(), "INSERT INTO player_ratings VALUES ($1, $2, $3, $4, 1) "+
+		"ON CONFLICT (guild_id, user_id, player_role) DO UPDATE SET "+
+		"rating = EXCLUDED.rating, games = player_ratings.games + 1;",
+		p.GuildID, p.UserID, p.PlayerRole, newRating)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(context.Background(), "INSERT INTO rating_history VALUES ($1, $2, $3, $4, $5, $6);",
+		gameID, p.GuildID, p.UserID, p.PlayerRole, p.Rating, newRating)
+	return err
+}
+
+// RatingsForPlayerOnServer is the user's rating for every role they've been rated as on the guild. Over anything but all
+// time, the ratings are where the games in the range would have taken a new player (see ratingFromHistory)
+func (psqlInterface *PsqlInterface) RatingsForPlayerOnServer(userID, guildID string, statsRange StatsRange) ([]*PostgresPlayerRating, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return ratingsForPlayerOnServer(conn.Conn(), userID, guildID, statsRange)
+}
+
+// ratingFromHistory rates players by how much the games in a range moved them, on top of rating.Default, so a season's
+// ratings start everybody from the same place without having to keep separate ratings for every season. It's an
+// approximation: each game's change was worked out from the players' all-time ratings, not from their season ratings
+const ratingFromHistory = "guild_id, user_id, player_role, " +
+	"$%[1]d + SUM(rating_after - rating_before) AS rating, " +
+	"COUNT(*)::integer AS games "
+
+func ratingsForPlayerOnServer(conn PgxIface, userID, guildID string, statsRange StatsRange) ([]*PostgresPlayerRating, error) {
+	var r []*PostgresPlayerRating
+	if !statsRange.IsAllTime() {
+		cond, args := statsRange.filter("game_id", 2, 4)
+		err := pgxscan.Select(context.Background(), conn, &r, "SELECT "+fmt.Sprintf(ratingFromHistory, 3)+", "+
+			"(ARRAY_AGG(rating_after - rating_before ORDER BY game_id DESC))[1] AS last_change "+
+			"FROM rating_history "+
+			"WHERE user_id = $1 AND guild_id = $2"+cond+" "+
+			"GROUP BY guild_id, user_id, player_role "+
+			"ORDER BY player_role;", append([]interface{}{userID, guildID, rating.Default}, args...)...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT guild_id, user_id, player_role, rating, games, "+
+		"COALESCE((SELECT rating_after - rating_before FROM rating_history "+
+		"WHERE rating_history.guild_id = player_ratings.guild_id AND rating_history.user_id = player_ratings.user_id "+
+		"AND rating_history.player_role = player_ratings.player_role "+
+		"ORDER BY game_id DESC LIMIT 1), 0) AS last_change "+
+		"FROM player_ratings "+
+		"WHERE user_id = $1 AND guild_id = $2 "+
+		"ORDER BY player_role;", userID, guildID)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RatingRankingForServer is the best rated players of the role on the guild, out of those with at least minGames
+// rated games
+func (psqlInterface *PsqlInterface) RatingRankingForServer(guildID string, role int16, minGames, limit int, statsRange StatsRange) ([]*PostgresPlayerRating, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return ratingRankingForServer(conn.Conn(), guildID, role, minGames, limit, statsRange)
+}
+
+func ratingRankingForServer(conn PgxIface, guildID string, role int16, minGames, limit int, statsRange StatsRange) ([]*PostgresPlayerRating, error) {
+	var r []*PostgresPlayerRating
+	if !statsRange.IsAllTime() {
+		cond, args := statsRange.filter("game_id", 1, 6)
+		err := pgxscan.Select(context.Background(), conn, &r, "SELECT "+fmt.Sprintf(ratingFromHistory, 5)+
+			"FROM rating_history "+
+			"WHERE guild_id = $1 AND player_role = $2"+cond+" "+
+			"GROUP BY guild_id, user_id, player_role "+
+			"HAVING COUNT(*) >= $3 "+
+			"ORDER BY rating DESC, games DESC "+
+			"LIMIT $4;", append([]interface{}{guildID, role, minGames, limit, rating.Default}, args...)...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT guild_id, user_id, player_role, rating, games "+
+		"FROM player_ratings "+
+		"WHERE guild_id = $1 AND player_role = $2 AND games >= $3 "+
+		"ORDER BY rating DESC, games DESC "+
+		"LIMIT $4;", guildID, role, minGames, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
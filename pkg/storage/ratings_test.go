@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"github.com/automuteus/automuteus/v8/pkg/rating"
+	"github.com/jackc/pgconn"
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"time"
+)
+
+func TestUpdateRatings(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	const otherUserID uint64 = 345345345345345345
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO player_ratings SELECT (.+) FROM users_games WHERE users_games.game_id = (.+) ON CONFLICT DO NOTHING;$").
+		WithArgs(int64(7), int16(0), int16(1), rating.Default).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	// the players' ratings are locked while they're updated
+	mock.ExpectQuery("^SELECT users_games.user_id, (.+) FROM users_games INNER JOIN player_ratings (.+) WHERE users_games.game_id = (.+) AND NOT EXISTS (.+) FOR UPDATE OF player_ratings;$").
+		WithArgs(int64(7), int16(0), int16(1)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "guild_id", "player_role", "player_won", "rating", "games"}).
+				AddRow(UserIDInt, GuildIDInt, int16(0), true, 1500.0, int32(20)).
+				AddRow(otherUserID, GuildIDInt, int16(1), false, 1500.0, int32(20)))
+	mock.ExpectExec("^INSERT INTO player_ratings VALUES (.+) ON CONFLICT (.+)$").
+		WithArgs(GuildIDInt, UserIDInt, int16(0), 1500+rating.K/2).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectExec("^INSERT INTO rating_history VALUES (.+)$").
+		WithArgs(int64(7), GuildIDInt, UserIDInt, int16(0), 1500.0, 1500+rating.K/2).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectExec("^INSERT INTO player_ratings VALUES (.+) ON CONFLICT (.+)$").
+		WithArgs(GuildIDInt, otherUserID, int16(1), 1500-rating.K/2).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectExec("^INSERT INTO rating_history VALUES (.+)$").
+		WithArgs(int64(7), GuildIDInt, otherUserID, int16(1), 1500.0, 1500-rating.K/2).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+	mock.ExpectCommit()
+
+	err = updateRatings(mock, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// already rated (or nobody linked): nothing to write
+	mock.ExpectBegin()
+	mock.ExpectExec("^INSERT INTO player_ratings SELECT (.+)$").
+		WithArgs(int64(7), int16(0), int16(1), rating.Default).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 0"))
+	mock.ExpectQuery("^SELECT users_games.user_id, (.+)$").
+		WithArgs(int64(7), int16(0), int16(1)).
+		WillReturnRows(pgxmock.NewRows([]string{"user_id", "guild_id", "player_role", "player_won", "rating", "games"}))
+	mock.ExpectRollback()
+
+	err = updateRatings(mock, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRatingsForPlayerOnServer(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectQuery("^SELECT guild_id, user_id, player_role, rating, games, (.+) FROM player_ratings WHERE user_id = (.+) AND guild_id = (.+)$").
+		WithArgs(UserID, GuildID).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"guild_id", "user_id", "player_role", "rating", "games", "last_change"}).
+				AddRow(GuildIDInt, UserIDInt, int16(0), 1532.5, int32(14), -11.5))
+
+	ratings, err := ratingsForPlayerOnServer(mock, UserID, GuildID, StatsRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 1 || ratings[0].Rating != 1532.5 || ratings[0].Games != 14 || ratings[0].LastChange != -11.5 {
+		t.Errorf("unexpected ratings: %v", ratings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRatingRankingForServer(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectQuery("^SELECT guild_id, user_id, player_role, rating, games FROM player_ratings WHERE guild_id = (.+) ORDER BY rating DESC, games DESC LIMIT (.+)$").
+		WithArgs(GuildID, int16(1), 3, 5).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"guild_id", "user_id", "player_role", "rating", "games"}).
+				AddRow(GuildIDInt, UserIDInt, int16(1), 1610.0, int32(8)))
+
+	rankings, err := ratingRankingForServer(mock, GuildID, 1, 3, 5, StatsRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rankings) != 1 || rankings[0].UserID != UserIDInt || rankings[0].Rating != 1610 {
+		t.Errorf("unexpected rankings: %v", rankings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRatingRankingForSeason(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	season := NewStatsRange(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	mock.ExpectQuery("^SELECT guild_id, user_id, player_role, (.+) FROM rating_history WHERE guild_id = (.+) AND game_id IN \\(SELECT game_id FROM games (.+)\\) GROUP BY (.+) HAVING (.+)$").
+		WithArgs(GuildID, int16(0), 3, 5, rating.Default, season.Start, season.End).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"guild_id", "user_id", "player_role", "rating", "games"}).
+				AddRow(GuildIDInt, UserIDInt, int16(0), 1548.0, int32(6)))
+
+	rankings, err := ratingRankingForServer(mock, GuildID, 0, 3, 5, season)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rankings) != 1 || rankings[0].Rating != 1548 || rankings[0].Games != 6 {
+		t.Errorf("unexpected rankings: %v", rankings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/georgysavva/scany/pgxscan"
+)
+
+// RoleRankingForPlayerOnServer is how often a user won with every role they played on the guild, most played first
+func (psqlInterface *PsqlInterface) RoleRankingForPlayerOnServer(userID, guildID string, statsRange StatsRange) ([]*PostgresRoleRanking, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return roleRankingForPlayerOnServer(conn.Conn(), userID, guildID, statsRange)
+}
+
+func roleRankingForPlayerOnServer(conn PgxIface, userID, guildID string, statsRange StatsRange) ([]*PostgresRoleRanking, error) {
+	var r []*PostgresRoleRanking
+	cond, args := statsRange.filter("game_id", 2, 3)
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT player_role_name AS role_name, "+
+		"MIN(player_role) AS player_role, "+
+		"COUNT(*) FILTER ( WHERE player_won = TRUE ) AS win, "+
+		"COUNT(*) AS total, "+
+		"(COUNT(*) FILTER ( WHERE player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
+		"FROM users_games "+
+		"WHERE user_id = $1 AND guild_id = $2"+cond+" "+
+		"GROUP BY player_role_name "+
+		"ORDER BY total DESC, role_name;", append([]interface{}{userID, guildID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RoleRankingForServer is how often every role won on the guild, counting each game once per role
+func (psqlInterface *PsqlInterface) RoleRankingForServer(guildID string, statsRange StatsRange) ([]*PostgresRoleRanking, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return roleRankingForServer(conn.Conn(), guildID, statsRange)
+}
+
+func roleRankingForServer(conn PgxIface, guildID string, statsRange StatsRange) ([]*PostgresRoleRanking, error) {
+	var r []*PostgresRoleRanking
+	cond, args := statsRange.filter("game_id", 1, 2)
+	// players with the same role in the same game (e.g. two impostors) won or lost together
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT role_name, "+
+		"MIN(player_role) AS player_role, "+
+		"COUNT(*) FILTER ( WHERE won = TRUE ) AS win, "+
+		"COUNT(*) AS total, "+
+		"(COUNT(*) FILTER ( WHERE won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
+		"FROM (SELECT game_id, player_role_name AS role_name, MIN(player_role) AS player_role, BOOL_OR(player_won) AS won "+
+		"FROM users_games WHERE guild_id = $1"+cond+" GROUP BY game_id, player_role_name) role_games "+
+		"GROUP BY role_name "+
+		"ORDER BY total DESC, role_name;", append([]interface{}{guildID}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// RoleRecordsForPlayersOnServer is how many games every one of the users played and won as crewmate and as impostor on
+// the guild. Users that never played there are left out
+func (psqlInterface *PsqlInterface) RoleRecordsForPlayersOnServer(guildID string, userIDs []string, statsRange StatsRange) ([]*PostgresPlayerRoleRecord, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return roleRecordsForPlayersOnServer(conn.Conn(), guildID, userIDs, statsRange)
+}
+
+func roleRecordsForPlayersOnServer(conn PgxIface, guildID string, userIDs []string, statsRange StatsRange) ([]*PostgresPlayerRoleRecord, error) {
+	var r []*PostgresPlayerRoleRecord
+	cond, args := statsRange.filter("game_id", 1, 5)
+	err := pgxscan.Select(context.Background(), conn, &r, "SELECT user_id, player_role, "+
+		"COUNT(*) FILTER ( WHERE player_won = TRUE ) AS win, "+
+		"COUNT(*) AS total "+
+		"FROM users_games "+
+		"WHERE guild_id = $1 AND user_id = ANY($2::numeric[]) AND player_role IN ($3, $4) AND "+classicGames+cond+" "+
+		"GROUP BY user_id, player_role;", append([]interface{}{guildID, userIDs, int16(game.CrewmateRole), int16(game.ImposterRole)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
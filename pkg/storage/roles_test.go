@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"time"
+)
+
+func TestRoleRankingForPlayerOnServer(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectQuery("^SELECT player_role_name AS role_name, (.+) FROM users_games WHERE user_id = (.+) AND guild_id = (.+) GROUP BY player_role_name (.+)$").
+		WithArgs(UserID, GuildID).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"role_name", "player_role", "win", "total", "win_rate"}).
+				AddRow("Crewmate", int16(0), int64(6), int64(10), 60.0).
+				AddRow("Jester", int16(2), int64(1), int64(4), 25.0))
+
+	rankings, err := roleRankingForPlayerOnServer(mock, UserID, GuildID, StatsRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rankings) != 2 || rankings[1].RoleName != "Jester" || rankings[1].PlayerRole != 2 || rankings[1].WinCount != 1 {
+		t.Errorf("unexpected role rankings: %v", rankings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRoleRankingForServer(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	mock.ExpectQuery("^SELECT role_name, (.+) FROM \\(SELECT game_id, (.+) FROM users_games WHERE guild_id = (.+) GROUP BY game_id, player_role_name\\) role_games GROUP BY role_name (.+)$").
+		WithArgs(GuildID).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"role_name", "player_role", "win", "total", "win_rate"}).
+				AddRow("Impostor", int16(1), int64(12), int64(20), 60.0))
+
+	rankings, err := roleRankingForServer(mock, GuildID, StatsRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rankings) != 1 || rankings[0].Count != 20 || rankings[0].WinRate != 60 {
+		t.Errorf("unexpected role rankings: %v", rankings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRoleRecordsForPlayersOnServer(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	userIDs := []string{UserID, "345345345345345345"}
+	mock.ExpectQuery("^SELECT user_id, player_role, (.+) FROM users_games WHERE guild_id = (.+) AND user_id = ANY(.+) GROUP BY user_id, player_role;$").
+		WithArgs(GuildID, userIDs, int16(0), int16(1)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"user_id", "player_role", "win", "total"}).
+				AddRow(UserIDInt, int16(0), int64(6), int64(10)).
+				AddRow(UserIDInt, int16(1), int64(3), int64(4)))
+
+	records, err := roleRecordsForPlayersOnServer(mock, GuildID, userIDs, StatsRange{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].PlayerRole != 1 || records[1].WinCount != 3 || records[1].Count != 4 {
+		t.Errorf("unexpected role records: %v", records)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRoleRankingForServerInSeason(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	// seasons that are still going on are open-ended
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	season := StatsRange{Start: &start}
+	mock.ExpectQuery("^SELECT role_name, (.+) FROM users_games WHERE guild_id = \\$1 AND game_id IN \\(SELECT game_id FROM games WHERE guild_id = \\$1 AND \\(\\$2::timestamptz IS NULL OR start_time >= \\$2\\) AND \\(\\$3::timestamptz IS NULL OR start_time < \\$3\\)\\) GROUP BY game_id, player_role_name\\) (.+)$").
+		WithArgs(GuildID, &start, (*time.Time)(nil)).
+		WillReturnRows(
+			pgxmock.NewRows([]string{"role_name", "player_role", "win", "total", "win_rate"}).
+				AddRow("Impostor", int16(1), int64(2), int64(5), 40.0))
+
+	rankings, err := roleRankingForServer(mock, GuildID, season)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rankings) != 1 || rankings[0].Count != 5 {
+		t.Errorf("unexpected role rankings: %v", rankings)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
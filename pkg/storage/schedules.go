@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+func (psqlInterface *PsqlInterface) AddScheduledSession(session *PostgresScheduledSession) (int64, error) {
+	var id int64
+	err := psqlInterface.Pool.QueryRow(context.Background(), "INSERT INTO scheduled_sessions "+
+		"(guild_id, text_channel_id, voice_channel_id, host_role_id, created_by, title, start_time, timezone, recurrence) "+
+		"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING session_id;",
+		session.GuildID, session.TextChannelID, session.VoiceChannelID, session.HostRoleID, session.CreatedBy,
+		session.Title, session.StartTime, session.Timezone, session.Recurrence).Scan(&id)
+	return id, err
+}
+
+// GetScheduledSession returns nil (and no error) if the session doesn't exist anymore
+func (psqlInterface *PsqlInterface) GetScheduledSession(sessionID int64) (*PostgresScheduledSession, error) {
+	var session PostgresScheduledSession
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &session,
+		"SELECT * FROM scheduled_sessions WHERE session_id = $1;", sessionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetScheduledSessions returns the guild's sessions, next one first
+func (psqlInterface *PsqlInterface) GetScheduledSessions(guildID string) ([]*PostgresScheduledSession, error) {
+	var sessions []*PostgresScheduledSession
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &sessions,
+		"SELECT * FROM scheduled_sessions WHERE guild_id = $1 ORDER BY start_time;", guildID)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// DeleteScheduledSession cancels a session on the guild. Returns how many sessions were deleted
+func (psqlInterface *PsqlInterface) DeleteScheduledSession(guildID string, sessionID int64) (int64, error) {
+	tag, err := psqlInterface.Pool.Exec(context.Background(),
+		"DELETE FROM scheduled_sessions WHERE guild_id = $1 AND session_id = $2;", guildID, sessionID)
+	return tag.RowsAffected(), err
+}
+
+// GetSessionsToRemind returns the sessions starting by before that haven't had a reminder posted yet
+func (psqlInterface *PsqlInterface) GetSessionsToRemind(before time.Time) ([]*PostgresScheduledSession, error) {
+	var sessions []*PostgresScheduledSession
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &sessions,
+		"SELECT * FROM scheduled_sessions WHERE reminder_message_id IS NULL AND start_time <= $1;", before)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetSessionsToStart returns the sessions that were due to start by now
+func (psqlInterface *PsqlInterface) GetSessionsToStart(now time.Time) ([]*PostgresScheduledSession, error) {
+	var sessions []*PostgresScheduledSession
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &sessions,
+		"SELECT * FROM scheduled_sessions WHERE start_time <= $1;", now)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ClaimSessionReminder marks the reminder for the session's next occurrence as being posted. Only one caller (across
+// every shard) gets true for each occurrence, and has to post the reminder and record it with SetSessionReminder
+func (psqlInterface *PsqlInterface) ClaimSessionReminder(session *PostgresScheduledSession) (bool, error) {
+	tag, err := psqlInterface.Pool.Exec(context.Background(), "UPDATE scheduled_sessions SET reminder_message_id = 0 "+
+		"WHERE session_id = $1 AND start_time = $2 AND reminder_message_id IS NULL;", session.SessionID, session.StartTime)
+	return tag.RowsAffected() == 1, err
+}
+
+func (psqlInterface *PsqlInterface) SetSessionReminder(sessionID int64, messageID string) error {
+	_, err := psqlInterface.Pool.Exec(context.Background(),
+		"UPDATE scheduled_sessions SET reminder_message_id = $2 WHERE session_id = $1;", sessionID, messageID)
+	return err
+}
+
+// AdvanceScheduledSession moves a session that's starting on to its next occurrence, or deletes it if next is nil.
+// RSVPs are for one occurrence, so they're cleared. Only one caller (across every shard) gets true for each occurrence,
+// and has to start the game
+func (psqlInterface *PsqlInterface) AdvanceScheduledSession(session *PostgresScheduledSession, next *time.Time) (bool, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	return advanceScheduledSession(conn.Conn(), session, next)
+}
+
+func advanceScheduledSession(conn PgxIface, session *PostgresScheduledSession, next *time.Time) (bool, error) {
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return false, err
+	}
+	// no-op once committed
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), "DELETE FROM scheduled_session_rsvps WHERE session_id = $1;", session.SessionID)
+	if err != nil {
+		return false, err
+	}
+	var tag pgconn.CommandTag
+	if next == nil {
+		tag, err = tx.Exec(context.Background(), "DELETE FROM scheduled_sessions WHERE session_id = $1 AND start_time = $2;",
+			session.SessionID, session.StartTime)
+	} else {
+		tag, err = tx.Exec(context.Background(), "UPDATE scheduled_sessions SET start_time = $3, reminder_message_id = NULL "+
+			"WHERE session_id = $1 AND start_time = $2;", session.SessionID, session.StartTime, *next)
+	}
+	if err != nil {
+		return false, err
+	}
+	// someone else already advanced it
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+	return true, tx.Commit(context.Background())
+}
+
+// ToggleSessionRSVP adds the user to the session's RSVPs, or removes them if they were on it already. Returns whether
+// the user is going now
+func (psqlInterface *PsqlInterface) ToggleSessionRSVP(sessionID int64, userID string) (bool, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Release()
+	return toggleSessionRSVP(conn.Conn(), sessionID, userID)
+}
+
+func toggleSessionRSVP(conn PgxIface, sessionID int64, userID string) (bool, error) {
+	tag, err := conn.Exec(context.Background(),
+		"DELETE FROM scheduled_session_rsvps WHERE session_id = $1 AND user_id = $2;", sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() > 0 {
+		return false, nil
+	}
+	_, err = conn.Exec(context.Background(), "INSERT INTO scheduled_session_rsvps VALUES ($1, $2);", sessionID, userID)
+	return err == nil, err
+}
+
+func (psqlInterface *PsqlInterface) GetSessionRSVPs(sessionID int64) ([]uint64, error) {
+	var userIDs []uint64
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &userIDs,
+		"SELECT user_id FROM scheduled_session_rsvps WHERE session_id = $1 ORDER BY user_id;", sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
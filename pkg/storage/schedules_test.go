@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"time"
+)
+
+func TestAdvanceScheduledSession(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	session := &PostgresScheduledSession{SessionID: 7, StartTime: time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)}
+	next := session.StartTime.Add(7 * 24 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM scheduled_session_rsvps WHERE session_id = (.+)$").
+		WithArgs(int64(7)).
+		WillReturnResult(pgconn.CommandTag("DELETE 3"))
+	mock.ExpectExec("^UPDATE scheduled_sessions SET start_time = (.+), reminder_message_id = NULL WHERE (.+)$").
+		WithArgs(int64(7), session.StartTime, next).
+		WillReturnResult(pgconn.CommandTag("UPDATE 1"))
+	mock.ExpectCommit()
+
+	advanced, err := advanceScheduledSession(mock, session, &next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !advanced {
+		t.Error("expected the session to be advanced")
+	}
+
+	// another shard got there first; the RSVPs it cleared must not be cleared twice
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM scheduled_session_rsvps WHERE session_id = (.+)$").
+		WithArgs(int64(7)).
+		WillReturnResult(pgconn.CommandTag("DELETE 0"))
+	mock.ExpectExec("^DELETE FROM scheduled_sessions WHERE session_id = (.+) AND start_time = (.+)$").
+		WithArgs(int64(7), session.StartTime).
+		WillReturnResult(pgconn.CommandTag("DELETE 0"))
+	mock.ExpectRollback()
+
+	advanced, err = advanceScheduledSession(mock, session, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if advanced {
+		t.Error("expected a session that was already advanced not to be claimed again")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestToggleSessionRSVP(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	mock.ExpectExec("^DELETE FROM scheduled_session_rsvps WHERE session_id = (.+) AND user_id = (.+)$").
+		WithArgs(int64(7), UserID).
+		WillReturnResult(pgconn.CommandTag("DELETE 0"))
+	mock.ExpectExec("^INSERT INTO scheduled_session_rsvps VALUES (.+)$").
+		WithArgs(int64(7), UserID).
+		WillReturnResult(pgconn.CommandTag("INSERT 0 1"))
+
+	going, err := toggleSessionRSVP(mock, 7, UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !going {
+		t.Error("expected the user to be going after their first RSVP")
+	}
+
+	mock.ExpectExec("^DELETE FROM scheduled_session_rsvps WHERE session_id = (.+) AND user_id = (.+)$").
+		WithArgs(int64(7), UserID).
+		WillReturnResult(pgconn.CommandTag("DELETE 1"))
+
+	going, err = toggleSessionRSVP(mock, 7, UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if going {
+		t.Error("expected a second RSVP to take the first one back")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
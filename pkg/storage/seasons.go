@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"time"
+)
+
+var ErrSeasonExists = errors.New("the guild already has a season with that name")
+
+// postgres' code for a unique constraint violation
+const uniqueViolation = "23505"
+
+func (psqlInterface *PsqlInterface) AddSeason(season *PostgresSeason) (int64, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+	return addSeason(conn.Conn(), season)
+}
+
+func addSeason(conn PgxIface, season *PostgresSeason) (int64, error) {
+	// a season that's already over when it's created isn't news, so it never gets a summary
+	season.SummaryPosted = !season.EndTime.After(time.Now())
+	var id int64
+	err := conn.QueryRow(context.Background(), "INSERT INTO seasons (guild_id, name, start_time, end_time, summary_posted) "+
+		"VALUES ($1, $2, $3, $4, $5) RETURNING season_id;",
+		season.GuildID, season.Name, season.StartTime, season.EndTime, season.SummaryPosted).Scan(&id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return 0, ErrSeasonExists
+	}
+	return id, err
+}
+
+// GetSeason returns nil (and no error) if the guild has no season by that name
+func (psqlInterface *PsqlInterface) GetSeason(guildID, name string) (*PostgresSeason, error) {
+	conn, err := psqlInterface.Pool.Acquire(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Release()
+	return getSeason(conn.Conn(), guildID, name)
+}
+
+func getSeason(conn PgxIface, guildID, name string) (*PostgresSeason, error) {
+	var season PostgresSeason
+	err := pgxscan.Get(context.Background(), conn, &season,
+		"SELECT * FROM seasons WHERE guild_id = $1 AND name = $2;", guildID, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// GetSeasons returns the guild's seasons, latest one first
+func (psqlInterface *PsqlInterface) GetSeasons(guildID string) ([]*PostgresSeason, error) {
+	var seasons []*PostgresSeason
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &seasons,
+		"SELECT * FROM seasons WHERE guild_id = $1 ORDER BY start_time DESC;", guildID)
+	if err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// DeleteSeason deletes a season on the guild (the games in it are kept). Returns how many seasons were deleted
+func (psqlInterface *PsqlInterface) DeleteSeason(guildID, name string) (int64, error) {
+	tag, err := psqlInterface.Pool.Exec(context.Background(),
+		"DELETE FROM seasons WHERE guild_id = $1 AND name = $2;", guildID, name)
+	return tag.RowsAffected(), err
+}
+
+// GetEndedSeasons returns the seasons that ended by now and haven't had their summary posted yet
+func (psqlInterface *PsqlInterface) GetEndedSeasons(now time.Time) ([]*PostgresSeason, error) {
+	var seasons []*PostgresSeason
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &seasons,
+		"SELECT * FROM seasons WHERE summary_posted = false AND end_time <= $1;", now)
+	if err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+// ClaimSeasonSummary marks the season's summary as posted. Only one caller (across every shard) gets true for each
+// season, and has to post the summary, or give the claim back with ReleaseSeasonSummary if it couldn't
+func (psqlInterface *PsqlInterface) ClaimSeasonSummary(seasonID int64) (bool, error) {
+	tag, err := psqlInterface.Pool.Exec(context.Background(),
+		"UPDATE seasons SET summary_posted = true WHERE season_id = $1 AND summary_posted = false;", seasonID)
+	return tag.RowsAffected() == 1, err
+}
+
+// ReleaseSeasonSummary marks the season's summary as not posted, so it's tried again
+func (psqlInterface *PsqlInterface) ReleaseSeasonSummary(seasonID int64) error {
+	_, err := psqlInterface.Pool.Exec(context.Background(),
+		"UPDATE seasons SET summary_posted = false WHERE season_id = $1;", seasonID)
+	return err
+}
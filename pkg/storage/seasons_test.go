@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"errors"
+	"github.com/jackc/pgconn"
+	"github.com/pashagolub/pgxmock"
+	"testing"
+	"time"
+)
+
+func TestAddSeason(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	season := &PostgresSeason{
+		GuildID:   GuildIDInt,
+		Name:      "Spring",
+		StartTime: time.Date(2024, 3, 31, 15, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 6, 30, 15, 0, 0, 0, time.UTC),
+	}
+
+	mock.ExpectQuery("^INSERT INTO seasons (.+) RETURNING season_id;$").
+		WithArgs(GuildIDInt, "Spring", season.StartTime, season.EndTime, true).
+		WillReturnRows(pgxmock.NewRows([]string{"season_id"}).AddRow(int64(4)))
+	id, err := addSeason(mock, season)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("expected the new season's ID, got %d", id)
+	}
+
+	mock.ExpectQuery("^INSERT INTO seasons (.+) RETURNING season_id;$").
+		WithArgs(GuildIDInt, "Spring", season.StartTime, season.EndTime, true).
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation})
+	_, err = addSeason(mock, season)
+	if !errors.Is(err, ErrSeasonExists) {
+		t.Errorf("expected a season with a name that's taken to be rejected, got %v", err)
+	}
+
+	// only seasons that haven't ended yet get a summary
+	upcoming := &PostgresSeason{
+		GuildID:   GuildIDInt,
+		Name:      "Next",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(24 * time.Hour),
+	}
+	mock.ExpectQuery("^INSERT INTO seasons (.+) RETURNING season_id;$").
+		WithArgs(GuildIDInt, "Next", upcoming.StartTime, upcoming.EndTime, false).
+		WillReturnRows(pgxmock.NewRows([]string{"season_id"}).AddRow(int64(5)))
+	_, err = addSeason(mock, upcoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSeason(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	start := time.Date(2024, 3, 31, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 30, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("^SELECT \\* FROM seasons WHERE guild_id = (.+) AND name = (.+);$").
+		WithArgs(GuildID, "Spring").
+		WillReturnRows(pgxmock.NewRows([]string{"season_id", "guild_id", "name", "start_time", "end_time", "summary_posted"}).
+			AddRow(int64(4), GuildIDInt, "Spring", start, end, false))
+	season, err := getSeason(mock, GuildID, "Spring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if season == nil || season.SeasonID != 4 {
+		t.Fatalf("expected the season, got %v", season)
+	}
+	statsRange := season.Range()
+	if statsRange.IsAllTime() || !statsRange.Start.Equal(start) || !statsRange.End.Equal(end) {
+		t.Errorf("expected the season's stats to be for its dates, got %v", statsRange)
+	}
+
+	mock.ExpectQuery("^SELECT \\* FROM seasons WHERE guild_id = (.+) AND name = (.+);$").
+		WithArgs(GuildID, "Summer").
+		WillReturnRows(pgxmock.NewRows([]string{"season_id", "guild_id", "name", "start_time", "end_time", "summary_posted"}))
+	season, err = getSeason(mock, GuildID, "Summer")
+	if err != nil || season != nil {
+		t.Errorf("expected no season and no error for an unknown name, got %v %v", season, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
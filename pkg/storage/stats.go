@@ -1,16 +1,10 @@
 package storage
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"github.com/automuteus/automuteus/v8/pkg/capture"
 	"github.com/automuteus/automuteus/v8/pkg/game"
-	"github.com/automuteus/automuteus/v8/pkg/settings"
-	"github.com/bwmarrin/discordgo"
 	"github.com/georgysavva/scany/pgxscan"
-	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"log"
 	"strconv"
 	"time"
@@ -19,234 +13,58 @@ import (
 var DiscussCode = fmt.Sprintf("%d", game.DISCUSS)
 var TasksCode = fmt.Sprintf("%d", game.TASKS)
 
-type SimpleEventType int
+// classicGames limits a query to games that weren't played in hide-and-seek, so the seeker's wins and kills don't skew
+// everybody's win rates. Hide-and-seek players show up under their own role names instead
+const classicGames = "game_mode <> '" + game.HideAndSeekMode + "'"
 
-const (
-	Tasks SimpleEventType = iota
-	Discuss
-	PlayerDeath
-	PlayerDisconnect
-)
+// StatsRange limits stats to the games that started in [Start, End), like a season. A nil end is open, so the zero
+// value is all time
+type StatsRange struct {
+	Start *time.Time
+	End   *time.Time
+}
+
+func NewStatsRange(start, end time.Time) StatsRange {
+	return StatsRange{Start: &start, End: &end}
+}
+
+func (statsRange StatsRange) IsAllTime() bool {
+	return statsRange.Start == nil && statsRange.End == nil
+}
 
-type SimpleEvent struct {
-	EventType       SimpleEventType
-	EventTimeOffset time.Duration
-	Data            string
-}
-
-type GameStatistics struct {
-	GameDuration time.Duration
-	WinType      game.GameResult
-
-	NumMeetings    int
-	NumDeaths      int
-	NumVotedOff    int
-	NumDisconnects int
-	Events         []SimpleEvent
-}
-
-func (stats *GameStatistics) ToString() string {
-	buf := bytes.NewBuffer([]byte{})
-	buf.WriteString(stats.FormatDurationAndWin())
-
-	for _, v := range stats.Events {
-		switch {
-		case v.EventType == Tasks:
-			buf.WriteString(fmt.Sprintf("%s into the game, Tasks phase resumed", v.EventTimeOffset.String()))
-		case v.EventType == Discuss:
-			buf.WriteString(fmt.Sprintf("%s into the game, Discussion was called", v.EventTimeOffset.String()))
-		case v.EventType == PlayerDeath:
-			player := game.Player{}
-			err := json.Unmarshal([]byte(v.Data), &player)
-			if err != nil {
-				log.Println(err)
-			} else {
-				buf.WriteString(fmt.Sprintf("%s into the game, %s died", v.EventTimeOffset.String(), player.Name))
-			}
-		}
-		buf.WriteRune('\n')
-	}
-
-	return buf.String()
-}
-
-// TODO localize
-func (stats *GameStatistics) FormatDurationAndWin() string {
-	buf := bytes.NewBuffer([]byte{})
-	winner := ""
-	switch stats.WinType {
-	case game.HumansByTask:
-		winner = "Crewmates won by completing tasks"
-	case game.HumansByVote:
-		winner = "Crewmates won by voting off the last Imposter"
-	case game.HumansDisconnect:
-		winner = "Crewmates won because the last Imposter disconnected"
-	case game.ImpostorDisconnect:
-		winner = "Imposters won because the last Human disconnected"
-	case game.ImpostorBySabotage:
-		winner = "Imposters won by sabotage"
-	case game.ImpostorByVote:
-		winner = "Imposters won by voting off the last Human"
-	case game.ImpostorByKill:
-		winner = "Imposters won by killing the last Human"
-	}
-	buf.WriteString("This display is VERY UNFINISHED and will be refined as time goes on!\n\n")
-
-	buf.WriteString(fmt.Sprintf("Game lasted %s and %s\n", stats.GameDuration.String(), winner))
-	buf.WriteString(fmt.Sprintf("There were %d meetings, %d deaths, and of those deaths, %d were from being voted off\n",
-		stats.NumMeetings, stats.NumDeaths, stats.NumVotedOff))
-	buf.WriteString("Game Events:\n")
-	return buf.String()
-}
-
-func (stats *GameStatistics) ToDiscordEmbed(combinedID string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
-	title := sett.LocalizeMessage(&i18n.Message{
-		ID:    "responses.matchStatsEmbed.Title",
-		Other: "Game `{{.MatchID}}`",
-	}, map[string]interface{}{
-		"MatchID": combinedID,
-	})
-
-	fields := make([]*discordgo.MessageEmbedField, 0)
-
-	fieldsOnLine := 0
-	// TODO collapse by meeting/tasks "blocks" of data
-	// TODO localize
-	for _, v := range stats.Events {
-		switch {
-		case v.EventType == Tasks:
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   v.EventTimeOffset.String(),
-				Value:  "🔨 Task Phase Begins",
-				Inline: true,
-			})
-			fieldsOnLine++
-		case v.EventType == Discuss:
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   v.EventTimeOffset.String(),
-				Value:  "💬 Discussion Begins",
-				Inline: true,
-			})
-			fieldsOnLine++
-		case v.EventType == PlayerDeath:
-			player := game.Player{}
-			err := json.Unmarshal([]byte(v.Data), &player)
-			if err != nil {
-				log.Println(err)
-			} else {
-				fields = append(fields, &discordgo.MessageEmbedField{
-					Name:   v.EventTimeOffset.String(),
-					Value:  fmt.Sprintf("☠️ \"%s\" Died", player.Name),
-					Inline: false,
-				})
-			}
-			fieldsOnLine = 0
-		}
-		if fieldsOnLine == 2 {
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   "\u200B",
-				Value:  "\u200B",
-				Inline: true,
-			})
-		}
-	}
-
-	msg := discordgo.MessageEmbed{
-		URL:         "",
-		Type:        "",
-		Title:       title,
-		Description: stats.FormatDurationAndWin(),
-		Timestamp:   "",
-		Color:       10181046, // PURPLE
-		Footer:      nil,
-		Image:       nil,
-		Thumbnail:   nil,
-		Video:       nil,
-		Provider:    nil,
-		Author:      nil,
-		Fields:      fields,
-	}
-	return &msg
-}
-
-func StatsFromGameAndEvents(pgame *PostgresGame, events []*PostgresGameEvent) GameStatistics {
-	stats := GameStatistics{
-		GameDuration: 0,
-		WinType:      game.Unknown,
-		NumMeetings:  0,
-		NumDeaths:    0,
-		Events:       []SimpleEvent{},
-	}
-
-	if pgame != nil {
-		stats.GameDuration = time.Second * time.Duration(pgame.EndTime-pgame.StartTime)
-		stats.WinType = game.GameResult(pgame.WinType)
-	}
-
-	if len(events) < 2 {
-		return stats
-	}
-
-	for _, v := range events {
-		if v.EventType == int16(capture.State) {
-			if v.Payload == DiscussCode {
-				stats.NumMeetings++
-				stats.Events = append(stats.Events, SimpleEvent{
-					EventType:       Discuss,
-					EventTimeOffset: time.Second * time.Duration(v.EventTime-pgame.StartTime),
-					Data:            "",
-				})
-			} else if v.Payload == TasksCode {
-				stats.Events = append(stats.Events, SimpleEvent{
-					EventType:       Tasks,
-					EventTimeOffset: time.Second * time.Duration(v.EventTime-pgame.StartTime),
-					Data:            "",
-				})
-			}
-		} else if v.EventType == int16(capture.Player) {
-			player := game.Player{}
-			err := json.Unmarshal([]byte(v.Payload), &player)
-			if err != nil {
-				log.Println(err)
-			} else {
-				switch {
-				case player.Action == game.DIED:
-					stats.NumDeaths++
-					stats.Events = append(stats.Events, SimpleEvent{
-						EventType:       PlayerDeath,
-						EventTimeOffset: time.Second * time.Duration(v.EventTime-pgame.StartTime),
-						Data:            v.Payload,
-					})
-				case player.Action == game.EXILED:
-					stats.NumVotedOff++
-				case player.Action == game.DISCONNECTED:
-					stats.NumDisconnects++
-				}
-			}
-		}
-	}
-
-	return stats
-}
-
-func (psqlInterface *PsqlInterface) NumGamesPlayedOnGuild(guildID string) int64 {
+// filter is a condition (starting with AND) that keeps the rows whose gameID column is a game in the range, with the
+// start and end as the parameters $n and $n+1. $guild is the query's parameter for the guild, so the games are looked
+// up by the (guild_id, start_time) index. Both are empty for all time, so all time queries are as they always were
+func (statsRange StatsRange) filter(gameID string, guild, n int) (string, []interface{}) {
+	if statsRange.IsAllTime() {
+		return "", nil
+	}
+	return fmt.Sprintf(" AND %[1]s IN (SELECT game_id FROM games WHERE guild_id = $%[4]d AND ($%[2]d::timestamptz IS NULL OR start_time >= $%[2]d) "+
+			"AND ($%[3]d::timestamptz IS NULL OR start_time < $%[3]d))", gameID, n, n+1, guild),
+		[]interface{}{statsRange.Start, statsRange.End}
+}
+
+func (psqlInterface *PsqlInterface) NumGamesPlayedOnGuild(guildID string, statsRange StatsRange) int64 {
 	gid, _ := strconv.ParseInt(guildID, 10, 64)
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND end_time != -1;", gid)
+	cond, args := statsRange.filter("game_id", 1, 2)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND end_time IS NOT NULL AND "+classicGames+cond+";", append([]interface{}{gid}, args...)...)
 	if err != nil {
 		return -1
 	}
 	return r
 }
 
-func (psqlInterface *PsqlInterface) NumGamesWonAsRoleOnServer(guildID string, role game.GameRole) int64 {
+func (psqlInterface *PsqlInterface) NumGamesWonAsRoleOnServer(guildID string, role game.GameRole, statsRange StatsRange) int64 {
 	gid, _ := strconv.ParseInt(guildID, 10, 64)
 	var r int64
 	var err error
+	cond, args := statsRange.filter("game_id", 1, 2)
+	args = append([]interface{}{gid}, args...)
 	if role == game.CrewmateRole {
-		err = pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND (win_type=0 OR win_type=1 OR win_type=6)", gid)
+		err = pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND (win_type=0 OR win_type=1 OR win_type=6) AND "+classicGames+cond, args...)
 	} else {
-		err = pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND (win_type=2 OR win_type=3 OR win_type=4 OR win_type=5)", gid)
+		err = pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM games WHERE guild_id=$1 AND (win_type=2 OR win_type=3 OR win_type=4 OR win_type=5) AND "+classicGames+cond, args...)
 	}
 	if err != nil {
 		log.Println(err)
@@ -257,7 +75,7 @@ func (psqlInterface *PsqlInterface) NumGamesWonAsRoleOnServer(guildID string, ro
 
 func (psqlInterface *PsqlInterface) NumGamesPlayedByUser(userID string) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1;", userID)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND "+classicGames+";", userID)
 	if err != nil {
 		return -1
 	}
@@ -273,19 +91,21 @@ func (psqlInterface *PsqlInterface) NumGuildsPlayedInByUser(userID string) int64
 	return r
 }
 
-func (psqlInterface *PsqlInterface) NumGamesPlayedByUserOnServer(userID, guildID string) int64 {
+func (psqlInterface *PsqlInterface) NumGamesPlayedByUserOnServer(userID, guildID string, statsRange StatsRange) int64 {
 	var r int64
 	gid, _ := strconv.ParseInt(guildID, 10, 64)
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2", userID, gid)
+	cond, args := statsRange.filter("game_id", 2, 3)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND "+classicGames+cond, append([]interface{}{userID, gid}, args...)...)
 	if err != nil {
 		return -1
 	}
 	return r
 }
 
-func (psqlInterface *PsqlInterface) NumWinsAsRoleOnServer(userID, guildID string, role int16) int64 {
+func (psqlInterface *PsqlInterface) NumWinsAsRoleOnServer(userID, guildID string, role int16, statsRange StatsRange) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_role=$3 AND player_won=true;", userID, guildID, role)
+	cond, args := statsRange.filter("game_id", 2, 4)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_role=$3 AND player_won=true AND "+classicGames+cond+";", append([]interface{}{userID, guildID, role}, args...)...)
 	if err != nil {
 		return -1
 	}
@@ -294,16 +114,17 @@ func (psqlInterface *PsqlInterface) NumWinsAsRoleOnServer(userID, guildID string
 
 func (psqlInterface *PsqlInterface) NumWinsAsRole(userID string, role int16) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_role=$2 AND player_won=true;", userID, role)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_role=$2 AND player_won=true AND "+classicGames+";", userID, role)
 	if err != nil {
 		return -1
 	}
 	return r
 }
 
-func (psqlInterface *PsqlInterface) NumGamesAsRoleOnServer(userID, guildID string, role int16) int64 {
+func (psqlInterface *PsqlInterface) NumGamesAsRoleOnServer(userID, guildID string, role int16, statsRange StatsRange) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_role=$3;", userID, guildID, role)
+	cond, args := statsRange.filter("game_id", 2, 4)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_role=$3 AND "+classicGames+cond+";", append([]interface{}{userID, guildID, role}, args...)...)
 	if err != nil {
 		return -1
 	}
@@ -312,16 +133,17 @@ func (psqlInterface *PsqlInterface) NumGamesAsRoleOnServer(userID, guildID strin
 
 func (psqlInterface *PsqlInterface) NumGamesAsRole(userID string, role int16) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_role=$2;", userID, role)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_role=$2 AND "+classicGames+";", userID, role)
 	if err != nil {
 		return -1
 	}
 	return r
 }
 
-func (psqlInterface *PsqlInterface) NumWinsOnServer(userID, guildID string) int64 {
+func (psqlInterface *PsqlInterface) NumWinsOnServer(userID, guildID string, statsRange StatsRange) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_won=true;", userID, guildID)
+	cond, args := statsRange.filter("game_id", 2, 3)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND guild_id=$2 AND player_won=true AND "+classicGames+cond+";", append([]interface{}{userID, guildID}, args...)...)
 	if err != nil {
 		return -1
 	}
@@ -330,7 +152,7 @@ func (psqlInterface *PsqlInterface) NumWinsOnServer(userID, guildID string) int6
 
 func (psqlInterface *PsqlInterface) NumWins(userID string) int64 {
 	var r int64
-	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_won=true;", userID)
+	err := pgxscan.Get(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) FROM users_games WHERE user_id=$1 AND player_won=true AND "+classicGames+";", userID)
 	if err != nil {
 		return -1
 	}
@@ -360,9 +182,10 @@ type StringModeCount struct {
 //		}
 //		return r
 //	}
-func (psqlInterface *PsqlInterface) ColorRankingForPlayerOnServer(userID, guildID string) []*Int16ModeCount {
+func (psqlInterface *PsqlInterface) ColorRankingForPlayerOnServer(userID, guildID string, statsRange StatsRange) []*Int16ModeCount {
 	r := []*Int16ModeCount{}
-	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY player_color) AS mode FROM users_games WHERE user_id=$1 AND guild_id=$2 GROUP BY player_color ORDER BY count desc;", userID, guildID)
+	cond, args := statsRange.filter("game_id", 2, 3)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY player_color) AS mode FROM users_games WHERE user_id=$1 AND guild_id=$2 AND "+classicGames+cond+" GROUP BY player_color ORDER BY count desc;", append([]interface{}{userID, guildID}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -380,9 +203,10 @@ func (psqlInterface *PsqlInterface) ColorRankingForPlayerOnServer(userID, guildI
 //	return r
 //}
 
-func (psqlInterface *PsqlInterface) NamesRankingForPlayerOnServer(userID, guildID string) []*StringModeCount {
+func (psqlInterface *PsqlInterface) NamesRankingForPlayerOnServer(userID, guildID string, statsRange StatsRange) []*StringModeCount {
 	var r []*StringModeCount
-	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY player_name) AS mode FROM users_games WHERE user_id=$1 AND guild_id=$2 GROUP BY player_name ORDER BY count desc;", userID, guildID)
+	cond, args := statsRange.filter("game_id", 2, 3)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY player_name) AS mode FROM users_games WHERE user_id=$1 AND guild_id=$2 AND "+classicGames+cond+" GROUP BY player_name ORDER BY count desc;", append([]interface{}{userID, guildID}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -390,9 +214,10 @@ func (psqlInterface *PsqlInterface) NamesRankingForPlayerOnServer(userID, guildI
 	return r
 }
 
-func (psqlInterface *PsqlInterface) TotalGamesRankingForServer(guildID uint64) []*Uint64ModeCount {
+func (psqlInterface *PsqlInterface) TotalGamesRankingForServer(guildID uint64, statsRange StatsRange) []*Uint64ModeCount {
 	var r []*Uint64ModeCount
-	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY user_id) AS mode FROM users_games WHERE guild_id=$1 GROUP BY user_id ORDER BY count desc;", guildID)
+	cond, args := statsRange.filter("game_id", 1, 2)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY user_id) AS mode FROM users_games WHERE guild_id=$1 AND "+classicGames+cond+" GROUP BY user_id ORDER BY count desc;", append([]interface{}{guildID}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -400,14 +225,16 @@ func (psqlInterface *PsqlInterface) TotalGamesRankingForServer(guildID uint64) [
 	return r
 }
 
-func (psqlInterface *PsqlInterface) OtherPlayersRankingForPlayerOnServer(userID, guildID string) []*PostgresOtherPlayerRanking {
+func (psqlInterface *PsqlInterface) OtherPlayersRankingForPlayerOnServer(userID, guildID string, statsRange StatsRange) []*PostgresOtherPlayerRanking {
 	var r []*PostgresOtherPlayerRanking
+	cond, args := statsRange.filter("game_id", 2, 3)
+	condA, _ := statsRange.filter("A.game_id", 2, 3)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT distinct B.user_id,"+
 		"count(*) over (partition by B.user_id),"+
-		"(count(*) over (partition by B.user_id)::decimal / (SELECT count(*) from users_games where user_id=$1 AND guild_id=$2))*100 as percent "+
+		"(count(*) over (partition by B.user_id)::decimal / (SELECT count(*) from users_games where user_id=$1 AND guild_id=$2 AND "+classicGames+cond+"))*100 as percent "+
 		"FROM users_games A INNER JOIN users_games B ON A.game_id = B.game_id AND A.user_id != B.user_id "+
-		"WHERE A.user_id=$1 AND A.guild_id=$2 "+
-		"ORDER BY percent desc", userID, guildID)
+		"WHERE A.user_id=$1 AND A.guild_id=$2 AND A."+classicGames+condA+" "+
+		"ORDER BY percent desc", append([]interface{}{userID, guildID}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -415,8 +242,9 @@ func (psqlInterface *PsqlInterface) OtherPlayersRankingForPlayerOnServer(userID,
 	return r
 }
 
-func (psqlInterface *PsqlInterface) TotalWinRankingForServerByRole(guildID uint64, role int16) []*PostgresPlayerRanking {
+func (psqlInterface *PsqlInterface) TotalWinRankingForServerByRole(guildID uint64, role int16, statsRange StatsRange) []*PostgresPlayerRanking {
 	var r []*PostgresPlayerRanking
+	cond, args := statsRange.filter("game_id", 1, 3)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT user_id,"+
 		"COUNT(user_id) FILTER ( WHERE player_won = TRUE ) AS win, "+
 		// "COUNT(user_id) FILTER ( WHERE player_won = FALSE ) AS loss," +
@@ -424,9 +252,9 @@ func (psqlInterface *PsqlInterface) TotalWinRankingForServerByRole(guildID uint6
 		"(COUNT(user_id) FILTER ( WHERE player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
 		// "(COUNT(user_id) FILTER ( WHERE player_won = FALSE )::decimal / COUNT(*)) * 100 AS loss_rate" +
 		"FROM users_games "+
-		"WHERE guild_id = $1 AND player_role = $2 "+
+		"WHERE guild_id = $1 AND player_role = $2 AND "+classicGames+cond+" "+
 		"GROUP BY user_id "+
-		"ORDER BY win_rate DESC", guildID, role)
+		"ORDER BY win_rate DESC", append([]interface{}{guildID, role}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -434,8 +262,9 @@ func (psqlInterface *PsqlInterface) TotalWinRankingForServerByRole(guildID uint6
 	return r
 }
 
-func (psqlInterface *PsqlInterface) TotalWinRankingForServer(guildID uint64) []*PostgresPlayerRanking {
+func (psqlInterface *PsqlInterface) TotalWinRankingForServer(guildID uint64, statsRange StatsRange) []*PostgresPlayerRanking {
 	var r []*PostgresPlayerRanking
+	cond, args := statsRange.filter("game_id", 1, 2)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT user_id,"+
 		"COUNT(user_id) FILTER ( WHERE player_won = TRUE ) AS win, "+
 		// "COUNT(user_id) FILTER ( WHERE player_won = FALSE ) AS loss," +
@@ -443,9 +272,58 @@ func (psqlInterface *PsqlInterface) TotalWinRankingForServer(guildID uint64) []*
 		"(COUNT(user_id) FILTER ( WHERE player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
 		// "(COUNT(user_id) FILTER ( WHERE player_won = FALSE )::decimal / COUNT(*)) * 100 AS loss_rate" +
 		"FROM users_games "+
-		"WHERE guild_id = $1 "+
+		"WHERE guild_id = $1 AND "+classicGames+cond+" "+
 		"GROUP BY user_id "+
-		"ORDER BY win_rate DESC", guildID)
+		"ORDER BY win_rate DESC", append([]interface{}{guildID}, args...)...)
+
+	if err != nil {
+		log.Println(err)
+	}
+	return r
+}
+
+func (psqlInterface *PsqlInterface) ColorRankingForServer(guildID string, statsRange StatsRange) []*Int16ModeCount {
+	r := []*Int16ModeCount{}
+	cond, args := statsRange.filter("game_id", 1, 2)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT count(*),mode() within GROUP (ORDER BY player_color) AS mode FROM users_games WHERE guild_id=$1 AND "+classicGames+cond+" GROUP BY player_color ORDER BY count desc;", append([]interface{}{guildID}, args...)...)
+
+	if err != nil {
+		log.Println(err)
+	}
+	return r
+}
+
+// WinsOverTimeForPlayerOnServer is the player's wins and games on each day they played, oldest first. Days are in UTC
+func (psqlInterface *PsqlInterface) WinsOverTimeForPlayerOnServer(userID, guildID string, statsRange StatsRange) []*PostgresWinsOnDay {
+	r := []*PostgresWinsOnDay{}
+	cond, args := statsRange.filter("users_games.game_id", 2, 3)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT date_trunc('day', games.start_time AT TIME ZONE 'UTC') AS day,"+
+		"COUNT(*) FILTER ( WHERE player_won = TRUE )::integer AS win, "+
+		"COUNT(*)::integer AS total "+
+		"FROM users_games INNER JOIN games ON users_games.game_id = games.game_id "+
+		"WHERE users_games.user_id=$1 AND users_games.guild_id=$2 AND users_games."+classicGames+cond+" "+
+		"GROUP BY day "+
+		"ORDER BY day", append([]interface{}{userID, guildID}, args...)...)
+
+	if err != nil {
+		log.Println(err)
+	}
+	return r
+}
+
+// CrewmateWinsOverTimeForServer is how many of the guild's finished games the crewmates won on each day, oldest first.
+// Days are in UTC
+func (psqlInterface *PsqlInterface) CrewmateWinsOverTimeForServer(guildID string, statsRange StatsRange) []*PostgresWinsOnDay {
+	r := []*PostgresWinsOnDay{}
+	gid, _ := strconv.ParseInt(guildID, 10, 64)
+	cond, args := statsRange.filter("game_id", 1, 2)
+	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT date_trunc('day', start_time AT TIME ZONE 'UTC') AS day,"+
+		fmt.Sprintf("COUNT(*) FILTER ( WHERE win_type IN (%d, %d, %d) )::integer AS win, ", game.HumansByVote, game.HumansByTask, game.HumansDisconnect)+
+		"COUNT(*)::integer AS total "+
+		"FROM games "+
+		fmt.Sprintf("WHERE guild_id=$1 AND end_time IS NOT NULL AND win_type <> %d AND ", game.Unknown)+classicGames+cond+" "+
+		"GROUP BY day "+
+		"ORDER BY day", append([]interface{}{gid}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -455,16 +333,30 @@ func (psqlInterface *PsqlInterface) TotalWinRankingForServer(guildID uint64) []*
 
 func (psqlInterface *PsqlInterface) DeleteAllGamesForServer(guildID string) error {
 	_, err := psqlInterface.Pool.Exec(context.Background(), "DELETE FROM games WHERE guild_id=$1", guildID)
+	if err != nil {
+		return err
+	}
+	// the rating history went with the games, so the ratings start over
+	_, err = psqlInterface.Pool.Exec(context.Background(), "DELETE FROM player_ratings WHERE guild_id=$1", guildID)
 	return err
 }
 
 func (psqlInterface *PsqlInterface) DeleteAllGamesForUser(userID string) error {
 	_, err := psqlInterface.Pool.Exec(context.Background(), "DELETE FROM users_games WHERE user_id=$1", userID)
+	if err != nil {
+		return err
+	}
+	_, err = psqlInterface.Pool.Exec(context.Background(), "DELETE FROM rating_history WHERE user_id=$1", userID)
+	if err != nil {
+		return err
+	}
+	_, err = psqlInterface.Pool.Exec(context.Background(), "DELETE FROM player_ratings WHERE user_id=$1", userID)
 	return err
 }
 
-func (psqlInterface *PsqlInterface) BestTeammateByRole(userID, guildID string, role int16, leaderboardMin int) []*PostgresBestTeammatePlayerRanking {
+func (psqlInterface *PsqlInterface) BestTeammateByRole(userID, guildID string, role int16, leaderboardMin int, statsRange StatsRange) []*PostgresBestTeammatePlayerRanking {
 	var r []*PostgresBestTeammatePlayerRanking
+	cond, args := statsRange.filter("users_games.game_id", 1, 5)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT users_games.user_id, "+
 		"uG.user_id as teammate_id,"+
 		"COUNT(users_games.player_won) as total, "+
@@ -472,10 +364,10 @@ func (psqlInterface *PsqlInterface) BestTeammateByRole(userID, guildID string, r
 		"(COUNT(users_games.user_id) FILTER ( WHERE users_games.player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
 		"FROM users_games "+
 		"INNER JOIN users_games uG ON users_games.game_id = uG.game_id AND users_games.user_id <> uG.user_id "+
-		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2 AND users_games.user_id = $3 "+
+		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2 AND users_games.user_id = $3 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, uG.user_id "+
 		"HAVING COUNT(users_games.player_won) >= $4 "+
-		"ORDER BY win_rate DESC, win DESC, total DESC", guildID, role, userID, leaderboardMin)
+		"ORDER BY win_rate DESC, win DESC, total DESC", append([]interface{}{guildID, role, userID, leaderboardMin}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -483,8 +375,9 @@ func (psqlInterface *PsqlInterface) BestTeammateByRole(userID, guildID string, r
 	return r
 }
 
-func (psqlInterface *PsqlInterface) WorstTeammateByRole(userID, guildID string, role int16, leaderboardMin int) []*PostgresWorstTeammatePlayerRanking {
+func (psqlInterface *PsqlInterface) WorstTeammateByRole(userID, guildID string, role int16, leaderboardMin int, statsRange StatsRange) []*PostgresWorstTeammatePlayerRanking {
 	var r []*PostgresWorstTeammatePlayerRanking
+	cond, args := statsRange.filter("users_games.game_id", 1, 5)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT users_games.user_id, "+
 		"uG.user_id as teammate_id,"+
 		"COUNT(users_games.player_won) as total, "+
@@ -492,10 +385,10 @@ func (psqlInterface *PsqlInterface) WorstTeammateByRole(userID, guildID string,
 		"(COUNT(users_games.user_id) FILTER ( WHERE users_games.player_won = FALSE )::decimal / COUNT(*)) * 100 AS loose_rate "+
 		"FROM users_games "+
 		"INNER JOIN users_games uG ON users_games.game_id = uG.game_id AND users_games.user_id <> uG.user_id "+
-		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2 AND users_games.user_id = $3 "+
+		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2 AND users_games.user_id = $3 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, uG.user_id "+
 		"HAVING COUNT(users_games.player_won) >= $4 "+
-		"ORDER BY loose_rate DESC, loose DESC, total DESC", guildID, role, userID, leaderboardMin)
+		"ORDER BY loose_rate DESC, loose DESC, total DESC", append([]interface{}{guildID, role, userID, leaderboardMin}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -503,8 +396,9 @@ func (psqlInterface *PsqlInterface) WorstTeammateByRole(userID, guildID string,
 	return r
 }
 
-func (psqlInterface *PsqlInterface) BestTeammateForServerByRole(guildID string, role int16, leaderboardMin int) []*PostgresBestTeammatePlayerRanking {
+func (psqlInterface *PsqlInterface) BestTeammateForServerByRole(guildID string, role int16, leaderboardMin int, statsRange StatsRange) []*PostgresBestTeammatePlayerRanking {
 	var r []*PostgresBestTeammatePlayerRanking
+	cond, args := statsRange.filter("users_games.game_id", 1, 4)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT "+
 		"CASE WHEN users_games.user_id > uG.user_id THEN users_games.user_id ELSE uG.user_id END, "+
 		"CASE WHEN users_games.user_id > uG.user_id THEN uG.user_id ELSE users_games.user_id END as teammate_id, "+
@@ -513,10 +407,10 @@ func (psqlInterface *PsqlInterface) BestTeammateForServerByRole(guildID string,
 		"(COUNT(users_games.user_id) FILTER ( WHERE users_games.player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
 		"FROM users_games "+
 		"INNER JOIN users_games uG ON users_games.game_id = uG.game_id AND users_games.user_id <> uG.user_id "+
-		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 and uG.player_role = $2"+
+		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 and uG.player_role = $2 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, uG.user_id "+
 		"HAVING COUNT(users_games.player_won) >= $3 "+
-		"ORDER BY win_rate DESC, win DESC, total DESC", guildID, role, leaderboardMin)
+		"ORDER BY win_rate DESC, win DESC, total DESC", append([]interface{}{guildID, role, leaderboardMin}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -524,8 +418,9 @@ func (psqlInterface *PsqlInterface) BestTeammateForServerByRole(guildID string,
 	return r
 }
 
-func (psqlInterface *PsqlInterface) WorstTeammateForServerByRole(guildID string, role int16, leaderboardMin int) []*PostgresWorstTeammatePlayerRanking {
+func (psqlInterface *PsqlInterface) WorstTeammateForServerByRole(guildID string, role int16, leaderboardMin int, statsRange StatsRange) []*PostgresWorstTeammatePlayerRanking {
 	var r []*PostgresWorstTeammatePlayerRanking
+	cond, args := statsRange.filter("users_games.game_id", 1, 4)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT DISTINCT "+
 		"CASE WHEN users_games.user_id > uG.user_id THEN users_games.user_id ELSE uG.user_id END, "+
 		"CASE WHEN users_games.user_id > uG.user_id THEN uG.user_id ELSE users_games.user_id END as teammate_id,"+
@@ -534,10 +429,10 @@ func (psqlInterface *PsqlInterface) WorstTeammateForServerByRole(guildID string,
 		"(COUNT(users_games.user_id) FILTER ( WHERE users_games.player_won = FALSE )::decimal / COUNT(*)) * 100 AS loose_rate "+
 		"FROM users_games "+
 		"INNER JOIN users_games uG ON users_games.game_id = uG.game_id AND users_games.user_id <> uG.user_id "+
-		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2"+
+		"WHERE users_games.guild_id = $1 AND users_games.player_role = $2 AND uG.player_role = $2 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, uG.user_id "+
 		"HAVING COUNT(users_games.player_won) >= $3 "+
-		"ORDER BY loose_rate DESC, loose DESC, total DESC", guildID, role, leaderboardMin)
+		"ORDER BY loose_rate DESC, loose DESC, total DESC", append([]interface{}{guildID, role, leaderboardMin}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -545,8 +440,9 @@ func (psqlInterface *PsqlInterface) WorstTeammateForServerByRole(guildID string,
 	return r
 }
 
-func (psqlInterface *PsqlInterface) UserWinByActionAndRole(userdID, guildID string, action string, role int16) []*PostgresUserActionRanking {
+func (psqlInterface *PsqlInterface) UserWinByActionAndRole(userdID, guildID string, action string, role int16, statsRange StatsRange) []*PostgresUserActionRanking {
 	var r []*PostgresUserActionRanking
+	cond, args := statsRange.filter("users_games.game_id", 3, 5)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT users_games.user_id, "+
 		"COUNT(ge.user_id) FILTER ( WHERE payload ->> 'Action' = $1 ) as total_action, "+
 		"total_user.total as total, "+
@@ -555,14 +451,14 @@ func (psqlInterface *PsqlInterface) UserWinByActionAndRole(userdID, guildID stri
 		"LEFT JOIN (SELECT user_id, guild_id, player_role, "+
 		"COUNT(users_games.player_won) as total, "+
 		"(COUNT(users_games.user_id) FILTER ( WHERE users_games.player_won = TRUE )::decimal / COUNT(*)) * 100 AS win_rate "+
-		"FROM users_games "+
+		"FROM users_games WHERE "+classicGames+cond+" "+
 		"GROUP BY user_id, player_role, guild_id "+
 		") total_user on total_user.user_id = users_games.user_id and users_games.player_role = total_user.player_role and users_games.guild_id = total_user.guild_id "+
 		"LEFT JOIN game_events ge ON users_games.game_id = ge.game_id AND ge.user_id = users_games.user_id "+
 		"WHERE users_games.user_id = $2 AND users_games.guild_id = $3 "+
-		"AND users_games.player_role = $4 "+
+		"AND users_games.player_role = $4 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, total, win_rate "+
-		"ORDER BY win_rate DESC, total DESC;", action, userdID, guildID, role)
+		"ORDER BY win_rate DESC, total DESC;", append([]interface{}{action, userdID, guildID, role}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -570,21 +466,22 @@ func (psqlInterface *PsqlInterface) UserWinByActionAndRole(userdID, guildID stri
 	return r
 }
 
-func (psqlInterface *PsqlInterface) UserFrequentFirstTarget(userID, guildID string, action string, leaderboardSize int) []*PostgresUserMostFrequentFirstTargetRanking {
+func (psqlInterface *PsqlInterface) UserFrequentFirstTarget(userID, guildID string, action string, leaderboardSize int, statsRange StatsRange) []*PostgresUserMostFrequentFirstTargetRanking {
 	var r []*PostgresUserMostFrequentFirstTargetRanking
+	cond, args := statsRange.filter("users_games.game_id", 2, 5)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) AS total_death, "+
 		"users_games.user_id, total, "+
 		"COUNT(*)::decimal / total * 100 AS death_rate "+
 		"FROM users_games "+
 		"LEFT JOIN LATERAL (SELECT game_events.user_id "+
 		"FROM game_events WHERE game_events.game_id = users_games.game_id AND payload ->> 'Action' = $1 "+
-		"ORDER BY event_time FETCH FIRST 1 ROW ONLY ) AS ge ON TRUE "+
+		"ORDER BY event_time, event_id FETCH FIRST 1 ROW ONLY ) AS ge ON TRUE "+
 		"LEFT JOIN LATERAL (SELECT count(*) AS total "+
-		"FROM users_games WHERE users_games.user_id = ge.user_id AND users_games.guild_id = $2 AND player_role = 0) AS TOTAL_GAME ON TRUE "+
-		"WHERE users_games.guild_id = $2 AND users_games.user_id = ge.user_id AND users_games.user_id = $3"+
+		"FROM users_games WHERE users_games.user_id = ge.user_id AND users_games.guild_id = $2 AND player_role = 0 AND "+classicGames+cond+") AS TOTAL_GAME ON TRUE "+
+		"WHERE users_games.guild_id = $2 AND users_games.user_id = ge.user_id AND users_games.user_id = $3 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, total  "+
 		"ORDER BY total_death DESC "+
-		"LIMIT $4;", action, guildID, userID, leaderboardSize)
+		"LIMIT $4;", append([]interface{}{action, guildID, userID, leaderboardSize}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -592,21 +489,22 @@ func (psqlInterface *PsqlInterface) UserFrequentFirstTarget(userID, guildID stri
 	return r
 }
 
-func (psqlInterface *PsqlInterface) UserMostFrequentFirstTargetForServer(guildID string, action string, leaderboardSize int) []*PostgresUserMostFrequentFirstTargetRanking {
+func (psqlInterface *PsqlInterface) UserMostFrequentFirstTargetForServer(guildID string, action string, leaderboardSize int, statsRange StatsRange) []*PostgresUserMostFrequentFirstTargetRanking {
 	var r []*PostgresUserMostFrequentFirstTargetRanking
+	cond, args := statsRange.filter("users_games.game_id", 2, 4)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT COUNT(*) AS total_death, "+
 		"users_games.user_id, total, "+
 		"COUNT(*)::decimal / total * 100 AS death_rate "+
 		"FROM users_games "+
 		"LEFT JOIN LATERAL (SELECT game_events.user_id "+
 		"FROM game_events WHERE game_events.game_id = users_games.game_id AND payload ->> 'Action' = $1 "+
-		"ORDER BY event_time FETCH FIRST 1 ROW ONLY ) AS ge ON TRUE "+
+		"ORDER BY event_time, event_id FETCH FIRST 1 ROW ONLY ) AS ge ON TRUE "+
 		"LEFT JOIN LATERAL (SELECT COUNT(*) AS total "+
-		"FROM users_games WHERE users_games.user_id = ge.user_id AND users_games.guild_id = $2 AND player_role = 0) AS TOTAL_GAME ON TRUE "+
-		"WHERE users_games.guild_id = $2 AND users_games.user_id = ge.user_id AND total > 3"+
+		"FROM users_games WHERE users_games.user_id = ge.user_id AND users_games.guild_id = $2 AND player_role = 0 AND "+classicGames+cond+") AS TOTAL_GAME ON TRUE "+
+		"WHERE users_games.guild_id = $2 AND users_games.user_id = ge.user_id AND total > 3 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, total  "+
 		"ORDER BY death_rate DESC, total_death DESC "+
-		"LIMIT $3;", action, guildID, leaderboardSize)
+		"LIMIT $3;", append([]interface{}{action, guildID, leaderboardSize}, args...)...)
 
 	if err != nil {
 		log.Println(err)
@@ -614,8 +512,9 @@ func (psqlInterface *PsqlInterface) UserMostFrequentFirstTargetForServer(guildID
 	return r
 }
 
-func (psqlInterface *PsqlInterface) UserMostFrequentKilledBy(userID, guildID string) []*PostgresUserMostFrequentKilledByanking {
+func (psqlInterface *PsqlInterface) UserMostFrequentKilledBy(userID, guildID string, statsRange StatsRange) []*PostgresUserMostFrequentKilledByanking {
 	var r []*PostgresUserMostFrequentKilledByanking
+	cond, args := statsRange.filter("users_games.game_id", 4, 6)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT users_games.user_id, "+
 		"usG.user_id as teammate_id, "+
 		"COUNT(ge.user_id) FILTER ( WHERE payload ->> 'Action' = $1 ) as total_death, "+
@@ -623,20 +522,21 @@ func (psqlInterface *PsqlInterface) UserMostFrequentKilledBy(userID, guildID str
 		"FROM users_games "+
 		"LEFT JOIN users_games usG on users_games.game_id = usG.game_id and usG.player_role = $2 "+
 		"LEFT JOIN (SELECT user_id, guild_id, player_role, COUNT(users_games.player_won) as total "+
-		"FROM users_games "+
+		"FROM users_games WHERE "+classicGames+cond+" "+
 		"GROUP BY user_id, player_role, guild_id) total_user on total_user.user_id = users_games.user_id and users_games.player_role = total_user.player_role and users_games.guild_id = total_user.guild_id "+
 		"LEFT JOIN game_events ge ON users_games.game_id = ge.game_id AND ge.user_id = $3 "+
-		"WHERE users_games.guild_id = $4 AND users_games.user_id = $3 AND users_games.player_role = $5 "+
+		"WHERE users_games.guild_id = $4 AND users_games.user_id = $3 AND users_games.player_role = $5 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, usG.user_id, users_games.user_id, total "+
-		"ORDER BY death_rate DESC, total_death DESC, encounter DESC;", strconv.Itoa(int(game.DIED)), strconv.Itoa(int(game.ImposterRole)), userID, guildID, strconv.Itoa(int(game.CrewmateRole)))
+		"ORDER BY death_rate DESC, total_death DESC, encounter DESC;", append([]interface{}{strconv.Itoa(int(game.DIED)), strconv.Itoa(int(game.ImposterRole)), userID, guildID, strconv.Itoa(int(game.CrewmateRole))}, args...)...)
 	if err != nil {
 		log.Println(err)
 	}
 	return r
 }
 
-func (psqlInterface *PsqlInterface) UserMostFrequentKilledByServer(guildID string) []*PostgresUserMostFrequentKilledByanking {
+func (psqlInterface *PsqlInterface) UserMostFrequentKilledByServer(guildID string, statsRange StatsRange) []*PostgresUserMostFrequentKilledByanking {
 	var r []*PostgresUserMostFrequentKilledByanking
+	cond, args := statsRange.filter("users_games.game_id", 3, 5)
 	err := pgxscan.Select(context.Background(), psqlInterface.Pool, &r, "SELECT users_games.user_id, "+
 		"usG.user_id as teammate_id, "+
 		"COUNT(ge.user_id) FILTER ( WHERE payload ->> 'Action' = $1 ) as total_death, "+
@@ -644,12 +544,12 @@ func (psqlInterface *PsqlInterface) UserMostFrequentKilledByServer(guildID strin
 		"FROM users_games "+
 		"INNER JOIN users_games usG on users_games.game_id = usG.game_id and usG.player_role = $2 "+
 		"INNER JOIN (SELECT user_id, guild_id, player_role, COUNT(users_games.player_won) as total "+
-		"FROM users_games "+
+		"FROM users_games WHERE "+classicGames+cond+" "+
 		"GROUP BY user_id, player_role, guild_id) total_user on total_user.user_id = users_games.user_id and users_games.player_role = total_user.player_role and users_games.guild_id = total_user.guild_id "+
 		"INNER JOIN game_events ge ON users_games.game_id = ge.game_id AND ge.user_id = users_games.user_id "+
-		"WHERE users_games.guild_id = $3 AND users_games.player_role = $4 "+
+		"WHERE users_games.guild_id = $3 AND users_games.player_role = $4 AND users_games."+classicGames+cond+" "+
 		"GROUP BY users_games.user_id, usG.user_id, users_games.user_id, total "+
-		"ORDER BY death_rate DESC, total_death DESC, encounter DESC;", strconv.Itoa(int(game.DIED)), strconv.Itoa(int(game.ImposterRole)), guildID, strconv.Itoa(int(game.CrewmateRole)))
+		"ORDER BY death_rate DESC, total_death DESC, encounter DESC;", append([]interface{}{strconv.Itoa(int(game.DIED)), strconv.Itoa(int(game.ImposterRole)), guildID, strconv.Itoa(int(game.CrewmateRole))}, args...)...)
 	if err != nil {
 		log.Println(err)
 	}
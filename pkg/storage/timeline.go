@@ -0,0 +1,258 @@
+package storage
+
+import (
+	"encoding/json"
+	"github.com/automuteus/automuteus/v8/pkg/capture"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Offset is how far into the game something happened. It's in seconds in JSON
+type Offset time.Duration
+
+func (o Offset) String() string {
+	return time.Duration(o).Round(time.Second).String()
+}
+
+func (o Offset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(o).Seconds())
+}
+
+func (o *Offset) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	err := json.Unmarshal(data, &seconds)
+	if err != nil {
+		return err
+	}
+	*o = Offset(seconds * float64(time.Second))
+	return nil
+}
+
+// Fate is how a player's game ended
+type Fate string
+
+const (
+	Survived     Fate = "survived"
+	Killed       Fate = "killed"
+	Ejected      Fate = "ejected"
+	Disconnected Fate = "disconnected"
+)
+
+// TimelineEvent is something that happened to a player
+type TimelineEvent struct {
+	Name  string `json:"name"`
+	Color int    `json:"color"`
+	At    Offset `json:"at"`
+}
+
+type TimelineMeeting struct {
+	Start Offset `json:"start"`
+	End   Offset `json:"end"`
+	// nil if nobody was ejected, or the capture didn't see it
+	Ejected *TimelineEvent `json:"ejected,omitempty"`
+}
+
+// TimelineRound is a task phase, and the meeting that ended it. The last round ends with the game, and has no meeting
+// unless the game ended during one
+type TimelineRound struct {
+	Number       int              `json:"number"`
+	Start        Offset           `json:"start"`
+	End          Offset           `json:"end"`
+	Killed       []TimelineEvent  `json:"killed"`
+	Disconnected []TimelineEvent  `json:"disconnected"`
+	Meeting      *TimelineMeeting `json:"meeting,omitempty"`
+}
+
+type TimelinePlayer struct {
+	Name  string `json:"name"`
+	Color int    `json:"color"`
+	// empty if the player wasn't linked; a string, as snowflakes don't fit in a javascript number
+	UserID string `json:"userID,omitempty"`
+	Fate   Fate   `json:"fate"`
+	// the round the player was killed, ejected or disconnected in; 0 if they survived
+	Round     int    `json:"round,omitempty"`
+	TimeAlive Offset `json:"timeAlive"`
+}
+
+// MatchTimeline is a game broken down into rounds, built from the events recorded while it was played
+type MatchTimeline struct {
+	GameID   int64            `json:"gameID"`
+	Duration Offset           `json:"duration"`
+	WinType  game.GameResult  `json:"winType"`
+	Rounds   []*TimelineRound `json:"rounds"`
+	// longest alive first
+	Players []*TimelinePlayer `json:"players"`
+}
+
+func (timeline *MatchTimeline) NumMeetings() int {
+	count := 0
+	for _, round := range timeline.Rounds {
+		if round.Meeting != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (timeline *MatchTimeline) NumWithFate(fate Fate) int {
+	count := 0
+	for _, player := range timeline.Players {
+		if player.Fate == fate {
+			count++
+		}
+	}
+	return count
+}
+
+// BuildMatchTimeline replays the game's events into rounds. players are the game's players as they were recorded when
+// it ended, so players nothing happened to are on the timeline too; it can be nil
+func BuildMatchTimeline(pgame *PostgresGame, events []*PostgresGameEvent, players []*PostgresUserGame) *MatchTimeline {
+	timeline := &MatchTimeline{
+		GameID:  pgame.GameID,
+		WinType: game.GameResult(pgame.WinType),
+		Rounds:  []*TimelineRound{},
+		Players: []*TimelinePlayer{},
+	}
+	if pgame.EndTime != nil {
+		timeline.Duration = Offset(pgame.EndTime.Sub(pgame.StartTime))
+	} else if len(events) > 0 {
+		timeline.Duration = Offset(events[len(events)-1].EventTime.Sub(pgame.StartTime))
+	}
+
+	byName := map[string]*TimelinePlayer{}
+	playerFor := func(name string, color int, userID *uint64) *TimelinePlayer {
+		player, ok := byName[name]
+		if !ok {
+			player = &TimelinePlayer{Name: name, Color: color, Fate: Survived}
+			byName[name] = player
+			timeline.Players = append(timeline.Players, player)
+		}
+		if player.UserID == "" && userID != nil {
+			player.UserID = strconv.FormatUint(*userID, 10)
+		}
+		return player
+	}
+	for _, p := range players {
+		userID := p.UserID
+		playerFor(p.PlayerName, int(p.PlayerColor), &userID)
+	}
+
+	round := &TimelineRound{Number: 1, Killed: []TimelineEvent{}, Disconnected: []TimelineEvent{}}
+	// the meeting an ejection is for; the capture can see it after the next round has started
+	var lastMeeting *TimelineMeeting
+	lastMeetingRound := 0
+	inMeeting := false
+
+events:
+	for _, v := range events {
+		at := Offset(v.EventTime.Sub(pgame.StartTime))
+		// from the lobby, before the game started
+		if at < 0 {
+			continue
+		}
+		switch v.EventType {
+		case int16(capture.State):
+			switch v.Payload {
+			case DiscussCode:
+				if inMeeting {
+					continue
+				}
+				inMeeting = true
+				round.End = at
+				round.Meeting = &TimelineMeeting{Start: at}
+				lastMeeting, lastMeetingRound = round.Meeting, round.Number
+			case TasksCode:
+				if !inMeeting {
+					continue
+				}
+				inMeeting = false
+				round.Meeting.End = at
+				timeline.Rounds = append(timeline.Rounds, round)
+				round = &TimelineRound{Number: round.Number + 1, Start: at, Killed: []TimelineEvent{}, Disconnected: []TimelineEvent{}}
+			default:
+				// back in the lobby or menu, so the game is over
+				break events
+			}
+		case int16(capture.GameOver):
+			break events
+		case int16(capture.Player):
+			p := game.Player{}
+			err := json.Unmarshal([]byte(v.Payload), &p)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			switch p.Action {
+			case game.DIED:
+				player := playerFor(p.Name, p.Color, v.UserID)
+				if player.Fate != Survived {
+					continue
+				}
+				player.Fate, player.Round, player.TimeAlive = Killed, round.Number, at
+				round.Killed = append(round.Killed, TimelineEvent{Name: p.Name, Color: p.Color, At: at})
+			case game.EXILED:
+				player := playerFor(p.Name, p.Color, v.UserID)
+				if lastMeeting == nil || lastMeeting.Ejected != nil || player.Fate == Ejected || player.Fate == Disconnected {
+					continue
+				}
+				if player.Fate == Killed {
+					// some captures also report the ejected player as having died, since the meeting was called
+					if player.TimeAlive < lastMeeting.Start {
+						continue
+					}
+					round.Killed = withoutPlayer(round.Killed, p.Name)
+					timeline.Rounds = removeKill(timeline.Rounds, player.Round, p.Name)
+				}
+				player.Fate, player.Round, player.TimeAlive = Ejected, lastMeetingRound, at
+				lastMeeting.Ejected = &TimelineEvent{Name: p.Name, Color: p.Color, At: at}
+			case game.DISCONNECTED:
+				player := playerFor(p.Name, p.Color, v.UserID)
+				if player.Fate != Survived {
+					continue
+				}
+				player.Fate, player.Round, player.TimeAlive = Disconnected, round.Number, at
+				round.Disconnected = append(round.Disconnected, TimelineEvent{Name: p.Name, Color: p.Color, At: at})
+			}
+		}
+	}
+
+	if inMeeting {
+		round.Meeting.End = timeline.Duration
+	} else {
+		round.End = timeline.Duration
+	}
+	timeline.Rounds = append(timeline.Rounds, round)
+
+	for _, player := range timeline.Players {
+		if player.Fate == Survived {
+			player.TimeAlive = timeline.Duration
+		}
+	}
+	sort.SliceStable(timeline.Players, func(i, j int) bool {
+		return timeline.Players[i].TimeAlive > timeline.Players[j].TimeAlive
+	})
+	return timeline
+}
+
+func withoutPlayer(events []TimelineEvent, name string) []TimelineEvent {
+	kept := []TimelineEvent{}
+	for _, e := range events {
+		if e.Name != name {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// removeKill takes a kill off a round that's already over
+func removeKill(rounds []*TimelineRound, number int, name string) []*TimelineRound {
+	for _, round := range rounds {
+		if round.Number == number {
+			round.Killed = withoutPlayer(round.Killed, name)
+		}
+	}
+	return rounds
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"encoding/json"
+	"github.com/automuteus/automuteus/v8/pkg/game"
+	"github.com/automuteus/automuteus/v8/pkg/task"
+	"testing"
+	"time"
+)
+
+func timelineEvent(t int64, jobType task.JobType, payload string) *PostgresGameEvent {
+	return &PostgresGameEvent{
+		EventTime: time.Unix(t, 0),
+		EventType: int16(jobType),
+		Payload:   payload,
+	}
+}
+
+func TestBuildMatchTimeline(t *testing.T) {
+	end := time.Unix(400, 0)
+	pgame := &PostgresGame{GameID: 9, StartTime: time.Unix(100, 0), EndTime: &end, WinType: int16(game.HumansByVote)}
+	events := []*PostgresGameEvent{
+		// from the lobby, before the game started
+		timelineEvent(90, task.StateJob, "0"),
+		timelineEvent(100, task.StateJob, "1"),
+		timelineEvent(130, task.PlayerJob, `{"Action":2,"Name":"bob","Color":1,"IsDead":true}`),
+		timelineEvent(150, task.StateJob, "2"),
+		timelineEvent(200, task.StateJob, "1"),
+		// the ejection is seen after the next round started, and reported as a death too
+		timelineEvent(201, task.PlayerJob, `{"Action":2,"Name":"carol","Color":2,"IsDead":true}`),
+		timelineEvent(201, task.PlayerJob, `{"Action":6,"Name":"carol","Color":2,"IsDead":true}`),
+		timelineEvent(250, task.PlayerJob, `{"Action":5,"Name":"dave","Color":3,"Disconnected":true}`),
+		timelineEvent(300, task.StateJob, "2"),
+		timelineEvent(400, task.GameOverJob, `{"GameOverReason":0}`),
+		timelineEvent(401, task.StateJob, "0"),
+	}
+	players := []*PostgresUserGame{
+		{UserID: UserIDInt, PlayerName: "alice", PlayerColor: 0},
+		{UserID: 456, PlayerName: "bob", PlayerColor: 1},
+	}
+
+	timeline := BuildMatchTimeline(pgame, events, players)
+	if timeline.Duration != Offset(300*time.Second) || timeline.WinType != game.HumansByVote {
+		t.Errorf("expected a 5m game won by vote, got %s %d", timeline.Duration, timeline.WinType)
+	}
+	if len(timeline.Rounds) != 2 || timeline.NumMeetings() != 2 {
+		t.Fatalf("expected 2 rounds that both ended in a meeting, got %d rounds and %d meetings", len(timeline.Rounds), timeline.NumMeetings())
+	}
+
+	first := timeline.Rounds[0]
+	if first.Start != 0 || first.End != Offset(50*time.Second) || len(first.Killed) != 1 || first.Killed[0].Name != "bob" {
+		t.Errorf("expected bob to be killed in the first round, got %+v", first)
+	}
+	if first.Meeting.End != Offset(100*time.Second) || first.Meeting.Ejected == nil || first.Meeting.Ejected.Name != "carol" {
+		t.Errorf("expected carol to be ejected in the first meeting, got %+v", first.Meeting)
+	}
+
+	second := timeline.Rounds[1]
+	if len(second.Killed) != 0 || len(second.Disconnected) != 1 || second.Disconnected[0].Name != "dave" {
+		t.Errorf("expected dave to disconnect in the second round, and nobody to be killed, got %+v", second)
+	}
+	// the game ended during the meeting
+	if second.End != Offset(200*time.Second) || second.Meeting == nil || second.Meeting.End != timeline.Duration {
+		t.Errorf("expected the last meeting to end with the game, got %+v", second.Meeting)
+	}
+
+	expected := []TimelinePlayer{
+		{Name: "alice", Color: 0, UserID: UserID, Fate: Survived, TimeAlive: Offset(300 * time.Second)},
+		{Name: "dave", Color: 3, Fate: Disconnected, Round: 2, TimeAlive: Offset(150 * time.Second)},
+		{Name: "carol", Color: 2, Fate: Ejected, Round: 1, TimeAlive: Offset(101 * time.Second)},
+		{Name: "bob", Color: 1, UserID: "456", Fate: Killed, Round: 1, TimeAlive: Offset(30 * time.Second)},
+	}
+	if len(timeline.Players) != len(expected) {
+		t.Fatalf("expected %d players, got %d", len(expected), len(timeline.Players))
+	}
+	for i, player := range timeline.Players {
+		if *player != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], *player)
+		}
+	}
+	if timeline.NumWithFate(Killed) != 1 || timeline.NumWithFate(Ejected) != 1 {
+		t.Error("expected one kill and one ejection")
+	}
+}
+
+func TestMatchTimelineJSON(t *testing.T) {
+	end := time.Unix(190, 0)
+	pgame := &PostgresGame{GameID: 9, StartTime: time.Unix(100, 0), EndTime: &end, WinType: int16(game.ImpostorByKill)}
+	timeline := BuildMatchTimeline(pgame, []*PostgresGameEvent{
+		timelineEvent(145, task.PlayerJob, `{"Action":2,"Name":"bob","Color":1,"IsDead":true}`),
+	}, nil)
+
+	data, err := json.Marshal(timeline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"gameID":9,"duration":90,"winType":3,"rounds":[{"number":1,"start":0,"end":90,` +
+		`"killed":[{"name":"bob","color":1,"at":45}],"disconnected":[]}],` +
+		`"players":[{"name":"bob","color":1,"fate":"killed","round":1,"timeAlive":45}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+
+	var decoded MatchTimeline
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Duration != timeline.Duration || decoded.Rounds[0].Killed[0].At != Offset(45*time.Second) {
+		t.Errorf("expected the timeline to survive a round trip, got %+v", decoded)
+	}
+}
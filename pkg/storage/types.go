@@ -3,6 +3,7 @@ package storage
 import (
 	"bytes"
 	"fmt"
+	"time"
 )
 
 type PostgresGuild struct {
@@ -28,21 +29,32 @@ func (g *PostgresGuild) ToCSV() string {
 		nilToEmpty(g.TxTimeUnix), nilToEmpty(g.TransferredTo), nilToEmpty(g.InheritsFrom))
 }
 
+// CSVTimeFormat is ISO-8601, down to the millisecond like game_events.event_time
+const CSVTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+func timeToCSV(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(CSVTimeFormat)
+}
+
 type PostgresGame struct {
-	GameID      int64  `db:"game_id"`
-	GuildID     uint64 `db:"guild_id"`
-	ConnectCode string `db:"connect_code"`
-	StartTime   int32  `db:"start_time"`
-	WinType     int16  `db:"win_type"`
-	EndTime     int32  `db:"end_time"`
+	GameID      int64      `db:"game_id"`
+	GuildID     uint64     `db:"guild_id"`
+	ConnectCode string     `db:"connect_code"`
+	StartTime   time.Time  `db:"start_time"`
+	WinType     int16      `db:"win_type"`
+	EndTime     *time.Time `db:"end_time"` // nil until the game is over
+	GameMode    string     `db:"game_mode"`
 }
 
 func GamesToCSV(g []*PostgresGame) string {
-	s := bytes.NewBufferString("game_id,guild_id,connect_code,start_time,win_type,end_time,\n")
+	s := bytes.NewBufferString("game_id,guild_id,connect_code,start_time,win_type,end_time,game_mode,\n")
 	for _, v := range g {
 		if v != nil {
-			s.WriteString(fmt.Sprintf("%d,%d,%s,%d,%d,%d,\n",
-				v.GameID, v.GuildID, v.ConnectCode, v.StartTime, v.WinType, v.EndTime))
+			s.WriteString(fmt.Sprintf("%d,%d,%s,%s,%d,%s,%s,\n",
+				v.GameID, v.GuildID, v.ConnectCode, timeToCSV(&v.StartTime), v.WinType, timeToCSV(v.EndTime), v.GameMode))
 		}
 	}
 	return s.String()
@@ -72,39 +84,66 @@ type PostgresUserGame struct {
 	PlayerColor int16  `db:"player_color"`
 	PlayerRole  int16  `db:"player_role"`
 	PlayerWon   bool   `db:"player_won"`
+	// the role's name from the game mode, e.g. "Sheriff"; PlayerRole is the team it plays for
+	PlayerRoleName string `db:"player_role_name"`
+	// copied from the game, so player stats can leave out hide-and-seek games without a join
+	GameMode string `db:"game_mode"`
 }
 
 func UsersGamesToCSV(ug []*PostgresUserGame) string {
-	s := bytes.NewBufferString("user_id,guild_id,game_id,player_name,player_color,player_role,player_won,\n")
+	s := bytes.NewBufferString("user_id,guild_id,game_id,player_name,player_color,player_role,player_won,player_role_name,game_mode,\n")
 	for _, v := range ug {
 		if v != nil {
-			s.WriteString(fmt.Sprintf("%d,%d,%d,%s,%d,%d,%t,\n",
-				v.UserID, v.GuildID, v.GameID, v.PlayerName, v.PlayerColor, v.PlayerRole, v.PlayerWon))
+			s.WriteString(fmt.Sprintf("%d,%d,%d,%s,%d,%d,%t,%s,%s,\n",
+				v.UserID, v.GuildID, v.GameID, v.PlayerName, v.PlayerColor, v.PlayerRole, v.PlayerWon, v.PlayerRoleName, v.GameMode))
 		}
 	}
 	return s.String()
 }
 
 type PostgresGameEvent struct {
-	EventID   uint64  `db:"event_id"`
-	UserID    *uint64 `db:"user_id"`
-	GameID    int64   `db:"game_id"`
-	EventTime int32   `db:"event_time"`
-	EventType int16   `db:"event_type"`
-	Payload   string  `db:"payload"`
+	EventID   uint64    `db:"event_id"`
+	UserID    *uint64   `db:"user_id"`
+	GameID    int64     `db:"game_id"`
+	EventTime time.Time `db:"event_time"`
+	EventType int16     `db:"event_type"`
+	Payload   string    `db:"payload"`
 }
 
 func EventsToCSV(e []*PostgresGameEvent) string {
 	s := bytes.NewBufferString("event_id,user_id,game_id,event_time,event_type,payload,\n")
 	for _, v := range e {
 		if v != nil {
-			s.WriteString(fmt.Sprintf("%d,%s,%d,%d,%d,%s,\n",
-				v.EventID, nilToEmpty(v.UserID), v.GameID, v.EventTime, v.EventType, v.Payload))
+			s.WriteString(fmt.Sprintf("%d,%s,%d,%s,%d,%s,\n",
+				v.EventID, nilToEmpty(v.UserID), v.GameID, timeToCSV(&v.EventTime), v.EventType, v.Payload))
 		}
 	}
 	return s.String()
 }
 
+type PostgresPlayerAlias struct {
+	GuildID        uint64    `db:"guild_id"`
+	UserID         uint64    `db:"user_id"`
+	PlayerName     string    `db:"player_name"`
+	PlayerColor    *int16    `db:"player_color"`
+	TimesConfirmed int32     `db:"times_confirmed"`
+	LastSeen       time.Time `db:"last_seen"`
+}
+
+type PostgresScheduledSession struct {
+	SessionID         int64     `db:"session_id"`
+	GuildID           uint64    `db:"guild_id"`
+	TextChannelID     uint64    `db:"text_channel_id"`
+	VoiceChannelID    uint64    `db:"voice_channel_id"`
+	HostRoleID        *uint64   `db:"host_role_id"`
+	CreatedBy         uint64    `db:"created_by"`
+	Title             string    `db:"title"`
+	StartTime         time.Time `db:"start_time"`
+	Timezone          string    `db:"timezone"`
+	Recurrence        string    `db:"recurrence"`
+	ReminderMessageID *uint64   `db:"reminder_message_id"`
+}
+
 type PostgresOtherPlayerRanking struct {
 	UserID  uint64  `db:"user_id"`
 	Count   int64   `db:"count"`
@@ -118,6 +157,21 @@ type PostgresPlayerRanking struct {
 	WinRate  float64 `db:"win_rate"`
 }
 
+// PostgresWinsOnDay is the games won and played on a day, for charting win rates over time
+type PostgresWinsOnDay struct {
+	Day      time.Time `db:"day"`
+	WinCount int32     `db:"win"`
+	Count    int32     `db:"total"`
+}
+
+type PostgresRoleRanking struct {
+	RoleName   string  `db:"role_name"`
+	PlayerRole int16   `db:"player_role"`
+	WinCount   int64   `db:"win"`
+	Count      int64   `db:"total"`
+	WinRate    float64 `db:"win_rate"`
+}
+
 type PostgresBestTeammatePlayerRanking struct {
 	UserID     uint64  `db:"user_id"`
 	TeammateID uint64  `db:"teammate_id"`
@@ -155,3 +209,34 @@ type PostgresUserMostFrequentKilledByanking struct {
 	Encounter  int64   `db:"encounter"`
 	DeathRate  float64 `db:"death_rate"`
 }
+
+type PostgresPlayerRating struct {
+	GuildID    uint64  `db:"guild_id"`
+	UserID     uint64  `db:"user_id"`
+	PlayerRole int16   `db:"player_role"`
+	Rating     float64 `db:"rating"`
+	Games      int32   `db:"games"`
+	// how much the last rated game moved it
+	LastChange float64 `db:"last_change"`
+}
+
+type PostgresPlayerRoleRecord struct {
+	UserID     uint64 `db:"user_id"`
+	PlayerRole int16  `db:"player_role"`
+	WinCount   int64  `db:"win"`
+	Count      int64  `db:"total"`
+}
+
+// PostgresSeason is a window of time a guild views its stats for. EndTime is exclusive
+type PostgresSeason struct {
+	SeasonID      int64     `db:"season_id"`
+	GuildID       uint64    `db:"guild_id"`
+	Name          string    `db:"name"`
+	StartTime     time.Time `db:"start_time"`
+	EndTime       time.Time `db:"end_time"`
+	SummaryPosted bool      `db:"summary_posted"`
+}
+
+func (season *PostgresSeason) Range() StatsRange {
+	return NewStatsRange(season.StartTime, season.EndTime)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/automuteus/automuteus/v8/pkg/premium"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPostgresGuild_ToCSV(t *testing.T) {
@@ -36,17 +37,24 @@ func TestGamesToCSV(t *testing.T) {
 		t.Error("Expected only 1 line of CSV when provided with nil game ptrs")
 	}
 
+	end := time.Date(2038, 1, 19, 12, 0, 0, 0, time.FixedZone("JST", 9*60*60))
 	games[0] = &PostgresGame{
 		GameID:      0,
 		GuildID:     1,
 		ConnectCode: "a",
-		StartTime:   2,
+		StartTime:   time.Unix(2, 0),
 		WinType:     3,
-		EndTime:     4,
+		EndTime:     &end,
+		GameMode:    "classic",
 	}
-	if strings.Split(GamesToCSV(games), "\n")[1] != "0,1,a,2,3,4," {
+	if strings.Split(GamesToCSV(games), "\n")[1] != "0,1,a,1970-01-01T00:00:02.000Z,3,2038-01-19T03:00:00.000Z,classic," {
 		t.Error("Games to CSV didn't match expected value")
 	}
+
+	games[0].EndTime = nil
+	if strings.Split(GamesToCSV(games), "\n")[1] != "0,1,a,1970-01-01T00:00:02.000Z,3,,classic," {
+		t.Error("Games to CSV didn't leave the end time of an unfinished game empty")
+	}
 }
 
 func TestEventsToCSV(t *testing.T) {
@@ -63,11 +71,11 @@ func TestEventsToCSV(t *testing.T) {
 		EventID:   0,
 		UserID:    nil,
 		GameID:    1,
-		EventTime: 2,
+		EventTime: time.Unix(2, int64(345*time.Millisecond)),
 		EventType: 3,
 		Payload:   "some_payload",
 	}
-	if strings.Split(EventsToCSV(events), "\n")[1] != "0,,1,2,3,some_payload," {
+	if strings.Split(EventsToCSV(events), "\n")[1] != "0,,1,1970-01-01T00:00:02.345Z,3,some_payload," {
 		t.Error("Events to CSV didn't match expected value")
 	}
 }
@@ -103,16 +111,18 @@ func TestUsersGamesToCSV(t *testing.T) {
 	}
 
 	usersGames[0] = &PostgresUserGame{
-		UserID:      0,
-		GuildID:     1,
-		GameID:      2,
-		PlayerName:  "tom",
-		PlayerColor: 3,
-		PlayerRole:  4,
-		PlayerWon:   true,
+		UserID:         0,
+		GuildID:        1,
+		GameID:         2,
+		PlayerName:     "tom",
+		PlayerColor:    3,
+		PlayerRole:     4,
+		PlayerWon:      true,
+		PlayerRoleName: "Sheriff",
+		GameMode:       "town-of-us",
 	}
 
-	if strings.Split(UsersGamesToCSV(usersGames), "\n")[1] != "0,1,2,tom,3,4,true," {
+	if strings.Split(UsersGamesToCSV(usersGames), "\n")[1] != "0,1,2,tom,3,4,true,Sheriff,town-of-us," {
 		t.Error("Users game to csv does not match expected value")
 	}
 }
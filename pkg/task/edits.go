@@ -0,0 +1,179 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-redis/redis/v8"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ChannelEditLimit is how many message edits Discord allows per channel in every ChannelEditWindow
+const ChannelEditLimit = 5
+const ChannelEditWindow = 5 * time.Second
+
+// MaxEditAttempts is how many times an edit is sent before it's dropped, when Discord keeps failing to take it
+const MaxEditAttempts = 3
+
+// edits are normally claimed within seconds. The ones that never are, like edits for messages deleted without
+// CancelEdit, expire after this instead of piling up in Redis; a game state message that went unedited this long
+// has been replaced with a new one anyway
+const pendingEditTTL = time.Hour
+
+// PendingEdit is the latest embed waiting to be sent for a game state message
+type PendingEdit struct {
+	ChannelID string                  `json:"channelID"`
+	MessageID string                  `json:"messageID"`
+	Embed     *discordgo.MessageEmbed `json:"embed"`
+	// how many times sending it has failed
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// takes every edit due by ARGV[1] (at most ARGV[2] of them) off the schedule, so only one node ever sends each edit
+var claimEditsScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local edits = {}
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+	local key = ARGV[3] .. id
+	local edit = redis.call('GET', key)
+	if edit then
+		redis.call('DEL', key)
+		table.insert(edits, edit)
+	end
+end
+return edits
+`)
+
+// counts an edit against the channel's rate limit window; returns how long until the window resets if it's full
+var channelEditSlotScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+if n > tonumber(ARGV[2]) then
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl < 0 then
+		return tonumber(ARGV[1])
+	end
+	return ttl
+end
+return 0
+`)
+
+// ScheduleEdit stores the embed as the latest one for the message, and schedules it to be sent after delay if it
+// isn't scheduled already. Edits made before then are coalesced into one, no matter which node made them.
+// Returns true if this is a new edit, and not just newer contents for one that was already scheduled
+func ScheduleEdit(ctx context.Context, client *redis.Client, edit PendingEdit, delay time.Duration) (bool, error) {
+	payload, err := json.Marshal(edit)
+	if err != nil {
+		return false, err
+	}
+	var added *redis.IntCmd
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, rediskey.PendingEdit(edit.MessageID), payload, pendingEditTTL)
+		added = pipe.ZAddNX(ctx, rediskey.PendingEditsZSet, &redis.Z{
+			Score:  float64(time.Now().Add(delay).UnixMilli()),
+			Member: edit.MessageID,
+		})
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return added.Val() == 1, nil
+}
+
+// RescheduleEdit puts a claimed edit back on the schedule, unless a newer edit for the message was scheduled since
+func RescheduleEdit(ctx context.Context, client *redis.Client, edit PendingEdit, at time.Time) error {
+	payload, err := json.Marshal(edit)
+	if err != nil {
+		return err
+	}
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, rediskey.PendingEdit(edit.MessageID), payload, pendingEditTTL)
+		pipe.ZAddNX(ctx, rediskey.PendingEditsZSet, &redis.Z{
+			Score:  float64(at.UnixMilli()),
+			Member: edit.MessageID,
+		})
+		return nil
+	})
+	return err
+}
+
+// RetryEdit puts an edit that Discord failed to take back on the schedule, if the failure was transient and the edit
+// has attempts left. Returns true if it was rescheduled
+func RetryEdit(ctx context.Context, client *redis.Client, edit PendingEdit, sendErr error, at time.Time) (bool, error) {
+	if !transientEditError(sendErr) || edit.Attempts+1 >= MaxEditAttempts {
+		return false, nil
+	}
+	edit.Attempts++
+	return true, RescheduleEdit(ctx, client, edit, at)
+}
+
+// transientEditError is whether sending the edit again might work. Discord rejecting it, say because the message was
+// deleted or the bot lost access to the channel, won't change on a retry
+func transientEditError(err error) bool {
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		code := restErr.Response.StatusCode
+		return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+	}
+	// no response at all, like a timeout
+	return true
+}
+
+// CancelEdit drops any pending edit for the message, for messages that are about to be deleted
+func CancelEdit(ctx context.Context, client *redis.Client, messageID string) error {
+	_, err := client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, rediskey.PendingEditsZSet, messageID)
+		pipe.Del(ctx, rediskey.PendingEdit(messageID))
+		return nil
+	})
+	return err
+}
+
+// ClaimDueEdits takes up to count edits that are due by now off the schedule. Claimed edits belong to the caller; it
+// has to send them, or put them back with RescheduleEdit or RetryEdit. Edits claimed by a node that dies before
+// sending them are lost, but every edit carries the whole embed, so the message catches up on the next one
+func ClaimDueEdits(ctx context.Context, client *redis.Client, now time.Time, count int) ([]PendingEdit, error) {
+	res, err := claimEditsScript.Run(ctx, client, []string{rediskey.PendingEditsZSet},
+		strconv.FormatInt(now.UnixMilli(), 10), count, rediskey.PendingEditNamespace).Result()
+	if err != nil {
+		return nil, err
+	}
+	payloads, _ := res.([]interface{})
+	edits := make([]PendingEdit, 0, len(payloads))
+	for _, payload := range payloads {
+		str, ok := payload.(string)
+		if !ok {
+			continue
+		}
+		var edit PendingEdit
+		err = json.Unmarshal([]byte(str), &edit)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		edits = append(edits, edit)
+	}
+	return edits, nil
+}
+
+// TakeChannelEditSlot counts an edit against the channel's rate limit, shared by every node. Returns 0 if the edit can
+// be sent now, otherwise how long to wait before trying again
+func TakeChannelEditSlot(ctx context.Context, client *redis.Client, channelID string) (time.Duration, error) {
+	wait, err := channelEditSlotScript.Run(ctx, client, []string{rediskey.ChannelEditRateLimit(channelID)},
+		ChannelEditWindow.Milliseconds(), ChannelEditLimit).Int64()
+	return time.Duration(wait) * time.Millisecond, err
+}
+
+// PendingEdits is how many game state message edits are waiting to be sent
+func PendingEdits(ctx context.Context, client *redis.Client) (int64, error) {
+	return client.ZCard(ctx, rediskey.PendingEditsZSet).Result()
+}
@@ -0,0 +1,149 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testEdit(title string) PendingEdit {
+	return PendingEdit{
+		ChannelID: "754465589958803548",
+		MessageID: "1057795428536234025",
+		Embed:     &discordgo.MessageEmbed{Title: title},
+	}
+}
+
+func TestScheduleEditCoalesces(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	added, err := ScheduleEdit(ctx, client, testEdit("first"), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added {
+		t.Error("expected the first edit to be a new one")
+	}
+	// e.g. from another node
+	if added, _ = ScheduleEdit(ctx, client, testEdit("second"), time.Second); added {
+		t.Error("an edit for a message with one pending already should be coalesced into it")
+	}
+	if pending, _ := PendingEdits(ctx, client); pending != 1 {
+		t.Errorf("expected 1 pending edit, got %d", pending)
+	}
+
+	edits, err := ClaimDueEdits(ctx, client, time.Now(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("the edit isn't due yet, but %d were claimed", len(edits))
+	}
+
+	edits, err = ClaimDueEdits(ctx, client, time.Now().Add(2*time.Second), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 || edits[0].Embed.Title != "second" {
+		t.Fatalf("expected only the latest edit to be claimed, got %+v", edits)
+	}
+	if edits, _ = ClaimDueEdits(ctx, client, time.Now().Add(2*time.Second), 10); len(edits) != 0 {
+		t.Error("an edit should only ever be claimed once")
+	}
+	if pending, _ := PendingEdits(ctx, client); pending != 0 {
+		t.Errorf("expected no pending edits, got %d", pending)
+	}
+}
+
+func TestRescheduleAndCancelEdit(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	ScheduleEdit(ctx, client, testEdit("old"), 0)
+	edits, _ := ClaimDueEdits(ctx, client, time.Now().Add(time.Second), 10)
+	if len(edits) != 1 {
+		t.Fatalf("expected to claim the edit, got %d", len(edits))
+	}
+	// a newer edit comes in while the old one is waiting on the rate limit
+	ScheduleEdit(ctx, client, testEdit("new"), 0)
+	if err := RescheduleEdit(ctx, client, edits[0], time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	edits, _ = ClaimDueEdits(ctx, client, time.Now().Add(time.Second), 10)
+	if len(edits) != 1 || edits[0].Embed.Title != "new" {
+		t.Errorf("rescheduling an old edit should not overwrite a newer one, got %+v", edits)
+	}
+
+	ScheduleEdit(ctx, client, testEdit("deleted"), 0)
+	if err := CancelEdit(ctx, client, testEdit("").MessageID); err != nil {
+		t.Fatal(err)
+	}
+	if edits, _ = ClaimDueEdits(ctx, client, time.Now().Add(time.Second), 10); len(edits) != 0 {
+		t.Errorf("expected a cancelled edit to never be sent, got %+v", edits)
+	}
+}
+
+func TestRetryEdit(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+	serverError := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusBadGateway}}
+	unknownMessage := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+	edit := testEdit("failed")
+	for attempt := 1; attempt < MaxEditAttempts; attempt++ {
+		retried, err := RetryEdit(ctx, client, edit, serverError, time.Now())
+		if err != nil || !retried {
+			t.Fatalf("expected attempt %d to be retried, got %v (%v)", attempt, retried, err)
+		}
+		edits, _ := ClaimDueEdits(ctx, client, time.Now().Add(time.Second), 10)
+		if len(edits) != 1 || edits[0].Attempts != attempt {
+			t.Fatalf("expected the edit to be back on the schedule after attempt %d, got %+v", attempt, edits)
+		}
+		edit = edits[0]
+	}
+	if retried, _ := RetryEdit(ctx, client, edit, serverError, time.Now()); retried {
+		t.Error("expected the edit to be dropped once it's out of attempts")
+	}
+
+	if retried, _ := RetryEdit(ctx, client, testEdit("deleted"), unknownMessage, time.Now()); retried {
+		t.Error("expected an edit Discord rejected not to be retried")
+	}
+	if retried, _ := RetryEdit(ctx, client, testEdit("timeout"), errors.New("i/o timeout"), time.Now()); !retried {
+		t.Error("expected an edit that never got a response to be retried")
+	}
+}
+
+func TestTakeChannelEditSlot(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestClient(t)
+	channelID := testEdit("").ChannelID
+
+	for i := 0; i < ChannelEditLimit; i++ {
+		wait, err := TakeChannelEditSlot(ctx, client, channelID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != 0 {
+			t.Fatalf("edit %d should be within the rate limit, got wait %s", i, wait)
+		}
+	}
+	wait, err := TakeChannelEditSlot(ctx, client, channelID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait <= 0 || wait > ChannelEditWindow {
+		t.Errorf("expected to wait for the window to reset, got %s", wait)
+	}
+	if wait, _ = TakeChannelEditSlot(ctx, client, "754465589958803549"); wait != 0 {
+		t.Error("other channels should have their own rate limit")
+	}
+
+	mr.FastForward(ChannelEditWindow)
+	if wait, _ = TakeChannelEditSlot(ctx, client, channelID); wait != 0 {
+		t.Errorf("expected the rate limit to reset after the window, got wait %s", wait)
+	}
+}
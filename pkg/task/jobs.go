@@ -3,8 +3,11 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"github.com/automuteus/automuteus/v8/pkg/rediskey"
 	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,13 +22,31 @@ const (
 )
 
 type Job struct {
+	// ID is the stream entry ID assigned by Redis; it is never serialized into the payload
+	ID      string      `json:"-"`
 	JobType JobType     `json:"type"`
 	Payload interface{} `json:"payload"`
 }
 
 const JobTTLSeconds = 3600
 
-func PushJob(ctx context.Context, redis *redis.Client, connCode string, jobType JobType, payload string) error {
+// JobGroup is the consumer group shared by every worker that processes capture jobs
+const JobGroup = "automuteus"
+
+// JobStreamMaxLen caps the stream length. XADD trims the oldest entries past it (approximately) whether they were acked
+// or not, so it's sized for the worst backlog: a stream nobody consumes lives for JobTTLSeconds, and even a busy capture
+// pushes well under an event a second for that hour
+const JobStreamMaxLen = 10000
+
+// JobReclaimTimeout is how long a job can sit unacknowledged with another consumer before it is reclaimed
+const JobReclaimTimeout = 30 * time.Second
+
+// JobMaxDeliveries is how many times a job is delivered before it is moved to the dead-letter list
+const JobMaxDeliveries = 5
+
+const jobField = "job"
+
+func PushJob(ctx context.Context, client *redis.Client, connCode string, jobType JobType, payload string) error {
 	job := Job{
 		JobType: jobType,
 		Payload: payload,
@@ -35,18 +56,31 @@ func PushJob(ctx context.Context, redis *redis.Client, connCode string, jobType
 		return err
 	}
 
-	count, err := redis.RPush(ctx, rediskey.JobNamespace+connCode, string(jBytes)).Result()
-	if err == nil {
-		notify(ctx, redis, connCode)
+	stream := rediskey.JobStream(connCode)
+	_, err = client.XAdd(ctx, &redis.XAddArgs{
+		Stream:       stream,
+		MaxLenApprox: JobStreamMaxLen,
+		Values:       map[string]interface{}{jobField: string(jBytes)},
+	}).Result()
+	if err != nil {
+		return err
 	}
+	client.Expire(ctx, stream, JobTTLSeconds*time.Second)
+	notify(ctx, client, connCode)
 
-	// new list
-	if count < 2 {
-		// log.Printf("Set TTL for List")
-		redis.Expire(ctx, rediskey.JobNamespace+connCode, JobTTLSeconds*time.Second)
-	}
+	return nil
+}
 
-	return err
+// EnsureJobGroup creates the consumer group (and the stream) for a game if it doesn't exist yet.
+// The group starts at the beginning of the stream, so jobs pushed before any worker subscribed are not lost
+func EnsureJobGroup(ctx context.Context, redis *redis.Client, connCode string) error {
+	stream := rediskey.JobStream(connCode)
+	err := redis.XGroupCreateMkStream(ctx, stream, JobGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	redis.Expire(ctx, stream, JobTTLSeconds*time.Second)
+	return nil
 }
 
 func notify(ctx context.Context, redis *redis.Client, connCode string) {
@@ -57,17 +91,160 @@ func Subscribe(ctx context.Context, redis *redis.Client, connCode string) *redis
 	return redis.Subscribe(ctx, rediskey.JobNamespace+connCode+":notify")
 }
 
-func PopJob(ctx context.Context, redis *redis.Client, connCode string) (Job, error) {
-	str, err := redis.LPop(ctx, rediskey.JobNamespace+connCode).Result()
-
-	j := Job{}
-	if err != nil {
+// PopJob returns the next job for this consumer. Jobs previously delivered to this consumer but never acked
+// (for example, because the worker crashed mid-game) are returned first, then new jobs.
+// Returns redis.Nil when there is nothing to process. Every job returned must be acked with AckJob
+func PopJob(ctx context.Context, client *redis.Client, connCode, consumer string) (Job, error) {
+	// our own pending entries first, so a restarted worker resumes where it left off
+	j, err := readGroup(ctx, client, connCode, consumer, "0")
+	if !errors.Is(err, redis.Nil) {
 		return j, err
 	}
-	err = json.Unmarshal([]byte(str), &j)
+	return readGroup(ctx, client, connCode, consumer, ">")
+}
+
+func readGroup(ctx context.Context, client *redis.Client, connCode, consumer, start string) (Job, error) {
+	stream := rediskey.JobStream(connCode)
+	for {
+		streams, err := client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    JobGroup,
+			Consumer: consumer,
+			Streams:  []string{stream, start},
+			Count:    1,
+			Block:    -1,
+		}).Result()
+		if err != nil {
+			return Job{}, err
+		}
+		if len(streams) == 0 || len(streams[0].Messages) == 0 {
+			return Job{}, redis.Nil
+		}
+		msg := streams[0].Messages[0]
+		// pending entries that were trimmed from the stream come back without values; ack them and move on
+		if len(msg.Values) == 0 {
+			client.XAck(ctx, stream, JobGroup, msg.ID)
+			continue
+		}
+		return parseJob(msg)
+	}
+}
+
+func parseJob(msg redis.XMessage) (Job, error) {
+	j := Job{ID: msg.ID}
+	str, ok := msg.Values[jobField].(string)
+	if !ok {
+		return j, errors.New("stream entry " + msg.ID + " has no job payload")
+	}
+	err := json.Unmarshal([]byte(str), &j)
+	j.ID = msg.ID
 	return j, err
 }
 
+// AckJob marks a job as processed so it is never redelivered
+func AckJob(ctx context.Context, redis *redis.Client, connCode, id string) error {
+	return redis.XAck(ctx, rediskey.JobStream(connCode), JobGroup, id).Err()
+}
+
+// ReclaimJobs takes ownership of jobs that another consumer has held for longer than minIdle without acking them,
+// so a replacement worker can resume a game whose previous worker died. Jobs that have already been delivered
+// JobMaxDeliveries times are moved to the dead-letter list instead of being retried again.
+// Returns the number of jobs claimed by this consumer
+func ReclaimJobs(ctx context.Context, client *redis.Client, connCode, consumer string, minIdle time.Duration) (int, error) {
+	stream := rediskey.JobStream(connCode)
+	pending, err := client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  JobGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  JobStreamMaxLen,
+	}).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	var claim []string
+	for _, p := range pending {
+		if p.Idle < minIdle {
+			continue
+		}
+		if p.RetryCount >= JobMaxDeliveries {
+			err = deadLetter(ctx, client, connCode, p.ID)
+			if err != nil {
+				return len(claim), err
+			}
+			continue
+		}
+		if p.Consumer != consumer {
+			claim = append(claim, p.ID)
+		}
+	}
+	if len(claim) == 0 {
+		return 0, nil
+	}
+
+	claimed, err := client.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    JobGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: claim,
+	}).Result()
+	return len(claimed), err
+}
+
+// deadLetter copies a poisoned job to the dead-letter list and acks it so it's no longer redelivered
+func deadLetter(ctx context.Context, client *redis.Client, connCode, id string) error {
+	stream := rediskey.JobStream(connCode)
+	msgs, err := client.XRange(ctx, stream, id, id).Result()
+	if err != nil {
+		return err
+	}
+	dlq := rediskey.JobDeadLetterList(connCode)
+	for _, msg := range msgs {
+		if str, ok := msg.Values[jobField].(string); ok {
+			client.RPush(ctx, dlq, str)
+		}
+	}
+	client.Expire(ctx, dlq, JobTTLSeconds*time.Second)
+	return client.XAck(ctx, stream, JobGroup, id).Err()
+}
+
+// LastJobTime is when the capture last pushed a job for the game, whether or not it was processed yet. Returns the zero
+// time if there are no jobs
+func LastJobTime(ctx context.Context, client *redis.Client, connCode string) (time.Time, error) {
+	msgs, err := client.XRevRangeN(ctx, rediskey.JobStream(connCode), "+", "-", 1).Result()
+	if err != nil || len(msgs) == 0 {
+		return time.Time{}, err
+	}
+	return entryTime(msgs[0].ID)
+}
+
+// SentAt is when the capture pushed the job, which can be well before it's processed, like when a worker catches up
+// on a backlog or reclaims a dead node's jobs. Jobs without a stream entry ID count as sent just now
+func (j Job) SentAt() time.Time {
+	sent, err := entryTime(j.ID)
+	if err != nil {
+		return time.Now()
+	}
+	return sent
+}
+
+// entryTime is when a stream entry was added, going by its ID
+func entryTime(id string) (time.Time, error) {
+	// stream entry IDs are <unix ms>-<sequence>
+	ms, _, _ := strings.Cut(id, "-")
+	unixMs, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(unixMs), nil
+}
+
+// DeleteJobStream removes the stream and its consumer group once a game has ended
+func DeleteJobStream(ctx context.Context, redis *redis.Client, connCode string) error {
+	return redis.Del(ctx, rediskey.JobStream(connCode)).Err()
+}
+
 func Ack(ctx context.Context, redis *redis.Client, connCode string) {
 	redis.Publish(ctx, rediskey.JobNamespace+connCode+":ack", true)
 }
@@ -0,0 +1,184 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/go-redis/redis/v8"
+	"testing"
+	"time"
+)
+
+func newTestClient(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	mr := miniredis.RunT(t)
+	return mr, redis.NewClient(&redis.Options{Addr: mr.Addr()})
+}
+
+func TestPushPopAck(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := EnsureJobGroup(ctx, client, "ABCDEF"); err != nil {
+		t.Fatal(err)
+	}
+	// creating the group twice must not fail
+	if err := EnsureJobGroup(ctx, client, "ABCDEF"); err != nil {
+		t.Error("EnsureJobGroup should ignore an existing group, got", err)
+	}
+
+	if err := PushJob(ctx, client, "ABCDEF", StateJob, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushJob(ctx, client, "ABCDEF", StateJob, "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := PopJob(ctx, client, "ABCDEF", "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" || job.JobType != StateJob || job.Payload.(string) != "1" {
+		t.Errorf("unexpected first job: %+v", job)
+	}
+
+	// an unacked job is redelivered to the same consumer before any new ones
+	again, err := PopJob(ctx, client, "ABCDEF", "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != job.ID {
+		t.Errorf("expected unacked job %s to be redelivered, got %s", job.ID, again.ID)
+	}
+
+	if err := AckJob(ctx, client, "ABCDEF", job.ID); err != nil {
+		t.Fatal(err)
+	}
+	next, err := PopJob(ctx, client, "ABCDEF", "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Payload.(string) != "2" {
+		t.Errorf("expected second job after ack, got %+v", next)
+	}
+	_ = AckJob(ctx, client, "ABCDEF", next.ID)
+
+	_, err = PopJob(ctx, client, "ABCDEF", "node-a")
+	if !errors.Is(err, redis.Nil) {
+		t.Error("expected redis.Nil for an empty stream, got", err)
+	}
+}
+
+func TestReclaimJobs(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := EnsureJobGroup(ctx, client, "ABCDEF"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushJob(ctx, client, "ABCDEF", LobbyJob, "{}"); err != nil {
+		t.Fatal(err)
+	}
+	// node-a reads the job and then "dies" without acking it
+	dead, err := PopJob(ctx, client, "ABCDEF", "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err := ReclaimJobs(ctx, client, "ABCDEF", "node-b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed != 1 {
+		t.Errorf("expected 1 job to be reclaimed, got %d", claimed)
+	}
+	job, err := PopJob(ctx, client, "ABCDEF", "node-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != dead.ID {
+		t.Errorf("expected node-b to resume job %s, got %s", dead.ID, job.ID)
+	}
+
+	// jobs that are still fresh must not be stolen
+	claimed, err = ReclaimJobs(ctx, client, "ABCDEF", "node-a", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed != 0 {
+		t.Errorf("expected no jobs to be reclaimed before the timeout, got %d", claimed)
+	}
+}
+
+func TestReclaimJobsDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	if err := EnsureJobGroup(ctx, client, "ABCDEF"); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushJob(ctx, client, "ABCDEF", PlayerJob, "poison"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < JobMaxDeliveries; i++ {
+		if _, err := PopJob(ctx, client, "ABCDEF", "node-a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err := ReclaimJobs(ctx, client, "ABCDEF", "node-b", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claimed != 0 {
+		t.Errorf("expected the poisoned job to be dead-lettered, not claimed; claimed %d", claimed)
+	}
+	n, err := client.LLen(ctx, rediskey.JobDeadLetterList("ABCDEF")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 job in the dead-letter list, got %d", n)
+	}
+	_, err = PopJob(ctx, client, "ABCDEF", "node-a")
+	if !errors.Is(err, redis.Nil) {
+		t.Error("expected the dead-lettered job to be acked, got", err)
+	}
+}
+
+func TestLastJobTime(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	last, err := LastJobTime(ctx, client, "ABCDEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.IsZero() {
+		t.Errorf("expected no last job time without any jobs, got %s", last)
+	}
+
+	before := time.Now().Add(-time.Second)
+	if err := PushJob(ctx, client, "ABCDEF", StateJob, "1"); err != nil {
+		t.Fatal(err)
+	}
+	last, err = LastJobTime(ctx, client, "ABCDEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Before(before) || last.After(time.Now().Add(time.Second)) {
+		t.Errorf("expected the last job time to be about now, got %s", last)
+	}
+}
+
+func TestJobSentAt(t *testing.T) {
+	// reclaimed from a node that died an hour ago
+	job := Job{ID: "1600000000123-4", JobType: StateJob, Payload: "1"}
+	if sent := job.SentAt(); !sent.Equal(time.UnixMilli(1600000000123)) {
+		t.Errorf("expected the job to have been sent when its stream entry was added, got %s", sent)
+	}
+	before := time.Now()
+	if sent := (Job{JobType: StateJob, Payload: "1"}).SentAt(); sent.Before(before) {
+		t.Errorf("expected a job without an entry ID to count as sent just now, got %s", sent)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/automuteus/automuteus/v8/pkg/premium"
 	"github.com/bwmarrin/discordgo"
+	"strconv"
 	"time"
 )
 
@@ -13,11 +14,21 @@ type UserModify struct {
 	UserID uint64 `json:"userID"`
 	Mute   bool   `json:"mute"`
 	Deaf   bool   `json:"deaf"`
+	// ChannelID, if set, also moves the user into this voice channel
+	ChannelID uint64 `json:"channelID,omitempty"`
+}
+
+// IsMove reports if the modification moves the user to another voice channel, which the capture client can't do
+func (um UserModify) IsMove() bool {
+	return um.ChannelID != 0
 }
 
 type UserModifyRequest struct {
 	Premium premium.Tier `json:"premium"`
-	Users   []UserModify `json:"users"`
+	// Users are dispatched in order, so put the ones that should be applied first at the front
+	Users []UserModify `json:"users"`
+	// Priority is how many of the Users at the front are applied before any of the others are started
+	Priority int `json:"priority,omitempty"`
 }
 
 type ModifyTask struct {
@@ -43,8 +54,9 @@ func NewModifyTask(guildID, userID uint64, params PatchParams) ModifyTask {
 }
 
 type PatchParams struct {
-	Deaf bool `json:"deaf"`
-	Mute bool `json:"mute"`
+	Deaf      bool   `json:"deaf"`
+	Mute      bool   `json:"mute"`
+	ChannelID string `json:"channel_id,omitempty"`
 }
 
 func ApplyMuteDeaf(sess *discordgo.Session, guildID, userID string, mute, deaf bool) error {
@@ -57,6 +69,21 @@ func ApplyMuteDeaf(sess *discordgo.Session, guildID, userID string, mute, deaf b
 	return err
 }
 
+// ApplyUserModify issues the mute/deafen, and the move if there is one, in a single request
+func ApplyUserModify(sess *discordgo.Session, guildID string, request UserModify) error {
+	p := PatchParams{
+		Deaf: request.Deaf,
+		Mute: request.Mute,
+	}
+	if request.IsMove() {
+		p.ChannelID = strconv.FormatUint(request.ChannelID, 10)
+	}
+	userID := strconv.FormatUint(request.UserID, 10)
+
+	_, err := sess.RequestWithBucketID("PATCH", discordgo.EndpointGuildMember(guildID, userID), p, discordgo.EndpointGuildMember(guildID, ""))
+	return err
+}
+
 // a response indicating how the mutes/deafens were issued, and if ratelimits occurred
 type MuteDeafenSuccessCounts struct {
 	Worker    int64 `json:"worker"`
@@ -0,0 +1,135 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"github.com/automuteus/automuteus/v8/pkg/rediskey"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GameLeaseTTL is how long a node owns a game without renewing its lease. If the node dies, any other node can adopt
+// the game once the lease expires
+const GameLeaseTTL = 30 * time.Second
+
+// GameLeaseRenewInterval is how often the owner renews its lease; a few renewals can fail before the lease is lost
+const GameLeaseRenewInterval = GameLeaseTTL / 3
+
+type ControlMessage string
+
+const (
+	// ControlEnd tells the owner of a game to stop processing it and end it
+	ControlEnd ControlMessage = "end"
+	// ControlPause tells the owner of a game to pause it, or to resume it if it's already paused
+	ControlPause ControlMessage = "pause"
+)
+
+// GameLease identifies a game whose ownership can be adopted
+type GameLease struct {
+	GuildID     string
+	ConnectCode string
+}
+
+func (l GameLease) member() string {
+	return l.GuildID + ":" + l.ConnectCode
+}
+
+func parseLease(member string) (GameLease, bool) {
+	guildID, connectCode, ok := strings.Cut(member, ":")
+	return GameLease{GuildID: guildID, ConnectCode: connectCode}, ok
+}
+
+// takes (or extends) the lease if nobody else holds it. With ARGV[5] set, only extends a lease we already hold
+var leaseScript = redis.NewScript(`
+local owner = redis.call('GET', KEYS[1])
+if owner ~= ARGV[1] and (owner or ARGV[5] == '1') then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[4])
+return 1
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	redis.call('ZREM', KEYS[2], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// AcquireGameLease makes node the owner of the game, if no other node owns it already (or the previous owner's lease
+// expired). Returns true if node owns the game afterwards
+func AcquireGameLease(ctx context.Context, client *redis.Client, lease GameLease, node string) (bool, error) {
+	return runLease(ctx, client, lease, node, false)
+}
+
+// RenewGameLease extends node's lease on the game. Returns false if node doesn't own the game anymore, because its
+// lease expired and another node adopted the game
+func RenewGameLease(ctx context.Context, client *redis.Client, lease GameLease, node string) (bool, error) {
+	return runLease(ctx, client, lease, node, true)
+}
+
+func runLease(ctx context.Context, client *redis.Client, lease GameLease, node string, mustOwn bool) (bool, error) {
+	expiry := time.Now().Add(GameLeaseTTL)
+	onlyRenew := "0"
+	if mustOwn {
+		onlyRenew = "1"
+	}
+	res, err := leaseScript.Run(ctx, client,
+		[]string{rediskey.GameOwner(lease.ConnectCode), rediskey.GameLeasesZSet},
+		node, GameLeaseTTL.Milliseconds(), expiry.UnixMilli(), lease.member(), onlyRenew).Int()
+	return res == 1, err
+}
+
+// ReleaseGameLease gives up node's ownership of the game, so it's not adopted by anyone else
+func ReleaseGameLease(ctx context.Context, client *redis.Client, lease GameLease, node string) error {
+	return releaseScript.Run(ctx, client,
+		[]string{rediskey.GameOwner(lease.ConnectCode), rediskey.GameLeasesZSet},
+		node, lease.member()).Err()
+}
+
+// GameOwner returns the node that owns the game, or "" if nobody does
+func GameOwner(ctx context.Context, client *redis.Client, connCode string) (string, error) {
+	owner, err := client.Get(ctx, rediskey.GameOwner(connCode)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return owner, err
+}
+
+// ExpiredGameLeases lists the games whose owner stopped renewing its lease before now
+func ExpiredGameLeases(ctx context.Context, client *redis.Client, now time.Time) ([]GameLease, error) {
+	members, err := client.ZRangeByScore(ctx, rediskey.GameLeasesZSet, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now.UnixMilli(), 10),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	leases := make([]GameLease, 0, len(members))
+	for _, member := range members {
+		if lease, ok := parseLease(member); ok {
+			leases = append(leases, lease)
+		}
+	}
+	return leases, nil
+}
+
+// ForgetGameLease drops an expired lease from the index, for games that ended while they had no owner
+func ForgetGameLease(ctx context.Context, client *redis.Client, lease GameLease) error {
+	return client.ZRem(ctx, rediskey.GameLeasesZSet, lease.member()).Err()
+}
+
+// PublishControl sends a control message to whichever node owns the game. Returns the number of nodes that received
+// it; 0 means the game has no owner right now, and the caller has to handle the message itself
+func PublishControl(ctx context.Context, client *redis.Client, connCode string, msg ControlMessage) (int64, error) {
+	return client.Publish(ctx, rediskey.GameControl(connCode), string(msg)).Result()
+}
+
+func SubscribeControl(ctx context.Context, client *redis.Client, connCode string) *redis.PubSub {
+	return client.Subscribe(ctx, rediskey.GameControl(connCode))
+}
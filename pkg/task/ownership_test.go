@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var testLease = GameLease{GuildID: "141082723635691520", ConnectCode: "ABCDEF"}
+
+func TestGameLease(t *testing.T) {
+	ctx := context.Background()
+	mr, client := newTestClient(t)
+
+	owned, err := AcquireGameLease(ctx, client, testLease, "node-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !owned {
+		t.Fatal("expected node-a to own an unowned game")
+	}
+	// re-acquiring our own lease just extends it
+	if owned, _ = AcquireGameLease(ctx, client, testLease, "node-a"); !owned {
+		t.Error("expected node-a to keep owning the game")
+	}
+	if owned, _ = AcquireGameLease(ctx, client, testLease, "node-b"); owned {
+		t.Error("node-b should not be able to take a game node-a holds a lease on")
+	}
+	if owned, _ = RenewGameLease(ctx, client, testLease, "node-b"); owned {
+		t.Error("node-b should not be able to renew a lease it doesn't hold")
+	}
+	if owner, _ := GameOwner(ctx, client, testLease.ConnectCode); owner != "node-a" {
+		t.Errorf("expected node-a to own the game, got %q", owner)
+	}
+
+	// node-a dies and stops renewing
+	mr.FastForward(GameLeaseTTL + time.Second)
+	if owner, _ := GameOwner(ctx, client, testLease.ConnectCode); owner != "" {
+		t.Errorf("expected the lease to have expired, got owner %q", owner)
+	}
+	expired, err := ExpiredGameLeases(ctx, client, time.Now().Add(GameLeaseTTL+time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0] != testLease {
+		t.Errorf("expected the lease to be listed as expired, got %v", expired)
+	}
+
+	if owned, _ = AcquireGameLease(ctx, client, testLease, "node-b"); !owned {
+		t.Fatal("expected node-b to adopt the game once node-a's lease expired")
+	}
+	// node-a comes back, and has to notice it lost the game
+	if owned, _ = RenewGameLease(ctx, client, testLease, "node-a"); owned {
+		t.Error("node-a should not be able to renew a lease node-b took over")
+	}
+	if expired, _ = ExpiredGameLeases(ctx, client, time.Now()); len(expired) != 0 {
+		t.Errorf("a freshly adopted lease should not be expired, got %v", expired)
+	}
+
+	// only the owner can release the lease
+	if err = ReleaseGameLease(ctx, client, testLease, "node-a"); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := GameOwner(ctx, client, testLease.ConnectCode); owner != "node-b" {
+		t.Errorf("node-a released a lease it doesn't hold, owner is now %q", owner)
+	}
+	if err = ReleaseGameLease(ctx, client, testLease, "node-b"); err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := GameOwner(ctx, client, testLease.ConnectCode); owner != "" {
+		t.Errorf("expected no owner after releasing, got %q", owner)
+	}
+	if expired, _ = ExpiredGameLeases(ctx, client, time.Now().Add(time.Hour)); len(expired) != 0 {
+		t.Errorf("a released lease should never be adopted, got %v", expired)
+	}
+}
+
+func TestControlMessages(t *testing.T) {
+	ctx := context.Background()
+	_, client := newTestClient(t)
+
+	received, err := PublishControl(ctx, client, testLease.ConnectCode, ControlEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != 0 {
+		t.Errorf("nobody is subscribed, but %d nodes received the message", received)
+	}
+
+	sub := SubscribeControl(ctx, client, testLease.ConnectCode)
+	defer sub.Close()
+	// wait for the subscription to be confirmed before publishing
+	if _, err = sub.Receive(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	received, err = PublishControl(ctx, client, testLease.ConnectCode, ControlPause)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received != 1 {
+		t.Errorf("expected the subscriber to receive the message, got %d receivers", received)
+	}
+	select {
+	case msg := <-sub.Channel():
+		if ControlMessage(msg.Payload) != ControlPause {
+			t.Errorf("expected a pause message, got %q", msg.Payload)
+		}
+	case <-time.After(time.Second):
+		t.Error("timed out waiting for the control message")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/automuteus/automuteus/v8/bot"
+	"github.com/automuteus/automuteus/v8/pkg/settings"
+	storage2 "github.com/automuteus/automuteus/v8/pkg/storage"
+)
+
+// replayMain re-runs a recorded match through the bot and prints every mute/deafen decision as one JSON object per line.
+// Events are read from a JSON file (-file) or from Postgres by game ID (-match, using the POSTGRES_* variables)
+func replayMain(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	file := fs.String("file", "", "JSON file containing the game_events of one match")
+	match := fs.String("match", "", "game_id of a match to load from Postgres")
+	settingsFile := fs.String("settings", "", "optional JSON file with the guild settings to replay with")
+	keepDelays := fs.Bool("delays", false, "wait for the configured mute delays instead of replaying instantly")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var events []*storage2.PostgresGameEvent
+	switch {
+	case *file != "":
+		events, err = bot.LoadReplayEventsFromFile(*file)
+	case *match != "":
+		events, err = loadReplayEventsFromPostgres(*match)
+	default:
+		return errors.New("replay needs either -file or -match")
+	}
+	if err != nil {
+		return err
+	}
+
+	opts := bot.ReplayOptions{KeepDelays: *keepDelays}
+	if *settingsFile != "" {
+		f, err := os.ReadFile(*settingsFile)
+		if err != nil {
+			return err
+		}
+		// start from the defaults so a partial settings file still works
+		sett := settings.MakeGuildSettings()
+		err = json.Unmarshal(f, sett)
+		if err != nil {
+			return err
+		}
+		opts.Settings = sett
+	}
+
+	decisions, err := bot.Replay(events, opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for _, v := range decisions {
+		err = enc.Encode(v)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "replayed %d events, %d mute/deafen decisions\n", len(events), len(decisions))
+	return nil
+}
+
+func loadReplayEventsFromPostgres(matchID string) ([]*storage2.PostgresGameEvent, error) {
+	psql, err := psqlFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	defer psql.Close()
+	return psql.GetGameEvents(matchID)
+}
@@ -0,0 +1,9 @@
+package storage
+
+import "embed"
+
+// Migrations are the numbered Postgres schema migrations, NNNN_name.up.sql and NNNN_name.down.sql
+// (see storage.LoadMigrations in pkg/storage)
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
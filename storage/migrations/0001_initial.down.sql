@@ -0,0 +1,5 @@
+drop table if exists users_games;
+drop table if exists game_events;
+drop table if exists users;
+drop table if exists games;
+drop table if exists guilds;
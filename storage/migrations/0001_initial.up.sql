@@ -1,3 +1,6 @@
+-- the schema as it was when it lived in a single postgres.sql. Everything is "if not exists", so databases that were
+-- created by postgres.sql can run this to start tracking migrations
+
 create table if not exists guilds
 (
     guild_id numeric PRIMARY KEY,
@@ -68,4 +71,4 @@ create index if not exists users_games_role_index ON users_games (player_role);
 create index if not exists users_games_won_index ON users_games (player_won); --query games by win status
 
 create index if not exists game_events_game_id_index on game_events (game_id); --query for game events by the game ID
-create index if not exists game_events_user_id_index on game_events (user_id); --query for game events by the user ID
\ No newline at end of file
+create index if not exists game_events_user_id_index on game_events (user_id); --query for game events by the user ID
@@ -0,0 +1,14 @@
+-- in-game names users were linked to, so they can be linked automatically in later games
+create table if not exists player_aliases
+(
+    guild_id        numeric     NOT NULL,
+    user_id         numeric     NOT NULL, --actually references users, but users who never opted in don't have a row
+    player_name     VARCHAR(10) NOT NULL,
+    player_color    smallint,             --color the name was last linked with; null if unknown (migrated from the old redis cache)
+    times_confirmed integer     NOT NULL DEFAULT 1,
+    last_seen       timestamptz NOT NULL,
+    PRIMARY KEY (guild_id, user_id, player_name)
+);
+
+create index if not exists player_aliases_name_index on player_aliases (guild_id, player_name); --query aliases by in-game name
+create index if not exists player_aliases_user_id_index on player_aliases (user_id); --query aliases by user ID
@@ -0,0 +1,3 @@
+alter table game_events alter column event_time type integer using extract(epoch from event_time)::integer;
+alter table games alter column end_time type integer using coalesce(extract(epoch from end_time)::integer, -1);
+alter table games alter column start_time type integer using extract(epoch from start_time)::integer;
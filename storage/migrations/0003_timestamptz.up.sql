@@ -0,0 +1,5 @@
+-- unix seconds in integer columns overflow in 2038. Events get millisecond precision, so that things that happen in
+-- the same second (two kills, a kill and a meeting) still sort in the order they happened
+alter table games alter column start_time type timestamptz using to_timestamp(start_time);
+alter table games alter column end_time type timestamptz using case when end_time < 0 then null else to_timestamp(end_time) end; --null until the game is over
+alter table game_events alter column event_time type timestamptz(3) using to_timestamp(event_time);
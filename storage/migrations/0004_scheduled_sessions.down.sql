@@ -0,0 +1,2 @@
+drop table if exists scheduled_session_rsvps;
+drop table if exists scheduled_sessions;
@@ -0,0 +1,25 @@
+-- recurring game nights; the bot posts a reminder ahead of start_time and starts the game at start_time
+create table if not exists scheduled_sessions
+(
+    session_id          bigserial PRIMARY KEY,
+    guild_id            numeric     NOT NULL references guilds ON DELETE CASCADE,
+    text_channel_id     numeric     NOT NULL,
+    voice_channel_id    numeric     NOT NULL,
+    host_role_id        numeric,                --role pinged by the reminder; null to only ping the people who RSVP'd
+    created_by          numeric     NOT NULL,   --becomes the game's leader when it's started
+    title               VARCHAR(100) NOT NULL,
+    start_time          timestamptz NOT NULL,   --the next occurrence; moved forward every time the session starts
+    timezone            text        NOT NULL,   --IANA name; recurrences keep the same wall clock time in it
+    recurrence          text        NOT NULL,   --once, daily or weekly
+    reminder_message_id numeric                 --null until the reminder for the next occurrence is posted
+);
+
+create table if not exists scheduled_session_rsvps
+(
+    session_id bigint  NOT NULL references scheduled_sessions ON DELETE CASCADE,
+    user_id    numeric NOT NULL,
+    PRIMARY KEY (session_id, user_id)
+);
+
+create index if not exists scheduled_sessions_guild_id_index on scheduled_sessions (guild_id); --query sessions by guild ID
+create index if not exists scheduled_sessions_start_time_index on scheduled_sessions (start_time); --query sessions that are due
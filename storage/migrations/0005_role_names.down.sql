@@ -0,0 +1,2 @@
+drop index if exists users_games_role_name_index;
+alter table users_games drop column if exists player_role_name;
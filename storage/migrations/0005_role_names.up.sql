@@ -0,0 +1,8 @@
+-- the role each player had, by name (e.g. "Sheriff" in modded games); player_role stays the team it played for
+alter table users_games add column if not exists player_role_name VARCHAR(32) NOT NULL DEFAULT '';
+
+-- games from before roles were recorded only had crewmates and impostors
+update users_games set player_role_name = 'Crewmate' where player_role = 0 and player_role_name = '';
+update users_games set player_role_name = 'Impostor' where player_role = 1 and player_role_name = '';
+
+create index if not exists users_games_role_name_index on users_games (guild_id, player_role_name); --query games by role
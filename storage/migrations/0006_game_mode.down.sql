@@ -0,0 +1,3 @@
+drop index if exists users_games_game_mode_index;
+alter table users_games drop column if exists game_mode;
+alter table games drop column if exists game_mode;
@@ -0,0 +1,6 @@
+-- the mode each game was played in, so hide-and-seek games can be kept apart from classic stats.
+-- users_games holds a copy like it does guild_id, so player stats don't need to join games
+alter table games add column if not exists game_mode VARCHAR(32) NOT NULL DEFAULT 'classic';
+alter table users_games add column if not exists game_mode VARCHAR(32) NOT NULL DEFAULT 'classic';
+
+create index if not exists users_games_game_mode_index on users_games (guild_id, game_mode); --query games by mode
@@ -0,0 +1,2 @@
+drop table if exists rating_history;
+drop table if exists player_ratings;
@@ -0,0 +1,25 @@
+-- skill ratings (see pkg/rating), one for every role (crewmate or impostor) a user has played on a guild
+create table if not exists player_ratings
+(
+    guild_id    numeric          NOT NULL references guilds ON DELETE CASCADE,
+    user_id     numeric          NOT NULL references users ON DELETE CASCADE,
+    player_role smallint         NOT NULL,
+    rating      double precision NOT NULL,
+    games       integer          NOT NULL, --rated games, not every game played
+    PRIMARY KEY (guild_id, user_id, player_role)
+);
+
+-- how every rated game moved the ratings of the players in it
+create table if not exists rating_history
+(
+    game_id       bigint           NOT NULL references games ON DELETE CASCADE,
+    guild_id      numeric          NOT NULL references guilds ON DELETE CASCADE,
+    user_id       numeric          NOT NULL references users ON DELETE CASCADE,
+    player_role   smallint         NOT NULL,
+    rating_before double precision NOT NULL,
+    rating_after  double precision NOT NULL,
+    PRIMARY KEY (game_id, user_id)
+);
+
+create index if not exists player_ratings_leaderboard_index on player_ratings (guild_id, player_role, rating); --query the best rated players
+create index if not exists rating_history_user_index on rating_history (guild_id, user_id, player_role); --query a user's rating over time
@@ -0,0 +1,2 @@
+drop index if exists games_start_time_index;
+drop table if exists seasons;
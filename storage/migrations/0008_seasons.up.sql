@@ -0,0 +1,15 @@
+-- stats windows a guild defines; stats can be viewed for a season, and a summary is posted when one ends
+create table if not exists seasons
+(
+    season_id      bigserial PRIMARY KEY,
+    guild_id       numeric      NOT NULL references guilds ON DELETE CASCADE,
+    name           VARCHAR(100) NOT NULL,
+    start_time     timestamptz  NOT NULL,
+    end_time       timestamptz  NOT NULL,          --exclusive
+    summary_posted boolean      NOT NULL DEFAULT false,
+    UNIQUE (guild_id, name)
+);
+
+create index if not exists seasons_guild_id_index on seasons (guild_id); --query seasons by guild ID
+create index if not exists seasons_end_time_index on seasons (end_time) where summary_posted = false; --query seasons that just ended
+create index if not exists games_start_time_index on games (guild_id, start_time); --query games in a season